@@ -0,0 +1,145 @@
+package apng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/optimize"
+)
+
+// fcTL dispose and blend operations
+const (
+	disposeNone = 0
+	blendSource = 0
+)
+
+// Frame is animation image shown for Duration
+type Frame struct {
+	Image    *image.NRGBA
+	Duration time.Duration
+}
+
+// Encode writes animated png of frames played loops times (0 loops forever). Frames must have the same size,
+// consecutive identical frames are merged with their durations summed and every frame after first stores only
+// area changed since previous one. Frames are encoded losslessly with straight alpha, indexed when they have
+// at most 256 colors together
+func Encode(frames []Frame, loops int) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("animation has no frames")
+	}
+	if loops < 0 {
+		return nil, fmt.Errorf("invalid loop count %d", loops)
+	}
+
+	size := frames[0].Image.Rect.Size()
+	if size.X == 0 || size.Y == 0 {
+		return nil, errors.New("animation frames are empty")
+	}
+	for _, frame := range frames {
+		if frame.Image.Rect.Size() != size {
+			return nil, fmt.Errorf("animation frames have different sizes %v and %v", size, frame.Image.Rect.Size())
+		}
+	}
+
+	frames = mergeFrames(frames)
+	images := make([]*image.NRGBA, len(frames))
+	for i, frame := range frames {
+		images[i] = frame.Image
+	}
+	encoder := optimize.NewEncoder(images)
+
+	var buf bytes.Buffer
+	encoder.WriteHeader(&buf, size.X, size.Y)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(loops))
+	optimize.WriteChunk(&buf, "acTL", actl)
+
+	sequence := uint32(0)
+	for i, frame := range frames {
+		// default image is first frame, so it covers whole canvas
+		area := image.Rectangle{Max: size}
+		if i > 0 {
+			area = changedArea(frames[i-1].Image, frame.Image)
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(area.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(area.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], uint32(area.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:], uint32(area.Min.Y))
+		num, den := delay(frame.Duration)
+		binary.BigEndian.PutUint16(fctl[20:], num)
+		binary.BigEndian.PutUint16(fctl[22:], den)
+		fctl[24] = disposeNone
+		// source blending replaces area pixels, so transparent pixels of frame clear previous ones
+		fctl[25] = blendSource
+		optimize.WriteChunk(&buf, "fcTL", fctl)
+		sequence++
+
+		data, err := encoder.ImageData(subImage(frame.Image, area))
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			optimize.WriteChunk(&buf, "IDAT", data)
+			continue
+		}
+
+		fdat := make([]byte, 4, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, sequence)
+		optimize.WriteChunk(&buf, "fdAT", append(fdat, data...))
+		sequence++
+	}
+
+	optimize.WriteChunk(&buf, "IEND", nil)
+	return buf.Bytes(), nil
+}
+
+// mergeFrames joins consecutive frames with identical pixels
+func mergeFrames(frames []Frame) []Frame {
+	merged := []Frame{frames[0]}
+	for _, frame := range frames[1:] {
+		last := &merged[len(merged)-1]
+		if changedArea(last.Image, frame.Image).Empty() {
+			last.Duration += frame.Duration
+			continue
+		}
+		merged = append(merged, frame)
+	}
+	return merged
+}
+
+// changedArea returns bounds of pixels differing between images of the same size, relative to image origin
+func changedArea(prev, cur *image.NRGBA) image.Rectangle {
+	var area image.Rectangle
+	size := cur.Rect.Size()
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if prev.NRGBAAt(prev.Rect.Min.X+x, prev.Rect.Min.Y+y) != cur.NRGBAAt(cur.Rect.Min.X+x, cur.Rect.Min.Y+y) {
+				area = area.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return area
+}
+
+func subImage(img *image.NRGBA, area image.Rectangle) *image.NRGBA {
+	return img.SubImage(area.Add(img.Rect.Min)).(*image.NRGBA)
+}
+
+// delay converts duration to fcTL delay fraction, milliseconds or centiseconds for long frames
+func delay(d time.Duration) (uint16, uint16) {
+	ms := d.Milliseconds()
+	if ms <= 0xffff {
+		return uint16(max(ms, 0)), 1000
+	}
+	return uint16(min(ms/10, 0xffff)), 100
+}
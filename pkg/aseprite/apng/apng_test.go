@@ -0,0 +1,151 @@
+package apng_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/apng"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type chunk struct {
+	name string
+	data []byte
+}
+
+func readChunks(t *testing.T, data []byte) []chunk {
+	require.True(t, bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")))
+	var chunks []chunk
+	for offset := 8; offset < len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		chunks = append(chunks, chunk{name: string(data[offset+4 : offset+8]), data: data[offset+8 : offset+8+length]})
+		offset += 12 + length
+	}
+	return chunks
+}
+
+func writeChunk(buf *bytes.Buffer, name string, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(name)
+	buf.Write(data)
+	_ = binary.Write(buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(name), data...)))
+}
+
+type decodedFrame struct {
+	image    *image.NRGBA
+	duration time.Duration
+}
+
+// decodeFrames renders animation frames by decoding every frame data as standalone png with frame size
+func decodeFrames(t *testing.T, data []byte) ([]decodedFrame, uint32) {
+	chunks := readChunks(t, data)
+	require.Equal(t, "IHDR", chunks[0].name)
+	header := chunks[0].data
+	canvas := image.NewNRGBA(image.Rect(0, 0, int(binary.BigEndian.Uint32(header[0:])), int(binary.BigEndian.Uint32(header[4:]))))
+
+	var (
+		palette  []chunk
+		frames   []decodedFrame
+		fctl     []byte
+		loops    uint32
+		sequence uint32
+	)
+	for _, c := range chunks[1:] {
+		switch c.name {
+		case "PLTE", "tRNS":
+			palette = append(palette, c)
+		case "acTL":
+			loops = binary.BigEndian.Uint32(c.data[4:])
+		case "fcTL":
+			require.Equal(t, sequence, binary.BigEndian.Uint32(c.data))
+			sequence++
+			fctl = c.data
+		case "IDAT", "fdAT":
+			content := c.data
+			if c.name == "fdAT" {
+				require.Equal(t, sequence, binary.BigEndian.Uint32(c.data))
+				sequence++
+				content = c.data[4:]
+			}
+
+			frameHeader := append([]byte{}, header...)
+			copy(frameHeader, fctl[4:12])
+			var buf bytes.Buffer
+			buf.WriteString("\x89PNG\r\n\x1a\n")
+			writeChunk(&buf, "IHDR", frameHeader)
+			for _, p := range palette {
+				writeChunk(&buf, p.name, p.data)
+			}
+			writeChunk(&buf, "IDAT", content)
+			writeChunk(&buf, "IEND", nil)
+
+			img, err := png.Decode(&buf)
+			require.NoError(t, err)
+			offset := image.Pt(int(binary.BigEndian.Uint32(fctl[12:])), int(binary.BigEndian.Uint32(fctl[16:])))
+			// source blending
+			draw.Draw(canvas, img.Bounds().Add(offset), img, image.Point{}, draw.Src)
+
+			num, den := binary.BigEndian.Uint16(fctl[20:]), binary.BigEndian.Uint16(fctl[22:])
+			frame := image.NewNRGBA(canvas.Rect)
+			copy(frame.Pix, canvas.Pix)
+			frames = append(frames, decodedFrame{image: frame, duration: time.Duration(num) * time.Second / time.Duration(den)})
+		}
+	}
+	return frames, loops
+}
+
+func frameImage(dot image.Point, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	img.SetNRGBA(dot.X, dot.Y, c)
+	return img
+}
+
+func TestEncode(t *testing.T) {
+	translucent := color.NRGBA{R: 200, G: 10, B: 10, A: 100}
+	opaque := color.NRGBA{B: 255, A: 255}
+	frames := []apng.Frame{
+		{Image: frameImage(image.Pt(0, 0), translucent), Duration: 100 * time.Millisecond},
+		{Image: frameImage(image.Pt(2, 1), opaque), Duration: 50 * time.Millisecond},
+		{Image: frameImage(image.Pt(2, 1), opaque), Duration: 70 * time.Millisecond},
+		{Image: frameImage(image.Pt(3, 2), translucent), Duration: 80 * time.Second},
+	}
+
+	data, err := apng.Encode(frames, 3)
+	require.NoError(t, err)
+
+	// default image is first frame for viewers without animation support
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, translucent, color.NRGBAModel.Convert(img.At(0, 0)))
+
+	decoded, loops := decodeFrames(t, data)
+	assert.Equal(t, uint32(3), loops)
+	require.Len(t, decoded, 3)
+
+	assert.Equal(t, frames[0].Image.Pix, decoded[0].image.Pix)
+	assert.Equal(t, 100*time.Millisecond, decoded[0].duration)
+	// identical frames are merged, translucent pixel of previous frame is cleared
+	assert.Equal(t, frames[1].Image.Pix, decoded[1].image.Pix)
+	assert.Equal(t, 120*time.Millisecond, decoded[1].duration)
+	assert.Equal(t, frames[3].Image.Pix, decoded[2].image.Pix)
+	assert.Equal(t, 80*time.Second, decoded[2].duration)
+}
+
+func TestEncodeErrors(t *testing.T) {
+	_, err := apng.Encode(nil, 0)
+	assert.Error(t, err)
+
+	_, err = apng.Encode([]apng.Frame{
+		{Image: image.NewNRGBA(image.Rect(0, 0, 2, 2))},
+		{Image: image.NewNRGBA(image.Rect(0, 0, 3, 2))},
+	}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package asefile
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// drawBlended draws src over dst with blend mode and opacity. Blended color is mixed with source color
+// by backdrop alpha (W3C compositing, as Aseprite does), so blend modes have no effect over transparent pixels
+func drawBlended(dst, src *image.NRGBA, mode BlendMode, opacity int) {
+	if opacity <= 0 {
+		return
+	}
+
+	if mode == BlendNormal {
+		mask := image.NewUniform(color.Alpha{A: uint8(opacity)})
+		draw.DrawMask(dst, src.Rect, src, src.Rect.Min, mask, image.Point{}, draw.Over)
+		return
+	}
+
+	r := dst.Rect.Intersect(src.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s := src.NRGBAAt(x, y)
+			if s.A == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, blendPixel(dst.NRGBAAt(x, y), s, mode, opacity))
+		}
+	}
+}
+
+// blendPixel composites source color over backdrop with blend mode and opacity
+func blendPixel(b, s color.NRGBA, mode BlendMode, opacity int) color.NRGBA {
+	sa := float64(s.A) / 255 * float64(opacity) / 255
+	ba := float64(b.A) / 255
+	if sa == 0 {
+		return b
+	}
+
+	cb := [3]float64{float64(b.R) / 255, float64(b.G) / 255, float64(b.B) / 255}
+	cs := [3]float64{float64(s.R) / 255, float64(s.G) / 255, float64(s.B) / 255}
+
+	blended := blendColors(mode, cb, cs)
+	outA := sa + ba*(1-sa)
+
+	var out [3]uint8
+	for i := range out {
+		mixed := (1-ba)*cs[i] + ba*blended[i]
+		out[i] = unit8((mixed*sa + cb[i]*ba*(1-sa)) / outA)
+	}
+	return color.NRGBA{R: out[0], G: out[1], B: out[2], A: unit8(outA)}
+}
+
+// blendColors returns blend mode result of backdrop and source colors with channels in [0, 1]
+func blendColors(mode BlendMode, b, s [3]float64) [3]float64 {
+	switch mode {
+	case BlendHue:
+		return setLum(setSat(s, sat(b)), lum(b))
+	case BlendSaturation:
+		return setLum(setSat(b, sat(s)), lum(b))
+	case BlendColor:
+		return setLum(s, lum(b))
+	case BlendLuminosity:
+		return setLum(b, lum(s))
+	}
+
+	var out [3]float64
+	for i := range out {
+		out[i] = blendChannel(mode, b[i], s[i])
+	}
+	return out
+}
+
+// blendChannel returns separable blend mode result of backdrop and source channels
+func blendChannel(mode BlendMode, b, s float64) float64 {
+	switch mode {
+	case BlendMultiply:
+		return b * s
+	case BlendScreen:
+		return b + s - b*s
+	case BlendOverlay:
+		return blendChannel(BlendHardLight, s, b)
+	case BlendDarken:
+		return math.Min(b, s)
+	case BlendLighten:
+		return math.Max(b, s)
+	case BlendColorDodge:
+		switch {
+		case b == 0:
+			return 0
+		case s == 1:
+			return 1
+		}
+		return math.Min(1, b/(1-s))
+	case BlendColorBurn:
+		switch {
+		case b == 1:
+			return 1
+		case s == 0:
+			return 0
+		}
+		return 1 - math.Min(1, (1-b)/s)
+	case BlendHardLight:
+		if s <= 0.5 {
+			return b * 2 * s
+		}
+		return blendChannel(BlendScreen, b, 2*s-1)
+	case BlendSoftLight:
+		if s <= 0.5 {
+			return b - (1-2*s)*b*(1-b)
+		}
+		d := math.Sqrt(b)
+		if b <= 0.25 {
+			d = ((16*b-12)*b + 4) * b
+		}
+		return b + (2*s-1)*(d-b)
+	case BlendDifference:
+		return math.Abs(b - s)
+	case BlendExclusion:
+		return b + s - 2*b*s
+	case BlendAddition:
+		return math.Min(1, b+s)
+	case BlendSubtract:
+		return math.Max(0, b-s)
+	case BlendDivide:
+		switch {
+		case b == 0:
+			return 0
+		case b >= s:
+			return 1
+		}
+		return b / s
+	default:
+		return s
+	}
+}
+
+func lum(c [3]float64) float64 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+func sat(c [3]float64) float64 {
+	return max(c[0], c[1], c[2]) - min(c[0], c[1], c[2])
+}
+
+// setLum returns color with luminosity l, channels out of range are clipped keeping luminosity
+func setLum(c [3]float64, l float64) [3]float64 {
+	d := l - lum(c)
+	c = [3]float64{c[0] + d, c[1] + d, c[2] + d}
+
+	l = lum(c)
+	lo, hi := min(c[0], c[1], c[2]), max(c[0], c[1], c[2])
+	for i := range c {
+		if lo < 0 {
+			c[i] = l + (c[i]-l)*l/(l-lo)
+		}
+		if hi > 1 {
+			c[i] = l + (c[i]-l)*(1-l)/(hi-l)
+		}
+	}
+	return c
+}
+
+// setSat returns color with saturation s keeping order of channels
+func setSat(c [3]float64, s float64) [3]float64 {
+	lo, hi := min(c[0], c[1], c[2]), max(c[0], c[1], c[2])
+	if hi == lo {
+		return [3]float64{}
+	}
+
+	var out [3]float64
+	for i := range c {
+		out[i] = (c[i] - lo) * s / (hi - lo)
+	}
+	return out
+}
+
+// unit8 converts value in [0, 1] to rounded byte
+func unit8(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
@@ -0,0 +1,629 @@
+// Package asefile implements native reading of Aseprite (.aseprite/.ase) files
+// as described in https://github.com/aseprite/aseprite/blob/main/docs/ase-file-specs.md
+package asefile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	headerSize      = 128
+	chunkHeaderSize = 6
+
+	fileMagic  uint16 = 0xA5E0
+	frameMagic uint16 = 0xF1FA
+)
+
+const (
+	chunkOldPalette     uint16 = 0x0004
+	chunkOldPalette2    uint16 = 0x0011
+	chunkLayer          uint16 = 0x2004
+	chunkCel            uint16 = 0x2005
+	chunkCelExtra       uint16 = 0x2006
+	chunkColorProfile   uint16 = 0x2007
+	chunkExternalFiles  uint16 = 0x2008
+	chunkMask           uint16 = 0x2016
+	chunkPath           uint16 = 0x2017
+	chunkTags           uint16 = 0x2018
+	chunkPalette        uint16 = 0x2019
+	chunkUserData       uint16 = 0x2020
+	chunkSlice          uint16 = 0x2022
+	chunkTileset        uint16 = 0x2023
+	colorProfileICC     uint16 = 2
+	paletteEntryHasName uint16 = 1
+	userDataHasText     uint32 = 1
+	userDataHasColor    uint32 = 2
+	userDataHasProps    uint32 = 4
+	tilesetReservedSize        = 14
+
+	// maxPaletteSize is the highest palette size accepted by Aseprite
+	maxPaletteSize = 65536
+	// minPaletteEntrySize is size of palette entry without name
+	minPaletteEntrySize = 6
+
+	// maxInflateRatio is the highest size ratio of zlib decompressed and compressed data
+	maxInflateRatio = 1032
+)
+
+var (
+	ErrInvalidMagic  = errors.New("not an aseprite file")
+	ErrUnexpectedEOF = errors.New("unexpected end of aseprite data")
+)
+
+// ReadFile reads and decodes aseprite file from disk
+func ReadFile(filename string) (*Sprite, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	sprite, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filename, err)
+	}
+
+	return sprite, nil
+}
+
+// Decode reads whole aseprite file from reader
+func Decode(r io.Reader) (*Sprite, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{sprite: &Sprite{}}
+	if err := d.decode(data); err != nil {
+		return nil, err
+	}
+
+	return d.sprite, nil
+}
+
+type decoder struct {
+	sprite      *Sprite
+	oldPalette  []PaletteEntry
+	hasPalette  bool
+	groupsStack []*Layer
+
+	// userDataTarget is object which receives next user data chunk
+	userDataTarget *UserData
+	// pendingTags are tags waiting for their user data chunks (one chunk per tag)
+	pendingTags []*Tag
+	lastCel     *Cel
+}
+
+func (d *decoder) decode(data []byte) error {
+	s := newStream(data)
+	if err := d.decodeHeader(s); err != nil {
+		return err
+	}
+
+	for i := range d.sprite.Frames {
+		if err := d.decodeFrame(s, i); err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+
+	if !d.hasPalette {
+		d.sprite.Palette.Entries = d.oldPalette
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeHeader(s *stream) error {
+	header := newStream(s.take(headerSize))
+	if s.err != nil {
+		return s.err
+	}
+
+	header.dword() // file size
+	if header.word() != fileMagic {
+		return ErrInvalidMagic
+	}
+
+	sp := d.sprite
+	framesCount := int(header.word())
+	sp.Width = int(header.word())
+	sp.Height = int(header.word())
+	sp.ColorDepth = ColorDepth(header.word())
+	sp.Flags = header.dword()
+	header.word() // deprecated speed
+	header.skip(8)
+	sp.TransparentIndex = header.byte()
+	header.skip(3)
+	sp.NumColors = int(header.word())
+	if sp.NumColors == 0 {
+		sp.NumColors = 256
+	}
+	sp.PixelWidth = header.byte()
+	sp.PixelHeight = header.byte()
+	gridX := int(header.short())
+	gridY := int(header.short())
+	gridW := int(header.word())
+	gridH := int(header.word())
+	sp.Grid = image.Rect(gridX, gridY, gridX+gridW, gridY+gridH)
+
+	switch sp.ColorDepth {
+	case ColorDepthIndexed, ColorDepthGrayscale, ColorDepthRGBA:
+	default:
+		return fmt.Errorf("unsupported color depth: %d", sp.ColorDepth)
+	}
+
+	sp.Frames = make([]*Frame, framesCount)
+	for i := range sp.Frames {
+		sp.Frames[i] = &Frame{Index: i}
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeFrame(s *stream, index int) error {
+	frameStart := s.pos
+	frameSize := int(s.dword())
+	if s.word() != frameMagic {
+		return errors.New("invalid frame magic number")
+	}
+	oldChunks := int(s.word())
+	duration := int(s.word())
+	s.skip(2)
+	newChunks := int(s.dword())
+	if s.err != nil {
+		return s.err
+	}
+
+	frame := d.sprite.Frames[index]
+	frame.Duration = time.Duration(duration) * time.Millisecond
+
+	chunks := newChunks
+	if chunks == 0 {
+		chunks = oldChunks
+	}
+
+	for i := 0; i < chunks; i++ {
+		chunkSize := int(s.dword())
+		chunkType := s.word()
+		if s.err != nil {
+			return s.err
+		}
+		if chunkSize < chunkHeaderSize {
+			return fmt.Errorf("invalid chunk size %d", chunkSize)
+		}
+
+		body := newStream(s.take(chunkSize - chunkHeaderSize))
+		if s.err != nil {
+			return s.err
+		}
+
+		if err := d.decodeChunk(body, chunkType, frame); err != nil {
+			return fmt.Errorf("chunk 0x%04X: %w", chunkType, err)
+		}
+	}
+
+	// frame size is authoritative, skip unknown trailing data
+	if end := frameStart + frameSize; end > s.pos && end <= len(s.buf) {
+		s.pos = end
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeChunk(s *stream, chunkType uint16, frame *Frame) error {
+	var err error
+
+	switch chunkType {
+	case chunkOldPalette, chunkOldPalette2:
+		d.decodeOldPalette(s, chunkType == chunkOldPalette2)
+		d.afterPalette(frame)
+	case chunkPalette:
+		err = d.decodePalette(s)
+		d.afterPalette(frame)
+	case chunkLayer:
+		d.decodeLayer(s)
+	case chunkCel:
+		err = d.decodeCel(s, frame)
+	case chunkCelExtra:
+		d.decodeCelExtra(s)
+	case chunkColorProfile:
+		d.decodeColorProfile(s)
+	case chunkExternalFiles:
+		d.decodeExternalFiles(s)
+	case chunkTags:
+		d.decodeTags(s)
+	case chunkUserData:
+		err = d.decodeUserData(s)
+	case chunkSlice:
+		d.decodeSlice(s)
+	case chunkTileset:
+		err = d.decodeTileset(s)
+	case chunkMask, chunkPath:
+		// deprecated and never used chunks
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.err
+}
+
+func (d *decoder) afterPalette(frame *Frame) {
+	if frame.Index == 0 {
+		d.setUserDataTarget(&d.sprite.UserData)
+	}
+}
+
+// setUserDataTarget makes object receiver of next user data chunk
+func (d *decoder) setUserDataTarget(target *UserData) {
+	d.userDataTarget = target
+	d.pendingTags = nil
+}
+
+func (d *decoder) decodeOldPalette(s *stream, sixBit bool) {
+	packets := int(s.word())
+	index := 0
+	for p := 0; p < packets && s.err == nil; p++ {
+		index += int(s.byte())
+		count := int(s.byte())
+		if count == 0 {
+			count = 256
+		}
+		for i := 0; i < count && s.err == nil; i++ {
+			r, g, b := s.byte(), s.byte(), s.byte()
+			if sixBit {
+				r, g, b = scale6Bit(r), scale6Bit(g), scale6Bit(b)
+			}
+			for len(d.oldPalette) <= index {
+				d.oldPalette = append(d.oldPalette, PaletteEntry{})
+			}
+			d.oldPalette[index] = PaletteEntry{Color: color.NRGBA{R: r, G: g, B: b, A: 255}}
+			index++
+		}
+	}
+}
+
+func scale6Bit(v uint8) uint8 {
+	return uint8(int(v) * 255 / 63)
+}
+
+func (d *decoder) decodePalette(s *stream) error {
+	d.hasPalette = true
+
+	size := int(s.dword())
+	first := int(s.dword())
+	last := int(s.dword())
+	s.skip(8)
+
+	if size > maxPaletteSize || last >= size {
+		return fmt.Errorf("invalid palette of %d colors with entries %d-%d", size, first, last)
+	}
+	if count := last - first + 1; count > s.remaining()/minPaletteEntrySize {
+		return fmt.Errorf("%d palette entries exceed %d bytes left in chunk", count, s.remaining())
+	}
+
+	entries := d.sprite.Palette.Entries
+	if len(entries) < size {
+		entries = append(entries, make([]PaletteEntry, size-len(entries))...)
+	}
+
+	for i := first; i <= last && s.err == nil; i++ {
+		flags := s.word()
+		entry := PaletteEntry{Color: color.NRGBA{R: s.byte(), G: s.byte(), B: s.byte(), A: s.byte()}}
+		if flags&paletteEntryHasName != 0 {
+			entry.Name = s.string()
+		}
+		if i < len(entries) {
+			entries[i] = entry
+		}
+	}
+
+	d.sprite.Palette.Entries = entries[:size]
+	return nil
+}
+
+func (d *decoder) decodeLayer(s *stream) {
+	layer := &Layer{
+		Index:      len(d.sprite.Layers),
+		Flags:      LayerFlags(s.word()),
+		Type:       LayerType(s.word()),
+		ChildLevel: int(s.word()),
+	}
+	s.word() // default width (ignored)
+	s.word() // default height (ignored)
+	layer.BlendMode = BlendMode(s.word())
+	layer.Opacity = s.byte()
+	s.skip(3)
+	layer.Name = s.string()
+
+	if layer.Type == LayerTypeTilemap {
+		layer.TilesetIndex = int(s.dword())
+	}
+
+	if d.sprite.Flags&HeaderFlagLayersHaveUUID != 0 {
+		id := s.uuid()
+		layer.UUID = &id
+	}
+
+	if d.sprite.Flags&HeaderFlagLayerOpacityValid == 0 {
+		layer.Opacity = 255
+	}
+
+	// child level describes position in layers tree relative to previous layer
+	if layer.ChildLevel > len(d.groupsStack) {
+		layer.ChildLevel = len(d.groupsStack)
+	}
+	d.groupsStack = d.groupsStack[:layer.ChildLevel]
+	if layer.ChildLevel > 0 {
+		parent := d.groupsStack[layer.ChildLevel-1]
+		layer.Parent = parent
+		parent.Children = append(parent.Children, layer)
+	}
+	d.groupsStack = append(d.groupsStack, layer)
+
+	d.sprite.Layers = append(d.sprite.Layers, layer)
+	d.setUserDataTarget(&layer.UserData)
+}
+
+func (d *decoder) decodeCel(s *stream, frame *Frame) error {
+	cel := &Cel{
+		LayerIndex: int(s.word()),
+		Frame:      frame.Index,
+		X:          int(s.short()),
+		Y:          int(s.short()),
+		Opacity:    s.byte(),
+		Type:       CelType(s.word()),
+		ZIndex:     int(s.short()),
+	}
+	s.skip(5)
+
+	switch cel.Type {
+	case CelTypeRaw:
+		cel.Width = int(s.word())
+		cel.Height = int(s.word())
+		cel.Pixels = s.take(cel.Width * cel.Height * d.sprite.ColorDepth.BytesPerPixel())
+	case CelTypeLinked:
+		cel.LinkedFrame = int(s.word())
+		if s.err == nil && cel.LinkedFrame >= frame.Index {
+			return fmt.Errorf("cel of layer %d is linked to frame %d which is not before its frame", cel.LayerIndex, cel.LinkedFrame)
+		}
+	case CelTypeCompressedImage:
+		cel.Width = int(s.word())
+		cel.Height = int(s.word())
+		pixels, err := inflate(s.take(s.remaining()), cel.Width, cel.Height, d.sprite.ColorDepth.BytesPerPixel())
+		if err != nil {
+			return err
+		}
+		cel.Pixels = pixels
+	case CelTypeCompressedTilemap:
+		tm := &Tilemap{
+			Width:       int(s.word()),
+			Height:      int(s.word()),
+			BitsPerTile: int(s.word()),
+		}
+		tm.TileIDMask = s.dword()
+		tm.XFlipMask = s.dword()
+		tm.YFlipMask = s.dword()
+		tm.DiagonalFlipMask = s.dword()
+		s.skip(10)
+
+		bytesPerTile := tm.BitsPerTile / 8
+		if bytesPerTile != 1 && bytesPerTile != 2 && bytesPerTile != 4 {
+			return fmt.Errorf("unsupported tile size: %d bits", tm.BitsPerTile)
+		}
+
+		raw, err := inflate(s.take(s.remaining()), tm.Width, tm.Height, bytesPerTile)
+		if err != nil {
+			return err
+		}
+
+		tm.Tiles = make([]uint32, tm.Width*tm.Height)
+		rs := newStream(raw)
+		for i := range tm.Tiles {
+			switch bytesPerTile {
+			case 1:
+				tm.Tiles[i] = uint32(rs.byte())
+			case 2:
+				tm.Tiles[i] = uint32(rs.word())
+			case 4:
+				tm.Tiles[i] = rs.dword()
+			}
+		}
+		cel.Tilemap = tm
+		cel.Width = tm.Width
+		cel.Height = tm.Height
+	default:
+		return fmt.Errorf("unknown cel type: %d", cel.Type)
+	}
+
+	frame.Cels = append(frame.Cels, cel)
+	d.lastCel = cel
+	d.setUserDataTarget(&cel.UserData)
+
+	return nil
+}
+
+func (d *decoder) decodeCelExtra(s *stream) {
+	extra := &CelExtra{Flags: s.dword()}
+	extra.X = s.fixed().Float()
+	extra.Y = s.fixed().Float()
+	extra.Width = s.fixed().Float()
+	extra.Height = s.fixed().Float()
+
+	if d.lastCel != nil {
+		d.lastCel.Extra = extra
+	}
+}
+
+func (d *decoder) decodeColorProfile(s *stream) {
+	profile := &ColorProfile{
+		Type:  s.word(),
+		Flags: s.word(),
+		Gamma: s.fixed().Float(),
+	}
+	s.skip(8)
+
+	if profile.Type == colorProfileICC {
+		profile.ICC = s.take(int(s.dword()))
+	}
+
+	d.sprite.ColorProfile = profile
+}
+
+func (d *decoder) decodeExternalFiles(s *stream) {
+	count := int(s.dword())
+	s.skip(8)
+
+	for i := 0; i < count && s.err == nil; i++ {
+		f := ExternalFile{ID: s.dword(), Type: s.byte()}
+		s.skip(7)
+		f.Name = s.string()
+		d.sprite.ExternalFiles = append(d.sprite.ExternalFiles, f)
+	}
+}
+
+func (d *decoder) decodeTags(s *stream) {
+	count := int(s.word())
+	s.skip(8)
+
+	d.setUserDataTarget(nil)
+	for i := 0; i < count && s.err == nil; i++ {
+		tag := &Tag{
+			From:      int(s.word()),
+			To:        int(s.word()),
+			Direction: TagDirection(s.byte()),
+			Repeat:    int(s.word()),
+		}
+		s.skip(6)
+		tag.Color = color.NRGBA{R: s.byte(), G: s.byte(), B: s.byte(), A: 255}
+		s.skip(1)
+		tag.Name = s.string()
+
+		d.sprite.Tags = append(d.sprite.Tags, tag)
+		d.pendingTags = append(d.pendingTags, tag)
+	}
+}
+
+func (d *decoder) decodeUserData(s *stream) error {
+	target := d.userDataTarget
+	if len(d.pendingTags) > 0 {
+		target = &d.pendingTags[0].UserData
+		d.pendingTags = d.pendingTags[1:]
+	}
+
+	var ud UserData
+	flags := s.dword()
+	if flags&userDataHasText != 0 {
+		ud.Text = s.string()
+	}
+	if flags&userDataHasColor != 0 {
+		ud.Color = &color.NRGBA{R: s.byte(), G: s.byte(), B: s.byte(), A: s.byte()}
+	}
+	if flags&userDataHasProps != 0 {
+		if err := decodeProperties(s, &ud); err != nil {
+			return err
+		}
+	}
+
+	if target != nil {
+		*target = ud
+	}
+
+	return nil
+}
+
+func (d *decoder) decodeSlice(s *stream) {
+	keysCount := int(s.dword())
+	slice := &Slice{Flags: SliceFlags(s.dword())}
+	s.dword() // reserved
+	slice.Name = s.string()
+
+	for i := 0; i < keysCount && s.err == nil; i++ {
+		key := SliceKey{Frame: int(s.dword())}
+		x, y := int(s.long()), int(s.long())
+		w, h := int(s.dword()), int(s.dword())
+		key.Bounds = image.Rect(x, y, x+w, y+h)
+
+		if slice.Flags&SliceFlagNinePatch != 0 {
+			cx, cy := int(s.long()), int(s.long())
+			cw, ch := int(s.dword()), int(s.dword())
+			center := image.Rect(cx, cy, cx+cw, cy+ch)
+			key.Center = &center
+		}
+		if slice.Flags&SliceFlagPivot != 0 {
+			pivot := image.Pt(int(s.long()), int(s.long()))
+			key.Pivot = &pivot
+		}
+
+		slice.Keys = append(slice.Keys, key)
+	}
+
+	d.sprite.Slices = append(d.sprite.Slices, slice)
+	d.setUserDataTarget(&slice.UserData)
+}
+
+func (d *decoder) decodeTileset(s *stream) error {
+	ts := &Tileset{
+		ID:         s.dword(),
+		Flags:      TilesetFlags(s.dword()),
+		NumTiles:   int(s.dword()),
+		TileWidth:  int(s.word()),
+		TileHeight: int(s.word()),
+		BaseIndex:  int(s.short()),
+	}
+	s.skip(tilesetReservedSize)
+	ts.Name = s.string()
+
+	if ts.Flags&TilesetFlagExternalFile != 0 {
+		ts.ExternalFileID = s.dword()
+		ts.ExternalTilesetID = s.dword()
+	}
+
+	if ts.Flags&TilesetFlagEmbedded != 0 {
+		compressed := s.take(int(s.dword()))
+		pixels, err := inflate(compressed, ts.TileWidth, ts.TileHeight, ts.NumTiles, d.sprite.ColorDepth.BytesPerPixel())
+		if err != nil {
+			return err
+		}
+		ts.Pixels = pixels
+	}
+
+	d.sprite.Tilesets = append(d.sprite.Tilesets, ts)
+	d.setUserDataTarget(&ts.UserData)
+
+	return nil
+}
+
+// inflate decompresses data of product of dimensions size. Size is checked against length of
+// compressed data first, so that corrupted dimensions are not allocated
+func inflate(data []byte, dimensions ...int) ([]byte, error) {
+	limit := len(data) * maxInflateRatio
+	expectedSize := 1
+	for _, n := range dimensions {
+		if n < 0 || (n > 0 && expectedSize > limit/n) {
+			return nil, fmt.Errorf("failed to decompress pixels: %d compressed bytes are too few for %v pixels", len(data), dimensions)
+		}
+		expectedSize *= n
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress pixels: %w", err)
+	}
+	defer zr.Close()
+
+	out := make([]byte, expectedSize)
+	if _, err := io.ReadFull(zr, out); err != nil {
+		return nil, fmt.Errorf("failed to decompress pixels: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,429 @@
+package asefile_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileBuilder writes aseprite binary structures for decoding tests
+type fileBuilder struct {
+	bytes.Buffer
+}
+
+func (b *fileBuilder) byte(v uint8)   { b.WriteByte(v) }
+func (b *fileBuilder) word(v uint16)  { _ = binary.Write(b, binary.LittleEndian, v) }
+func (b *fileBuilder) short(v int16)  { _ = binary.Write(b, binary.LittleEndian, v) }
+func (b *fileBuilder) dword(v uint32) { _ = binary.Write(b, binary.LittleEndian, v) }
+func (b *fileBuilder) long(v int32)   { _ = binary.Write(b, binary.LittleEndian, v) }
+func (b *fileBuilder) zeros(n int)    { b.Write(make([]byte, n)) }
+func (b *fileBuilder) string(s string) {
+	b.word(uint16(len(s)))
+	b.WriteString(s)
+}
+
+func chunk(chunkType uint16, body func(b *fileBuilder)) []byte {
+	data := &fileBuilder{}
+	body(data)
+
+	out := &fileBuilder{}
+	out.dword(uint32(data.Len() + 6))
+	out.word(chunkType)
+	out.Write(data.Bytes())
+	return out.Bytes()
+}
+
+func frame(duration uint16, chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+
+	out := &fileBuilder{}
+	out.dword(uint32(len(body) + 16))
+	out.word(0xF1FA)
+	out.word(uint16(len(chunks)))
+	out.word(duration)
+	out.zeros(2)
+	out.dword(uint32(len(chunks)))
+	out.Write(body)
+	return out.Bytes()
+}
+
+func file(width, height uint16, depth uint16, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+
+	out := &fileBuilder{}
+	out.dword(uint32(len(body) + 128))
+	out.word(0xA5E0)
+	out.word(uint16(len(frames)))
+	out.word(width)
+	out.word(height)
+	out.word(depth)
+	out.dword(1) // layer opacity valid
+	out.word(100)
+	out.zeros(8)
+	out.byte(0) // transparent index
+	out.zeros(3)
+	out.word(4) // colors
+	out.byte(1)
+	out.byte(1)
+	out.short(0)
+	out.short(0)
+	out.word(16)
+	out.word(16)
+	out.zeros(84)
+	out.Write(body)
+	return out.Bytes()
+}
+
+func layerChunk(name string, layerType, childLevel uint16, flags uint16) []byte {
+	return chunk(0x2004, func(b *fileBuilder) {
+		b.word(flags)
+		b.word(layerType)
+		b.word(childLevel)
+		b.word(0)
+		b.word(0)
+		b.word(0)
+		b.byte(200)
+		b.zeros(3)
+		b.string(name)
+	})
+}
+
+func compressed(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func userDataChunk(text string) []byte {
+	return chunk(0x2020, func(b *fileBuilder) {
+		b.dword(1)
+		b.string(text)
+	})
+}
+
+func testSprite() []byte {
+	palette := chunk(0x2019, func(b *fileBuilder) {
+		b.dword(2)
+		b.dword(0)
+		b.dword(1)
+		b.zeros(8)
+		b.word(0)
+		b.Write([]byte{0, 0, 0, 0})
+		b.word(1)
+		b.Write([]byte{255, 0, 0, 255})
+		b.string("red")
+	})
+
+	spriteUserData := chunk(0x2020, func(b *fileBuilder) {
+		b.dword(1 | 2 | 4)
+		b.string("hero")
+		b.Write([]byte{1, 2, 3, 4})
+
+		props := &fileBuilder{}
+		props.dword(0)
+		props.dword(2)
+		props.string("speed")
+		props.word(0x0006)
+		props.long(-5)
+		props.string("name")
+		props.word(0x000D)
+		props.string("knight")
+
+		b.dword(uint32(props.Len() + 8))
+		b.dword(1)
+		b.Write(props.Bytes())
+	})
+
+	pixels := []byte{
+		255, 0, 0, 255, 0, 255, 0, 255,
+		0, 0, 255, 255, 0, 0, 0, 0,
+	}
+
+	cel := chunk(0x2005, func(b *fileBuilder) {
+		b.word(1)
+		b.short(3)
+		b.short(4)
+		b.byte(255)
+		b.word(2)
+		b.short(0)
+		b.zeros(5)
+		b.word(2)
+		b.word(2)
+		b.Write(compressed(pixels))
+	})
+
+	linkedCel := chunk(0x2005, func(b *fileBuilder) {
+		b.word(1)
+		b.short(5)
+		b.short(6)
+		b.byte(255)
+		b.word(1)
+		b.short(0)
+		b.zeros(5)
+		b.word(0)
+	})
+
+	tags := chunk(0x2018, func(b *fileBuilder) {
+		b.word(2)
+		b.zeros(8)
+		for i, name := range []string{"idle", "walk"} {
+			b.word(uint16(i))
+			b.word(uint16(i + 1))
+			b.byte(uint8(i + 1))
+			b.word(3)
+			b.zeros(6)
+			b.Write([]byte{10, 20, 30})
+			b.zeros(1)
+			b.string(name)
+		}
+	})
+
+	slice := chunk(0x2022, func(b *fileBuilder) {
+		b.dword(1)
+		b.dword(3)
+		b.dword(0)
+		b.string("button")
+		b.dword(0)
+		b.long(1)
+		b.long(2)
+		b.dword(10)
+		b.dword(8)
+		b.long(2)
+		b.long(2)
+		b.dword(6)
+		b.dword(4)
+		b.long(5)
+		b.long(4)
+	})
+
+	return file(16, 16, 32,
+		frame(100,
+			palette,
+			spriteUserData,
+			layerChunk("body", 1, 0, 1),
+			userDataChunk("group"),
+			layerChunk("arm", 0, 1, 1),
+			layerChunk("guide", 0, 0, 64),
+			cel,
+			tags,
+			userDataChunk("idle data"),
+			userDataChunk("walk data"),
+			slice,
+		),
+		frame(150, linkedCel),
+	)
+}
+
+func TestDecodeHeaderAndFrames(t *testing.T) {
+	sprite, err := asefile.Decode(bytes.NewReader(testSprite()))
+	require.NoError(t, err)
+
+	assert.Equal(t, 16, sprite.Width)
+	assert.Equal(t, 16, sprite.Height)
+	assert.Equal(t, asefile.ColorDepthRGBA, sprite.ColorDepth)
+	assert.Equal(t, image.Rect(0, 0, 16, 16), sprite.Grid)
+	require.Len(t, sprite.Frames, 2)
+	assert.Equal(t, 100*time.Millisecond, sprite.Frames[0].Duration)
+	assert.Equal(t, 150*time.Millisecond, sprite.Frames[1].Duration)
+}
+
+func TestDecodeLayersTree(t *testing.T) {
+	sprite, err := asefile.Decode(bytes.NewReader(testSprite()))
+	require.NoError(t, err)
+
+	require.Len(t, sprite.Layers, 3)
+	body, arm, guide := sprite.Layers[0], sprite.Layers[1], sprite.Layers[2]
+
+	assert.True(t, body.IsGroup())
+	assert.Equal(t, "group", body.UserData.Text)
+	assert.Equal(t, []*asefile.Layer{arm}, body.Children)
+	assert.Equal(t, "body/arm", arm.Path())
+	assert.Equal(t, uint8(200), arm.Opacity)
+	assert.True(t, guide.IsReference())
+	assert.False(t, guide.Visible())
+	assert.Equal(t, []*asefile.Layer{body, guide}, sprite.TopLevelLayers())
+	assert.Same(t, arm, sprite.LayerByPath("body/arm"))
+}
+
+func TestDecodeCels(t *testing.T) {
+	sprite, err := asefile.Decode(bytes.NewReader(testSprite()))
+	require.NoError(t, err)
+
+	cel := sprite.Cel(0, 1)
+	require.NotNil(t, cel)
+	img := sprite.CelImage(cel)
+	assert.Equal(t, image.Rect(3, 4, 5, 6), img.Bounds())
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, img.NRGBAAt(3, 4))
+	assert.Equal(t, color.NRGBA{B: 255, A: 255}, img.NRGBAAt(3, 5))
+
+	linked := sprite.Cel(1, 1)
+	require.NotNil(t, linked)
+	assert.Equal(t, 5, linked.X)
+	assert.Equal(t, 1, linked.Frame)
+	assert.Equal(t, cel.Pixels, linked.Pixels)
+
+	assert.Nil(t, sprite.Cel(0, 0))
+}
+
+func TestDecodePaletteTagsSlicesAndUserData(t *testing.T) {
+	sprite, err := asefile.Decode(bytes.NewReader(testSprite()))
+	require.NoError(t, err)
+
+	require.Len(t, sprite.Palette.Entries, 2)
+	assert.Equal(t, "red", sprite.Palette.Entries[1].Name)
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, sprite.Palette.Entries[1].Color)
+
+	assert.Equal(t, "hero", sprite.UserData.Text)
+	assert.Equal(t, &color.NRGBA{R: 1, G: 2, B: 3, A: 4}, sprite.UserData.Color)
+	assert.Equal(t, map[string]any{"speed": int32(-5), "name": "knight"}, sprite.UserData.Properties)
+
+	require.Len(t, sprite.Tags, 2)
+	walk := sprite.TagByName("walk")
+	require.NotNil(t, walk)
+	assert.Equal(t, 1, walk.From)
+	assert.Equal(t, 2, walk.To)
+	assert.Equal(t, asefile.TagPingPong, walk.Direction)
+	assert.Equal(t, 3, walk.Repeat)
+	assert.Equal(t, "walk data", walk.UserData.Text)
+	assert.Equal(t, "idle data", sprite.Tags[0].UserData.Text)
+
+	require.Len(t, sprite.Slices, 1)
+	key := sprite.Slices[0].KeyAt(1)
+	require.NotNil(t, key)
+	assert.Equal(t, image.Rect(1, 2, 11, 10), key.Bounds)
+	assert.Equal(t, &image.Rectangle{Min: image.Pt(2, 2), Max: image.Pt(8, 6)}, key.Center)
+	assert.Equal(t, &image.Point{X: 5, Y: 4}, key.Pivot)
+}
+
+func TestDecodeInvalidData(t *testing.T) {
+	_, err := asefile.Decode(bytes.NewReader(make([]byte, 128)))
+	assert.ErrorIs(t, err, asefile.ErrInvalidMagic)
+
+	data := testSprite()
+	_, err = asefile.Decode(bytes.NewReader(data[:len(data)-10]))
+	assert.ErrorIs(t, err, asefile.ErrUnexpectedEOF)
+}
+
+func linkedCelChunk(linkedFrame uint16) []byte {
+	return chunk(0x2005, func(b *fileBuilder) {
+		b.word(0)
+		b.short(0)
+		b.short(0)
+		b.byte(255)
+		b.word(1)
+		b.short(0)
+		b.zeros(5)
+		b.word(linkedFrame)
+	})
+}
+
+func TestDecodeLinkedCelLoop(t *testing.T) {
+	data := file(4, 4, 32,
+		frame(100, layerChunk("body", 0, 0, 1), linkedCelChunk(1)),
+		frame(100, linkedCelChunk(0)),
+	)
+	_, err := asefile.Decode(bytes.NewReader(data))
+	assert.ErrorContains(t, err, "linked to frame 1")
+
+	// sprites built in memory never resolve links to the same or later frames
+	sprite := &asefile.Sprite{Frames: []*asefile.Frame{
+		{Index: 0, Cels: []*asefile.Cel{{Type: asefile.CelTypeLinked, LinkedFrame: 1}}},
+		{Index: 1, Cels: []*asefile.Cel{{Type: asefile.CelTypeLinked, LinkedFrame: 0}}},
+	}}
+	assert.Nil(t, sprite.Cel(0, 0))
+	assert.Nil(t, sprite.Cel(1, 0))
+}
+
+func TestDecodeCorruptedSizes(t *testing.T) {
+	hugeCel := chunk(0x2005, func(b *fileBuilder) {
+		b.word(0)
+		b.short(0)
+		b.short(0)
+		b.byte(255)
+		b.word(2)
+		b.short(0)
+		b.zeros(5)
+		b.word(0xFFFF)
+		b.word(0xFFFF)
+		b.Write(compressed([]byte{1, 2, 3, 4}))
+	})
+	_, err := asefile.Decode(bytes.NewReader(file(4, 4, 32, frame(100, layerChunk("body", 0, 0, 1), hugeCel))))
+	assert.ErrorContains(t, err, "too few")
+
+	hugeProperties := chunk(0x2020, func(b *fileBuilder) {
+		b.dword(4)
+		b.dword(16)
+		b.dword(1)
+		b.dword(0)
+		b.dword(0xFFFFFFFF)
+	})
+	_, err = asefile.Decode(bytes.NewReader(file(4, 4, 32, frame(100, hugeProperties))))
+	assert.ErrorContains(t, err, "properties exceed")
+
+	hugeVector := chunk(0x2020, func(b *fileBuilder) {
+		b.dword(4)
+		b.dword(28)
+		b.dword(1)
+		b.dword(0)
+		b.dword(1)
+		b.string("v")
+		b.word(0x0011)
+		b.dword(0xFFFFFFFF)
+		b.word(0x0001)
+	})
+	_, err = asefile.Decode(bytes.NewReader(file(4, 4, 32, frame(100, hugeVector))))
+	assert.ErrorContains(t, err, "vector elements exceed")
+
+	palette := func(size, first, last uint32) []byte {
+		return chunk(0x2019, func(b *fileBuilder) {
+			b.dword(size)
+			b.dword(first)
+			b.dword(last)
+			b.zeros(8)
+			b.word(0)
+			b.Write([]byte{255, 0, 0, 255})
+		})
+	}
+	tests := []struct {
+		name    string
+		chunk   []byte
+		wantErr string
+	}{
+		{"huge size", palette(0x7FFFFFFF, 0, 0), "invalid palette"},
+		{"last out of size", palette(2, 0, 2), "invalid palette"},
+		{"entries out of chunk", palette(256, 0, 255), "palette entries exceed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := asefile.Decode(bytes.NewReader(file(4, 4, 32, frame(100, tt.chunk))))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestFlipTileImage(t *testing.T) {
+	// 2x2 tile with red top-left pixel
+	tile := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	red := color.NRGBA{R: 255, A: 255}
+	tile.SetNRGBA(0, 0, red)
+
+	// diagonal with horizontal flip is 90° clockwise rotation, as in Tiled
+	rotated := asefile.FlipTileImage(tile, asefile.Tile{FlipDiagonal: true, FlipX: true})
+	assert.Equal(t, red, rotated.NRGBAAt(1, 0))
+
+	rotated = asefile.FlipTileImage(tile, asefile.Tile{FlipDiagonal: true, FlipY: true})
+	assert.Equal(t, red, rotated.NRGBAAt(0, 1))
+
+	flipped := asefile.FlipTileImage(tile, asefile.Tile{FlipX: true, FlipY: true})
+	assert.Equal(t, red, flipped.NRGBAAt(1, 1))
+}
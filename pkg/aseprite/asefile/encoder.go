@@ -0,0 +1,495 @@
+package asefile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	// tilemapBitsPerTile is tile size written to tilemap cels, flips masks are kept as decoded
+	tilemapBitsPerTile = 32
+)
+
+// WriteFile encodes sprite and writes it to disk, creating missing directories
+func WriteFile(filename string, sprite *Sprite) error {
+	var buf bytes.Buffer
+	if err := Encode(&buf, sprite); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filename, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// Encode writes sprite in aseprite file format. Image cels are written compressed, linked cels stay linked
+// and tilemap cels are written with 32 bits per tile. Sprite-wide chunks (color profile, external files,
+// palette, tilesets, layers, tags and slices) are written to first frame as Aseprite does
+func Encode(w io.Writer, sprite *Sprite) error {
+	if len(sprite.Frames) == 0 {
+		return fmt.Errorf("sprite has no frames")
+	}
+	switch sprite.ColorDepth {
+	case ColorDepthIndexed, ColorDepthGrayscale, ColorDepthRGBA:
+	default:
+		return fmt.Errorf("unsupported color depth: %d", sprite.ColorDepth)
+	}
+
+	e := &encoder{sprite: sprite}
+
+	body := &sink{}
+	for i, frame := range sprite.Frames {
+		if err := e.encodeFrame(body, i, frame); err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+
+	out := &sink{}
+	e.encodeHeader(out, body.Len())
+	out.Write(body.Bytes())
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+type encoder struct {
+	sprite *Sprite
+}
+
+func (e *encoder) encodeHeader(out *sink, bodySize int) {
+	sp := e.sprite
+
+	flags := sp.Flags | HeaderFlagLayerOpacityValid
+	if slices.ContainsFunc(sp.Layers, func(l *Layer) bool { return l.UUID == nil }) {
+		flags &^= HeaderFlagLayersHaveUUID
+	}
+
+	numColors := sp.NumColors
+	if numColors == 0 || numColors >= 256 {
+		numColors = 0
+	}
+
+	pixelWidth, pixelHeight := sp.PixelWidth, sp.PixelHeight
+	if pixelWidth == 0 || pixelHeight == 0 {
+		pixelWidth, pixelHeight = 1, 1
+	}
+
+	grid := sp.Grid
+	if grid.Empty() {
+		grid = image.Rect(0, 0, 16, 16)
+	}
+
+	out.dword(uint32(headerSize + bodySize))
+	out.word(fileMagic)
+	out.word(uint16(len(sp.Frames)))
+	out.word(uint16(sp.Width))
+	out.word(uint16(sp.Height))
+	out.word(uint16(sp.ColorDepth))
+	out.dword(flags)
+	out.word(uint16(sp.Frames[0].Duration.Milliseconds())) // deprecated speed
+	out.zeros(8)
+	out.byte(sp.TransparentIndex)
+	out.zeros(3)
+	out.word(uint16(numColors))
+	out.byte(pixelWidth)
+	out.byte(pixelHeight)
+	out.short(int16(grid.Min.X))
+	out.short(int16(grid.Min.Y))
+	out.word(uint16(grid.Dx()))
+	out.word(uint16(grid.Dy()))
+	out.zeros(84)
+}
+
+func (e *encoder) encodeFrame(out *sink, index int, frame *Frame) error {
+	var chunks [][]byte
+	add := func(chunkType uint16, body func(s *sink) error) error {
+		data := &sink{}
+		if err := body(data); err != nil {
+			return err
+		}
+
+		c := &sink{}
+		c.dword(uint32(data.Len() + chunkHeaderSize))
+		c.word(chunkType)
+		c.Write(data.Bytes())
+		chunks = append(chunks, c.Bytes())
+		return nil
+	}
+	addUserData := func(ud UserData) error {
+		return add(chunkUserData, func(s *sink) error { return encodeUserData(s, ud) })
+	}
+
+	sp := e.sprite
+	if index == 0 {
+		if sp.ColorProfile != nil {
+			_ = add(chunkColorProfile, e.encodeColorProfile)
+		}
+		if len(sp.ExternalFiles) > 0 {
+			_ = add(chunkExternalFiles, e.encodeExternalFiles)
+		}
+		_ = add(chunkPalette, e.encodePalette)
+		if !sp.UserData.IsEmpty() {
+			if err := addUserData(sp.UserData); err != nil {
+				return err
+			}
+		}
+
+		for _, ts := range sp.Tilesets {
+			if err := add(chunkTileset, func(s *sink) error { return e.encodeTileset(s, ts) }); err != nil {
+				return err
+			}
+			if !ts.UserData.IsEmpty() {
+				if err := addUserData(ts.UserData); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, layer := range sp.Layers {
+			_ = add(chunkLayer, func(s *sink) error { return e.encodeLayer(s, layer) })
+			if !layer.UserData.IsEmpty() {
+				if err := addUserData(layer.UserData); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, cel := range frame.Cels {
+		if err := add(chunkCel, func(s *sink) error { return encodeCel(s, cel) }); err != nil {
+			return err
+		}
+		if cel.Extra != nil {
+			_ = add(chunkCelExtra, func(s *sink) error { return encodeCelExtra(s, cel.Extra) })
+		}
+		if !cel.UserData.IsEmpty() {
+			if err := addUserData(cel.UserData); err != nil {
+				return err
+			}
+		}
+	}
+
+	if index == 0 {
+		if len(sp.Tags) > 0 {
+			_ = add(chunkTags, e.encodeTags)
+			// every tag receives user data chunk following tags chunk in tags order
+			for _, tag := range sp.Tags {
+				if err := addUserData(tag.UserData); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, slice := range sp.Slices {
+			_ = add(chunkSlice, func(s *sink) error { return encodeSlice(s, slice) })
+			if !slice.UserData.IsEmpty() {
+				if err := addUserData(slice.UserData); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	body := bytes.Join(chunks, nil)
+	out.dword(uint32(16 + len(body)))
+	out.word(frameMagic)
+	out.word(uint16(min(len(chunks), 0xFFFF)))
+	out.word(uint16(frame.Duration / time.Millisecond))
+	out.zeros(2)
+	out.dword(uint32(len(chunks)))
+	out.Write(body)
+
+	return nil
+}
+
+func (e *encoder) encodeColorProfile(s *sink) error {
+	profile := e.sprite.ColorProfile
+	s.word(profile.Type)
+	s.word(profile.Flags)
+	s.fixed(profile.Gamma)
+	s.zeros(8)
+	if profile.Type == colorProfileICC {
+		s.dword(uint32(len(profile.ICC)))
+		s.Write(profile.ICC)
+	}
+	return nil
+}
+
+func (e *encoder) encodeExternalFiles(s *sink) error {
+	s.dword(uint32(len(e.sprite.ExternalFiles)))
+	s.zeros(8)
+	for _, f := range e.sprite.ExternalFiles {
+		s.dword(f.ID)
+		s.byte(f.Type)
+		s.zeros(7)
+		s.string(f.Name)
+	}
+	return nil
+}
+
+func (e *encoder) encodePalette(s *sink) error {
+	entries := e.sprite.Palette.Entries
+	if len(entries) == 0 {
+		// aseprite expects at least one palette color
+		entries = []PaletteEntry{{}}
+	}
+
+	s.dword(uint32(len(entries)))
+	s.dword(0)
+	s.dword(uint32(len(entries) - 1))
+	s.zeros(8)
+	for _, entry := range entries {
+		var flags uint16
+		if entry.Name != "" {
+			flags |= paletteEntryHasName
+		}
+		s.word(flags)
+		s.Write([]byte{entry.Color.R, entry.Color.G, entry.Color.B, entry.Color.A})
+		if entry.Name != "" {
+			s.string(entry.Name)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeTileset(s *sink, ts *Tileset) error {
+	s.dword(ts.ID)
+	s.dword(uint32(ts.Flags))
+	s.dword(uint32(ts.NumTiles))
+	s.word(uint16(ts.TileWidth))
+	s.word(uint16(ts.TileHeight))
+	s.short(int16(ts.BaseIndex))
+	s.zeros(tilesetReservedSize)
+	s.string(ts.Name)
+
+	if ts.Flags&TilesetFlagExternalFile != 0 {
+		s.dword(ts.ExternalFileID)
+		s.dword(ts.ExternalTilesetID)
+	}
+	if ts.Flags&TilesetFlagEmbedded != 0 {
+		size := ts.TileWidth * ts.TileHeight * ts.NumTiles * e.sprite.ColorDepth.BytesPerPixel()
+		if len(ts.Pixels) != size {
+			return fmt.Errorf("tileset %q has %d bytes of pixels, %d expected", ts.Name, len(ts.Pixels), size)
+		}
+		data, err := deflate(ts.Pixels)
+		if err != nil {
+			return err
+		}
+		s.dword(uint32(len(data)))
+		s.Write(data)
+	}
+	return nil
+}
+
+func (e *encoder) encodeLayer(s *sink, layer *Layer) error {
+	s.word(uint16(layer.Flags))
+	s.word(uint16(layer.Type))
+	s.word(uint16(layer.ChildLevel))
+	s.word(0) // default width (ignored)
+	s.word(0) // default height (ignored)
+	s.word(uint16(layer.BlendMode))
+	s.byte(layer.Opacity)
+	s.zeros(3)
+	s.string(layer.Name)
+
+	if layer.Type == LayerTypeTilemap {
+		s.dword(uint32(layer.TilesetIndex))
+	}
+	if e.sprite.Flags&HeaderFlagLayersHaveUUID != 0 && layer.UUID != nil {
+		s.Write(layer.UUID[:])
+	}
+	return nil
+}
+
+func encodeCel(s *sink, cel *Cel) error {
+	celType := cel.Type
+	if celType == CelTypeRaw {
+		celType = CelTypeCompressedImage
+	}
+
+	s.word(uint16(cel.LayerIndex))
+	s.short(int16(cel.X))
+	s.short(int16(cel.Y))
+	s.byte(cel.Opacity)
+	s.word(uint16(celType))
+	s.short(int16(cel.ZIndex))
+	s.zeros(5)
+
+	switch celType {
+	case CelTypeLinked:
+		s.word(uint16(cel.LinkedFrame))
+	case CelTypeCompressedImage:
+		s.word(uint16(cel.Width))
+		s.word(uint16(cel.Height))
+		data, err := deflate(cel.Pixels)
+		if err != nil {
+			return err
+		}
+		s.Write(data)
+	case CelTypeCompressedTilemap:
+		tm := cel.Tilemap
+		if tm == nil || len(tm.Tiles) != tm.Width*tm.Height {
+			return fmt.Errorf("tilemap cel of layer %d has invalid tiles", cel.LayerIndex)
+		}
+		s.word(uint16(tm.Width))
+		s.word(uint16(tm.Height))
+		s.word(tilemapBitsPerTile)
+		s.dword(tm.TileIDMask)
+		s.dword(tm.XFlipMask)
+		s.dword(tm.YFlipMask)
+		s.dword(tm.DiagonalFlipMask)
+		s.zeros(10)
+
+		raw := &sink{}
+		for _, tile := range tm.Tiles {
+			raw.dword(tile)
+		}
+		data, err := deflate(raw.Bytes())
+		if err != nil {
+			return err
+		}
+		s.Write(data)
+	default:
+		return fmt.Errorf("unknown cel type: %d", cel.Type)
+	}
+	return nil
+}
+
+func encodeCelExtra(s *sink, extra *CelExtra) error {
+	s.dword(extra.Flags)
+	s.fixed(extra.X)
+	s.fixed(extra.Y)
+	s.fixed(extra.Width)
+	s.fixed(extra.Height)
+	return nil
+}
+
+func (e *encoder) encodeTags(s *sink) error {
+	s.word(uint16(len(e.sprite.Tags)))
+	s.zeros(8)
+	for _, tag := range e.sprite.Tags {
+		s.word(uint16(tag.From))
+		s.word(uint16(tag.To))
+		s.byte(uint8(tag.Direction))
+		s.word(uint16(tag.Repeat))
+		s.zeros(6)
+		s.Write([]byte{tag.Color.R, tag.Color.G, tag.Color.B})
+		s.zeros(1)
+		s.string(tag.Name)
+	}
+	return nil
+}
+
+func encodeSlice(s *sink, slice *Slice) error {
+	flags := slice.Flags &^ (SliceFlagNinePatch | SliceFlagPivot)
+	for _, key := range slice.Keys {
+		if key.Center != nil {
+			flags |= SliceFlagNinePatch
+		}
+		if key.Pivot != nil {
+			flags |= SliceFlagPivot
+		}
+	}
+
+	s.dword(uint32(len(slice.Keys)))
+	s.dword(uint32(flags))
+	s.dword(0)
+	s.string(slice.Name)
+	for _, key := range slice.Keys {
+		s.dword(uint32(key.Frame))
+		s.rect(key.Bounds)
+		if flags&SliceFlagNinePatch != 0 {
+			center := image.Rectangle{}
+			if key.Center != nil {
+				center = *key.Center
+			}
+			s.rect(center)
+		}
+		if flags&SliceFlagPivot != 0 {
+			pivot := image.Point{}
+			if key.Pivot != nil {
+				pivot = *key.Pivot
+			}
+			s.long(int32(pivot.X))
+			s.long(int32(pivot.Y))
+		}
+	}
+	return nil
+}
+
+func encodeUserData(s *sink, ud UserData) error {
+	var flags uint32
+	if ud.Text != "" {
+		flags |= userDataHasText
+	}
+	if ud.Color != nil {
+		flags |= userDataHasColor
+	}
+	if len(ud.Properties) > 0 || len(ud.ExtensionProperties) > 0 {
+		flags |= userDataHasProps
+	}
+
+	s.dword(flags)
+	if ud.Text != "" {
+		s.string(ud.Text)
+	}
+	if ud.Color != nil {
+		s.Write([]byte{ud.Color.R, ud.Color.G, ud.Color.B, ud.Color.A})
+	}
+	if flags&userDataHasProps != 0 {
+		return encodeProperties(s, ud)
+	}
+	return nil
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress pixels: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress pixels: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sink writes little-endian aseprite primitives, it is counterpart of stream
+type sink struct {
+	bytes.Buffer
+}
+
+func (s *sink) byte(v uint8)   { s.WriteByte(v) }
+func (s *sink) word(v uint16)  { s.Write(binary.LittleEndian.AppendUint16(nil, v)) }
+func (s *sink) short(v int16)  { s.word(uint16(v)) }
+func (s *sink) dword(v uint32) { s.Write(binary.LittleEndian.AppendUint32(nil, v)) }
+func (s *sink) long(v int32)   { s.dword(uint32(v)) }
+func (s *sink) qword(v uint64) { s.Write(binary.LittleEndian.AppendUint64(nil, v)) }
+func (s *sink) zeros(n int)    { s.Write(make([]byte, n)) }
+
+func (s *sink) fixed(v float64) {
+	s.long(int32(math.Round(v * 65536)))
+}
+
+func (s *sink) string(v string) {
+	s.word(uint16(len(v)))
+	s.WriteString(v)
+}
+
+// rect writes rectangle as position (LONG) and size (DWORD)
+func (s *sink) rect(r image.Rectangle) {
+	s.long(int32(r.Min.X))
+	s.long(int32(r.Min.Y))
+	s.dword(uint32(r.Dx()))
+	s.dword(uint32(r.Dy()))
+}
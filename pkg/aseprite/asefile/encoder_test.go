@@ -0,0 +1,87 @@
+package asefile_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	sprite, err := asefile.Decode(bytes.NewReader(testSprite()))
+	require.NoError(t, err)
+
+	sprite.UserData.Properties["nested"] = map[string]any{
+		"area":   image.Rect(1, 2, 5, 8),
+		"values": []any{int8(-1), "mixed", true},
+		"size":   asefile.Size{Width: 3, Height: 4},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, asefile.Encode(&buf, sprite))
+
+	decoded, err := asefile.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, sprite, decoded)
+
+	var again bytes.Buffer
+	require.NoError(t, asefile.Encode(&again, decoded))
+	assert.Equal(t, buf.Bytes(), again.Bytes(), "encoding is not deterministic")
+}
+
+func TestEncodeTilemap(t *testing.T) {
+	tiles := make([]byte, 2*2*4*2)
+	for i := 2 * 2 * 4; i < len(tiles); i += 4 {
+		tiles[i], tiles[i+3] = 255, 255
+	}
+
+	sprite := &asefile.Sprite{
+		Width:      4,
+		Height:     2,
+		ColorDepth: asefile.ColorDepthRGBA,
+		Grid:       image.Rect(0, 0, 2, 2),
+		Frames:     []*asefile.Frame{{Duration: 100 * time.Millisecond}},
+		Layers: []*asefile.Layer{{
+			Name: "map", Type: asefile.LayerTypeTilemap, Flags: asefile.LayerFlagVisible | asefile.LayerFlagEditable, Opacity: 255,
+		}},
+		Tilesets: []*asefile.Tileset{{
+			Flags:     asefile.TilesetFlagEmbedded | asefile.TilesetFlagEmptyTileID,
+			NumTiles:  2,
+			TileWidth: 2, TileHeight: 2, BaseIndex: 1,
+			Pixels: tiles,
+		}},
+	}
+	sprite.Frames[0].Cels = []*asefile.Cel{{
+		Opacity: 255,
+		Type:    asefile.CelTypeCompressedTilemap,
+		Width:   2,
+		Height:  1,
+		Tilemap: &asefile.Tilemap{
+			Width: 2, Height: 1, BitsPerTile: 32, TileIDMask: 0x1fffffff,
+			XFlipMask: 0x20000000, YFlipMask: 0x40000000, DiagonalFlipMask: 0x80000000,
+			Tiles: []uint32{1, 1 | 0x20000000},
+		},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, asefile.Encode(&buf, sprite))
+
+	decoded, err := asefile.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+
+	require.Len(t, decoded.Tilesets, 1)
+	assert.Equal(t, tiles, decoded.Tilesets[0].Pixels)
+	cel := decoded.Cel(0, 0)
+	require.NotNil(t, cel)
+	assert.Equal(t, asefile.Tile{ID: 1, FlipX: true}, cel.Tilemap.TileAt(1, 0))
+
+	img := decoded.FrameImage(0, asefile.DefaultLayerFilter)
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, img.NRGBAAt(3, 1))
+	// palette is required by aseprite even for RGB sprites
+	assert.Len(t, decoded.Palette.Entries, 1)
+}
@@ -0,0 +1,127 @@
+package asefile
+
+import (
+	"image"
+	"image/color"
+)
+
+// CelImage converts cel pixels to NRGBA image placed at cel position.
+// Tilemap cels are rendered with tiles of layer tileset.
+func (s *Sprite) CelImage(cel *Cel) *image.NRGBA {
+	if cel.Tilemap != nil {
+		return s.tilemapImage(cel)
+	}
+
+	opaqueIndex := false
+	if cel.LayerIndex < len(s.Layers) {
+		opaqueIndex = s.Layers[cel.LayerIndex].IsBackground()
+	}
+
+	img := s.pixelsToImage(cel.Pixels, cel.Width, cel.Height, opaqueIndex)
+	img.Rect = img.Rect.Add(image.Pt(cel.X, cel.Y))
+	return img
+}
+
+// TileImage returns image of one tile from embedded tileset pixels.
+// Returns nil if tileset has no embedded tiles or index is out of range.
+func (s *Sprite) TileImage(ts *Tileset, index int) *image.NRGBA {
+	if ts.Pixels == nil || index < 0 || index >= ts.NumTiles {
+		return nil
+	}
+
+	tileSize := ts.TileWidth * ts.TileHeight * s.ColorDepth.BytesPerPixel()
+	pixels := ts.Pixels[index*tileSize : (index+1)*tileSize]
+	return s.pixelsToImage(pixels, ts.TileWidth, ts.TileHeight, false)
+}
+
+// ColorAt converts single raw pixel of sprite color depth to color
+func (s *Sprite) ColorAt(pixel []byte, opaqueIndex bool) color.NRGBA {
+	switch s.ColorDepth {
+	case ColorDepthRGBA:
+		return color.NRGBA{R: pixel[0], G: pixel[1], B: pixel[2], A: pixel[3]}
+	case ColorDepthGrayscale:
+		return color.NRGBA{R: pixel[0], G: pixel[0], B: pixel[0], A: pixel[1]}
+	case ColorDepthIndexed:
+		index := pixel[0]
+		if index == s.TransparentIndex && !opaqueIndex {
+			return color.NRGBA{}
+		}
+		if int(index) < len(s.Palette.Entries) {
+			return s.Palette.Entries[index].Color
+		}
+	}
+	return color.NRGBA{}
+}
+
+func (s *Sprite) pixelsToImage(pixels []byte, width, height int, opaqueIndex bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	bpp := s.ColorDepth.BytesPerPixel()
+
+	for i := 0; i < width*height && (i+1)*bpp <= len(pixels); i++ {
+		c := s.ColorAt(pixels[i*bpp:(i+1)*bpp], opaqueIndex)
+		img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], img.Pix[i*4+3] = c.R, c.G, c.B, c.A
+	}
+
+	return img
+}
+
+func (s *Sprite) tilemapImage(cel *Cel) *image.NRGBA {
+	tm := cel.Tilemap
+	var ts *Tileset
+	if cel.LayerIndex < len(s.Layers) {
+		if idx := s.Layers[cel.LayerIndex].TilesetIndex; idx < len(s.Tilesets) {
+			ts = s.Tilesets[idx]
+		}
+	}
+
+	if ts == nil {
+		return image.NewNRGBA(image.Rect(cel.X, cel.Y, cel.X, cel.Y))
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, tm.Width*ts.TileWidth, tm.Height*ts.TileHeight))
+	for ty := 0; ty < tm.Height; ty++ {
+		for tx := 0; tx < tm.Width; tx++ {
+			tile := tm.TileAt(tx, ty)
+			tileImg := s.TileImage(ts, int(tile.ID))
+			if tileImg == nil {
+				continue
+			}
+			drawTile(img, tileImg, tx*ts.TileWidth, ty*ts.TileHeight, tile)
+		}
+	}
+
+	img.Rect = img.Rect.Add(image.Pt(cel.X, cel.Y))
+	return img
+}
+
+// FlipTileImage returns copy of tile image with tile flips applied
+func FlipTileImage(tile *image.NRGBA, t Tile) *image.NRGBA {
+	flipped := image.NewNRGBA(image.Rectangle{Max: tile.Rect.Size()})
+	drawTile(flipped, tile, 0, 0, t)
+	return flipped
+}
+
+// drawTile copies tile into dst applying tile flips as Aseprite and Tiled do: diagonal flip (swap of x and y
+// axes) is applied to tile first, then horizontal and vertical flips (diagonal with horizontal flip is 90° clockwise rotation)
+func drawTile(dst, tile *image.NRGBA, ox, oy int, t Tile) {
+	w, h := tile.Rect.Dx(), tile.Rect.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// source pixel is found undoing flips in reverse order
+			sx, sy := x, y
+			if t.FlipY {
+				sy = h - 1 - sy
+			}
+			if t.FlipX {
+				sx = w - 1 - sx
+			}
+			if t.FlipDiagonal {
+				sx, sy = sy, sx
+			}
+			if sx >= w || sy >= h {
+				continue
+			}
+			dst.SetNRGBA(ox+x, oy+y, tile.NRGBAAt(tile.Rect.Min.X+sx, tile.Rect.Min.Y+sy))
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package asefile
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"slices"
+)
+
+// Property value types of user data properties maps
+const (
+	propBool   uint16 = 0x0001
+	propInt8   uint16 = 0x0002
+	propUint8  uint16 = 0x0003
+	propInt16  uint16 = 0x0004
+	propUint16 uint16 = 0x0005
+	propInt32  uint16 = 0x0006
+	propUint32 uint16 = 0x0007
+	propInt64  uint16 = 0x0008
+	propUint64 uint16 = 0x0009
+	propFixed  uint16 = 0x000A
+	propFloat  uint16 = 0x000B
+	propDouble uint16 = 0x000C
+	propString uint16 = 0x000D
+	propPoint  uint16 = 0x000E
+	propSize   uint16 = 0x000F
+	propRect   uint16 = 0x0010
+	propVector uint16 = 0x0011
+	propMap    uint16 = 0x0012
+	propUUID   uint16 = 0x0013
+
+	// minPropertySize is size of property with empty name and one byte value
+	minPropertySize = 5
+)
+
+// decodeProperties reads properties maps. Values keep their exact Go types
+// (int8, uint16, Fixed, Size, image.Rectangle, []any, map[string]any, UUID, ...)
+func decodeProperties(s *stream, ud *UserData) error {
+	s.dword() // size of all maps in bytes
+	mapsCount := int(s.dword())
+
+	for i := 0; i < mapsCount && s.err == nil; i++ {
+		key := s.dword()
+		props, err := decodePropertiesMap(s)
+		if err != nil {
+			return err
+		}
+
+		if key == 0 {
+			ud.Properties = props
+			continue
+		}
+
+		if ud.ExtensionProperties == nil {
+			ud.ExtensionProperties = make(map[uint32]map[string]any)
+		}
+		ud.ExtensionProperties[key] = props
+	}
+
+	return s.err
+}
+
+func decodePropertiesMap(s *stream) (map[string]any, error) {
+	count := int(s.dword())
+	if count > s.remaining()/minPropertySize {
+		return nil, fmt.Errorf("%d properties exceed %d bytes left in chunk", count, s.remaining())
+	}
+	props := make(map[string]any, count)
+
+	for i := 0; i < count && s.err == nil; i++ {
+		name := s.string()
+		value, err := decodePropertyValue(s, s.word())
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", name, err)
+		}
+		props[name] = value
+	}
+
+	return props, s.err
+}
+
+func decodePropertyValue(s *stream, valueType uint16) (any, error) {
+	switch valueType {
+	case propBool:
+		return s.byte() != 0, nil
+	case propInt8:
+		return int8(s.byte()), nil
+	case propUint8:
+		return s.byte(), nil
+	case propInt16:
+		return s.short(), nil
+	case propUint16:
+		return s.word(), nil
+	case propInt32:
+		return s.long(), nil
+	case propUint32:
+		return s.dword(), nil
+	case propInt64:
+		return int64(s.qword()), nil
+	case propUint64:
+		return s.qword(), nil
+	case propFixed:
+		return s.fixed(), nil
+	case propFloat:
+		return s.float(), nil
+	case propDouble:
+		return s.double(), nil
+	case propString:
+		return s.string(), nil
+	case propPoint:
+		return image.Pt(int(s.long()), int(s.long())), nil
+	case propSize:
+		return Size{Width: s.long(), Height: s.long()}, nil
+	case propRect:
+		x, y := int(s.long()), int(s.long())
+		w, h := int(s.long()), int(s.long())
+		return image.Rect(x, y, x+w, y+h), nil
+	case propVector:
+		count := int(s.dword())
+		elemType := s.word()
+		// every element takes at least one byte
+		if count > s.remaining() {
+			return nil, fmt.Errorf("%d vector elements exceed %d bytes left in chunk", count, s.remaining())
+		}
+		values := make([]any, 0, count)
+		for i := 0; i < count && s.err == nil; i++ {
+			t := elemType
+			if t == 0 {
+				t = s.word()
+			}
+			v, err := decodePropertyValue(s, t)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case propMap:
+		return decodePropertiesMap(s)
+	case propUUID:
+		return s.uuid(), nil
+	default:
+		return nil, fmt.Errorf("unknown property type 0x%04X", valueType)
+	}
+}
+
+// encodeProperties writes properties maps, user properties are written as map with key 0
+func encodeProperties(s *sink, ud UserData) error {
+	maps := &sink{}
+	count := 0
+
+	if len(ud.Properties) > 0 {
+		maps.dword(0)
+		if err := encodePropertiesMap(maps, ud.Properties); err != nil {
+			return err
+		}
+		count++
+	}
+
+	keys := make([]uint32, 0, len(ud.ExtensionProperties))
+	for key := range ud.ExtensionProperties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		maps.dword(key)
+		if err := encodePropertiesMap(maps, ud.ExtensionProperties[key]); err != nil {
+			return err
+		}
+		count++
+	}
+
+	// size includes size and maps count fields
+	s.dword(uint32(maps.Len() + 8))
+	s.dword(uint32(count))
+	s.Write(maps.Bytes())
+	return nil
+}
+
+// encodePropertiesMap writes properties sorted by name so that output does not depend on map order
+func encodePropertiesMap(s *sink, props map[string]any) error {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	s.dword(uint32(len(names)))
+	for _, name := range names {
+		valueType, err := propertyType(props[name])
+		if err != nil {
+			return fmt.Errorf("property %q: %w", name, err)
+		}
+		s.string(name)
+		s.word(valueType)
+		if err := encodePropertyValue(s, props[name]); err != nil {
+			return fmt.Errorf("property %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// propertyType returns type of property value, int is written as int64
+func propertyType(value any) (uint16, error) {
+	switch value.(type) {
+	case bool:
+		return propBool, nil
+	case int8:
+		return propInt8, nil
+	case uint8:
+		return propUint8, nil
+	case int16:
+		return propInt16, nil
+	case uint16:
+		return propUint16, nil
+	case int32:
+		return propInt32, nil
+	case uint32:
+		return propUint32, nil
+	case int64, int:
+		return propInt64, nil
+	case uint64:
+		return propUint64, nil
+	case Fixed:
+		return propFixed, nil
+	case float32:
+		return propFloat, nil
+	case float64:
+		return propDouble, nil
+	case string:
+		return propString, nil
+	case image.Point:
+		return propPoint, nil
+	case Size:
+		return propSize, nil
+	case image.Rectangle:
+		return propRect, nil
+	case []any:
+		return propVector, nil
+	case map[string]any:
+		return propMap, nil
+	case UUID:
+		return propUUID, nil
+	default:
+		return 0, fmt.Errorf("unsupported property value type %T", value)
+	}
+}
+
+func encodePropertyValue(s *sink, value any) error {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			s.byte(1)
+		} else {
+			s.byte(0)
+		}
+	case int8:
+		s.byte(uint8(v))
+	case uint8:
+		s.byte(v)
+	case int16:
+		s.short(v)
+	case uint16:
+		s.word(v)
+	case int32:
+		s.long(v)
+	case uint32:
+		s.dword(v)
+	case int64:
+		s.qword(uint64(v))
+	case int:
+		s.qword(uint64(v))
+	case uint64:
+		s.qword(v)
+	case Fixed:
+		s.long(int32(v))
+	case float32:
+		s.dword(math.Float32bits(v))
+	case float64:
+		s.qword(math.Float64bits(v))
+	case string:
+		s.string(v)
+	case image.Point:
+		s.long(int32(v.X))
+		s.long(int32(v.Y))
+	case Size:
+		s.long(v.Width)
+		s.long(v.Height)
+	case image.Rectangle:
+		s.long(int32(v.Min.X))
+		s.long(int32(v.Min.Y))
+		s.long(int32(v.Dx()))
+		s.long(int32(v.Dy()))
+	case []any:
+		return encodePropertyVector(s, v)
+	case map[string]any:
+		return encodePropertiesMap(s, v)
+	case UUID:
+		s.Write(v[:])
+	default:
+		return fmt.Errorf("unsupported property value type %T", value)
+	}
+	return nil
+}
+
+// encodePropertyVector writes vector with common elements type, or type 0 and type of every element
+func encodePropertyVector(s *sink, values []any) error {
+	types := make([]uint16, len(values))
+	common := true
+	for i, value := range values {
+		t, err := propertyType(value)
+		if err != nil {
+			return err
+		}
+		types[i] = t
+		common = common && t == types[0]
+	}
+
+	s.dword(uint32(len(values)))
+	if common && len(values) > 0 {
+		s.word(types[0])
+	} else {
+		s.word(0)
+	}
+
+	for i, value := range values {
+		if !common {
+			s.word(types[i])
+		}
+		if err := encodePropertyValue(s, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
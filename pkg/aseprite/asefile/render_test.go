@@ -0,0 +1,99 @@
+package asefile_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/stretchr/testify/assert"
+)
+
+// renderSprite returns 1x1 RGB sprite with one cel of color per layer, layers with parent are children of the
+// previous group layer
+func renderSprite(layers []*asefile.Layer, colors []color.NRGBA) *asefile.Sprite {
+	sprite := &asefile.Sprite{
+		Width: 1, Height: 1, ColorDepth: asefile.ColorDepthRGBA,
+		Flags:  asefile.HeaderFlagGroupBlendValid,
+		Frames: []*asefile.Frame{{}},
+	}
+
+	var group *asefile.Layer
+	for i, layer := range layers {
+		layer.Index = i
+		layer.Flags |= asefile.LayerFlagVisible
+		if layer.ChildLevel > 0 {
+			layer.Parent = group
+			group.Children = append(group.Children, layer)
+		}
+		if layer.IsGroup() {
+			group = layer
+			continue
+		}
+
+		c := colors[i]
+		sprite.Frames[0].Cels = append(sprite.Frames[0].Cels, &asefile.Cel{
+			LayerIndex: i, Opacity: 255, Width: 1, Height: 1, Pixels: []byte{c.R, c.G, c.B, c.A},
+		})
+	}
+	sprite.Layers = layers
+	return sprite
+}
+
+func TestFrameImageBlendModes(t *testing.T) {
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	backdrop := color.NRGBA{R: 200, G: 100, B: 0, A: 255}
+
+	tests := []struct {
+		mode asefile.BlendMode
+		want color.NRGBA
+	}{
+		{asefile.BlendNormal, gray},
+		{asefile.BlendMultiply, color.NRGBA{R: 100, G: 50, A: 255}},
+		{asefile.BlendScreen, color.NRGBA{R: 228, G: 178, B: 128, A: 255}},
+		{asefile.BlendDarken, color.NRGBA{R: 128, G: 100, A: 255}},
+		{asefile.BlendLighten, color.NRGBA{R: 200, G: 128, B: 128, A: 255}},
+		{asefile.BlendDifference, color.NRGBA{R: 72, G: 28, B: 128, A: 255}},
+		{asefile.BlendAddition, color.NRGBA{R: 255, G: 228, B: 128, A: 255}},
+		{asefile.BlendSubtract, color.NRGBA{R: 72, A: 255}},
+		{asefile.BlendLuminosity, color.NRGBA{R: 209, G: 109, B: 9, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			sprite := renderSprite([]*asefile.Layer{
+				{Name: "back", Opacity: 255},
+				{Name: "top", Opacity: 255, BlendMode: tt.mode},
+			}, []color.NRGBA{backdrop, gray})
+
+			assert.Equal(t, tt.want, sprite.FrameImage(0, nil).NRGBAAt(0, 0))
+		})
+	}
+
+	// blend modes have no effect over transparent pixels
+	sprite := renderSprite([]*asefile.Layer{{Name: "top", Opacity: 255, BlendMode: asefile.BlendMultiply}}, []color.NRGBA{gray})
+	assert.Equal(t, gray, sprite.FrameImage(0, nil).NRGBAAt(0, 0))
+}
+
+func TestFrameImageGroups(t *testing.T) {
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	layers := func(groupOpacity uint8) []*asefile.Layer {
+		return []*asefile.Layer{
+			{Name: "group", Type: asefile.LayerTypeGroup, Opacity: groupOpacity},
+			{Name: "red", ChildLevel: 1, Opacity: 255},
+			{Name: "blue", ChildLevel: 1, Opacity: 255},
+		}
+	}
+	colors := []color.NRGBA{{}, red, blue}
+
+	// children are flattened first, so red under blue is not visible through translucent group
+	sprite := renderSprite(layers(128), colors)
+	assert.Equal(t, color.NRGBA{B: 255, A: 128}, sprite.FrameImage(0, nil).NRGBAAt(0, 0))
+
+	// group blend mode and opacity are ignored unless header marks them valid
+	sprite = renderSprite(layers(128), colors)
+	sprite.Flags = 0
+	assert.Equal(t, blue, sprite.FrameImage(0, nil).NRGBAAt(0, 0))
+
+	sprite = renderSprite(layers(0), colors)
+	assert.Equal(t, color.NRGBA{}, sprite.FrameImage(0, nil).NRGBAAt(0, 0))
+}
@@ -0,0 +1,102 @@
+package asefile
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// stream reads little-endian aseprite primitives from byte slice.
+// After first out of bounds read all following reads return zero values and err is set.
+type stream struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func newStream(buf []byte) *stream {
+	return &stream{buf: buf}
+}
+
+func (s *stream) take(n int) []byte {
+	if s.err != nil {
+		return nil
+	}
+	if n < 0 || s.pos+n > len(s.buf) {
+		s.err = ErrUnexpectedEOF
+		return nil
+	}
+	b := s.buf[s.pos : s.pos+n]
+	s.pos += n
+	return b
+}
+
+func (s *stream) skip(n int) {
+	s.take(n)
+}
+
+func (s *stream) remaining() int {
+	return len(s.buf) - s.pos
+}
+
+func (s *stream) byte() uint8 {
+	b := s.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (s *stream) word() uint16 {
+	b := s.take(2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (s *stream) short() int16 {
+	return int16(s.word())
+}
+
+func (s *stream) dword() uint32 {
+	b := s.take(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (s *stream) long() int32 {
+	return int32(s.dword())
+}
+
+func (s *stream) qword() uint64 {
+	b := s.take(8)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (s *stream) fixed() Fixed {
+	return Fixed(s.long())
+}
+
+func (s *stream) float() float32 {
+	return math.Float32frombits(s.dword())
+}
+
+func (s *stream) double() float64 {
+	return math.Float64frombits(s.qword())
+}
+
+func (s *stream) string() string {
+	n := int(s.word())
+	return string(s.take(n))
+}
+
+func (s *stream) uuid() UUID {
+	var id UUID
+	copy(id[:], s.take(len(id)))
+	return id
+}
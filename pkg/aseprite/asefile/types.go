@@ -0,0 +1,502 @@
+package asefile
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"time"
+)
+
+// ColorDepth is the number of bits per pixel used by sprite cels
+type ColorDepth uint16
+
+const (
+	ColorDepthIndexed   ColorDepth = 8
+	ColorDepthGrayscale ColorDepth = 16
+	ColorDepthRGBA      ColorDepth = 32
+)
+
+// BytesPerPixel returns size of one pixel stored in cel data
+func (d ColorDepth) BytesPerPixel() int {
+	return int(d) / 8
+}
+
+func (d ColorDepth) String() string {
+	switch d {
+	case ColorDepthIndexed:
+		return "indexed"
+	case ColorDepthGrayscale:
+		return "grayscale"
+	case ColorDepthRGBA:
+		return "rgb"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// HeaderFlagLayerOpacityValid marks that layer opacity field has valid value
+	HeaderFlagLayerOpacityValid uint32 = 1
+	// HeaderFlagGroupBlendValid marks that groups have valid blend mode and opacity
+	HeaderFlagGroupBlendValid uint32 = 2
+	// HeaderFlagLayersHaveUUID marks that every layer chunk stores UUID
+	HeaderFlagLayersHaveUUID uint32 = 4
+)
+
+type LayerType uint16
+
+const (
+	LayerTypeImage   LayerType = 0
+	LayerTypeGroup   LayerType = 1
+	LayerTypeTilemap LayerType = 2
+)
+
+func (t LayerType) String() string {
+	switch t {
+	case LayerTypeImage:
+		return "image"
+	case LayerTypeGroup:
+		return "group"
+	case LayerTypeTilemap:
+		return "tilemap"
+	default:
+		return "unknown"
+	}
+}
+
+type LayerFlags uint16
+
+const (
+	LayerFlagVisible          LayerFlags = 1
+	LayerFlagEditable         LayerFlags = 2
+	LayerFlagLockMovement     LayerFlags = 4
+	LayerFlagBackground       LayerFlags = 8
+	LayerFlagPreferLinkedCels LayerFlags = 16
+	LayerFlagCollapsed        LayerFlags = 32
+	LayerFlagReference        LayerFlags = 64
+)
+
+type BlendMode uint16
+
+const (
+	BlendNormal BlendMode = iota
+	BlendMultiply
+	BlendScreen
+	BlendOverlay
+	BlendDarken
+	BlendLighten
+	BlendColorDodge
+	BlendColorBurn
+	BlendHardLight
+	BlendSoftLight
+	BlendDifference
+	BlendExclusion
+	BlendHue
+	BlendSaturation
+	BlendColor
+	BlendLuminosity
+	BlendAddition
+	BlendSubtract
+	BlendDivide
+)
+
+var blendModeNames = []string{
+	"normal", "multiply", "screen", "overlay", "darken", "lighten",
+	"color-dodge", "color-burn", "hard-light", "soft-light", "difference",
+	"exclusion", "hue", "saturation", "color", "luminosity", "addition",
+	"subtract", "divide",
+}
+
+func (m BlendMode) String() string {
+	if int(m) < len(blendModeNames) {
+		return blendModeNames[m]
+	}
+	return "unknown"
+}
+
+type CelType uint16
+
+const (
+	CelTypeRaw               CelType = 0
+	CelTypeLinked            CelType = 1
+	CelTypeCompressedImage   CelType = 2
+	CelTypeCompressedTilemap CelType = 3
+)
+
+type TagDirection uint8
+
+const (
+	TagForward TagDirection = iota
+	TagReverse
+	TagPingPong
+	TagPingPongReverse
+)
+
+func (d TagDirection) String() string {
+	switch d {
+	case TagForward:
+		return "forward"
+	case TagReverse:
+		return "reverse"
+	case TagPingPong:
+		return "pingpong"
+	case TagPingPongReverse:
+		return "pingpong-reverse"
+	default:
+		return "unknown"
+	}
+}
+
+type SliceFlags uint32
+
+const (
+	SliceFlagNinePatch SliceFlags = 1
+	SliceFlagPivot     SliceFlags = 2
+)
+
+type TilesetFlags uint32
+
+const (
+	TilesetFlagExternalFile TilesetFlags = 1
+	TilesetFlagEmbedded     TilesetFlags = 2
+	TilesetFlagEmptyTileID  TilesetFlags = 4
+)
+
+// Sprite is in-memory representation of .aseprite/.ase file
+type Sprite struct {
+	Width            int
+	Height           int
+	ColorDepth       ColorDepth
+	Flags            uint32
+	TransparentIndex uint8
+	NumColors        int
+	PixelWidth       uint8
+	PixelHeight      uint8
+	Grid             image.Rectangle
+
+	Frames        []*Frame
+	Layers        []*Layer
+	Tags          []*Tag
+	Slices        []*Slice
+	Tilesets      []*Tileset
+	Palette       Palette
+	ColorProfile  *ColorProfile
+	ExternalFiles []ExternalFile
+	UserData      UserData
+}
+
+type Frame struct {
+	Index    int
+	Duration time.Duration
+	Cels     []*Cel
+}
+
+type Layer struct {
+	Index        int
+	Name         string
+	Flags        LayerFlags
+	Type         LayerType
+	ChildLevel   int
+	BlendMode    BlendMode
+	Opacity      uint8
+	TilesetIndex int
+	UUID         *UUID
+	UserData     UserData
+
+	Parent   *Layer
+	Children []*Layer
+}
+
+type Cel struct {
+	LayerIndex  int
+	Frame       int
+	X           int
+	Y           int
+	Opacity     uint8
+	Type        CelType
+	ZIndex      int
+	Width       int
+	Height      int
+	Pixels      []byte
+	LinkedFrame int
+	Tilemap     *Tilemap
+	Extra       *CelExtra
+	UserData    UserData
+}
+
+// CelExtra is precise cel bounds stored by Aseprite for cels with sub-pixel position
+type CelExtra struct {
+	Flags  uint32
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+type Tilemap struct {
+	Width            int
+	Height           int
+	BitsPerTile      int
+	TileIDMask       uint32
+	XFlipMask        uint32
+	YFlipMask        uint32
+	DiagonalFlipMask uint32
+	Tiles            []uint32
+}
+
+// Tilemap masks written by Aseprite 1.3 for 32 bits tiles
+const (
+	TileIDMask           uint32 = 0x1fffffff
+	TileFlipXMask        uint32 = 0x20000000
+	TileFlipYMask        uint32 = 0x40000000
+	TileFlipDiagonalMask uint32 = 0x80000000
+)
+
+// Tile is a single decoded tilemap cell
+type Tile struct {
+	ID           uint32
+	FlipX        bool
+	FlipY        bool
+	FlipDiagonal bool
+}
+
+type Tag struct {
+	Name      string
+	From      int
+	To        int
+	Direction TagDirection
+	Repeat    int
+	Color     color.NRGBA
+	UserData  UserData
+}
+
+type Slice struct {
+	Name     string
+	Flags    SliceFlags
+	Keys     []SliceKey
+	UserData UserData
+}
+
+type SliceKey struct {
+	Frame  int
+	Bounds image.Rectangle
+	// Center is nine-patch center relative to Bounds (nil if slice is not nine-patch)
+	Center *image.Rectangle
+	// Pivot is relative to Bounds origin (nil if slice has no pivot)
+	Pivot *image.Point
+}
+
+type Tileset struct {
+	ID                uint32
+	Flags             TilesetFlags
+	NumTiles          int
+	TileWidth         int
+	TileHeight        int
+	BaseIndex         int
+	Name              string
+	ExternalFileID    uint32
+	ExternalTilesetID uint32
+	// Pixels holds all tiles stacked vertically (TileWidth x TileHeight*NumTiles)
+	Pixels   []byte
+	UserData UserData
+}
+
+type Palette struct {
+	Entries []PaletteEntry
+}
+
+type PaletteEntry struct {
+	Color color.NRGBA
+	Name  string
+}
+
+type ColorProfile struct {
+	Type  uint16
+	Flags uint16
+	Gamma float64
+	ICC   []byte
+}
+
+type ExternalFile struct {
+	ID   uint32
+	Type uint8
+	Name string
+}
+
+type UUID [16]byte
+
+// UserData is text, color and properties attached to sprite, layer, cel, tag, slice or tileset
+type UserData struct {
+	Text  string
+	Color *color.NRGBA
+	// Properties are user defined properties (map key 0 in file)
+	Properties map[string]any
+	// ExtensionProperties are properties of extensions keyed by external file entry id
+	ExtensionProperties map[uint32]map[string]any
+}
+
+// IsEmpty reports whether no user data was stored
+func (u UserData) IsEmpty() bool {
+	return u.Text == "" && u.Color == nil && len(u.Properties) == 0 && len(u.ExtensionProperties) == 0
+}
+
+// Fixed is 16.16 fixed point number used by properties
+type Fixed int32
+
+func (f Fixed) Float() float64 {
+	return float64(f) / 65536
+}
+
+// Size is a property value of SIZE type
+type Size struct {
+	Width  int32
+	Height int32
+}
+
+func (l *Layer) Visible() bool {
+	return l.Flags&LayerFlagVisible != 0
+}
+
+func (l *Layer) IsBackground() bool {
+	return l.Flags&LayerFlagBackground != 0
+}
+
+func (l *Layer) IsReference() bool {
+	return l.Flags&LayerFlagReference != 0
+}
+
+func (l *Layer) IsGroup() bool {
+	return l.Type == LayerTypeGroup
+}
+
+// Path returns layer name prefixed by names of parent groups (e.g. "body/arm")
+func (l *Layer) Path() string {
+	names := []string{l.Name}
+	for p := l.Parent; p != nil; p = p.Parent {
+		names = append([]string{p.Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+// VisibleInTree reports whether layer and all its parent groups are visible
+func (l *Layer) VisibleInTree() bool {
+	for cur := l; cur != nil; cur = cur.Parent {
+		if !cur.Visible() {
+			return false
+		}
+	}
+	return true
+}
+
+// TopLevelLayers returns layers without parent group in bottom to top order
+func (s *Sprite) TopLevelLayers() []*Layer {
+	var layers []*Layer
+	for _, l := range s.Layers {
+		if l.Parent == nil {
+			layers = append(layers, l)
+		}
+	}
+	return layers
+}
+
+// LayerByPath finds layer by its group path (see Layer.Path)
+func (s *Sprite) LayerByPath(path string) *Layer {
+	for _, l := range s.Layers {
+		if l.Path() == path {
+			return l
+		}
+	}
+	return nil
+}
+
+// TagByName returns first tag with given name
+func (s *Sprite) TagByName(name string) *Tag {
+	for _, t := range s.Tags {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Cel returns cel of layer on frame resolving linked cels. Returns nil if cel is empty
+func (s *Sprite) Cel(frame, layer int) *Cel {
+	if frame < 0 || frame >= len(s.Frames) {
+		return nil
+	}
+
+	for _, c := range s.Frames[frame].Cels {
+		if c.LayerIndex != layer {
+			continue
+		}
+		if c.Type == CelTypeLinked {
+			// linked cels point to earlier frames, other links would never be resolved
+			if c.LinkedFrame < 0 || c.LinkedFrame >= frame {
+				return nil
+			}
+			linked := s.Cel(c.LinkedFrame, layer)
+			if linked == nil {
+				return nil
+			}
+			resolved := *linked
+			resolved.Frame = frame
+			resolved.X, resolved.Y, resolved.Opacity, resolved.ZIndex = c.X, c.Y, c.Opacity, c.ZIndex
+			resolved.UserData = c.UserData
+			return &resolved
+		}
+		return c
+	}
+
+	return nil
+}
+
+// TileAt decodes tilemap cell at given tile coordinates
+func (t *Tilemap) TileAt(x, y int) Tile {
+	v := t.Tiles[y*t.Width+x]
+	return Tile{
+		ID:           v & t.TileIDMask,
+		FlipX:        t.XFlipMask != 0 && v&t.XFlipMask != 0,
+		FlipY:        t.YFlipMask != 0 && v&t.YFlipMask != 0,
+		FlipDiagonal: t.DiagonalFlipMask != 0 && v&t.DiagonalFlipMask != 0,
+	}
+}
+
+// NewTilemap returns 32 bits tilemap with Aseprite masks, tiles are stored row by row
+func NewTilemap(width, height int, tiles []Tile) *Tilemap {
+	tm := &Tilemap{
+		Width:            width,
+		Height:           height,
+		BitsPerTile:      32,
+		TileIDMask:       TileIDMask,
+		XFlipMask:        TileFlipXMask,
+		YFlipMask:        TileFlipYMask,
+		DiagonalFlipMask: TileFlipDiagonalMask,
+		Tiles:            make([]uint32, width*height),
+	}
+
+	for i, tile := range tiles[:min(len(tiles), len(tm.Tiles))] {
+		v := tile.ID & TileIDMask
+		if tile.FlipX {
+			v |= TileFlipXMask
+		}
+		if tile.FlipY {
+			v |= TileFlipYMask
+		}
+		if tile.FlipDiagonal {
+			v |= TileFlipDiagonalMask
+		}
+		tm.Tiles[i] = v
+	}
+	return tm
+}
+
+// KeyAt returns slice key active on frame (keys are valid until next key frame)
+func (s *Slice) KeyAt(frame int) *SliceKey {
+	var active *SliceKey
+	for i := range s.Keys {
+		if s.Keys[i].Frame <= frame {
+			active = &s.Keys[i]
+		}
+	}
+	return active
+}
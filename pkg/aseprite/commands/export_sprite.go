@@ -7,6 +7,10 @@ type ExportSprite struct {
 	OutputFilename    string `script:"output-filename" format:"quotes"`
 	FramesIncluded    string `script:"frames-included"`
 	SelectedLayerName string `script:"layer-selected" format:"quotes"`
+	VisibleLayers     string `script:"visible-layers"`
+	Tags              string `script:"tags" format:"quotes"`
+	SplitLayers       bool   `script:"split-layers"`
+	SplitFrames       bool   `script:"split-frames"`
 	Format            string
 	Sizes             string
 	Scales            string
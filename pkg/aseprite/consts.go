@@ -1,8 +1,7 @@
 package aseprite
 
 const (
-	Name                 = "Aseprite"
-	ScriptsHelpersFolder = "helpers"
+	Name = "Aseprite"
 )
 
 type ColorMode string
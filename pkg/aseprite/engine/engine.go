@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/packer"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// Engine is game engine which animation metadata is written next to sprite sheet
+type Engine string
+
+const (
+	// Godot writes SpriteFrames resource (.tres) with AtlasTexture per frame
+	Godot Engine = "godot"
+	// Phaser writes atlas JSON and animations JSON (.anims.json) for anims.fromJSON
+	Phaser Engine = "phaser"
+	// LibGDX writes texture atlas (.atlas) with indexed regions per animation
+	LibGDX Engine = "libgdx"
+	// Unity writes texture importer (.png.meta) slicing sheet into sprites
+	Unity Engine = "unity"
+)
+
+func Engines() []string {
+	return []string{string(Godot), string(Phaser), string(LibGDX), string(Unity)}
+}
+
+// ValidateEngines checks engines names
+func ValidateEngines(engines []string) error {
+	for _, engine := range engines {
+		if !slices.Contains(Engines(), engine) {
+			return fmt.Errorf("unknown engine %q, available engines: %s", engine, strings.Join(Engines(), ", "))
+		}
+	}
+	return nil
+}
+
+// Params describes engine export of one sprite
+type Params struct {
+	SpriteFilename string
+	// ImageFilename is sprite sheet PNG, engine files are written next to it
+	ImageFilename string
+	Engines       []string
+	// Tags are exported animations, every sprite tag without them
+	Tags          []string
+	IncludeLayers []string
+	ExcludeLayers []string
+	IncludeHidden bool
+}
+
+// Sheet is sprite frames packed to one image with animations of sprite tags
+type Sheet struct {
+	// Name is sprite filename without extension
+	Name          string
+	ImageFilename string
+	Atlas         *packer.Atlas
+	Animations    []Animation
+}
+
+// Animation is range of sheet frames played by engine
+type Animation struct {
+	Name string
+	// Frames are indices of atlas placements in tag order
+	Frames    []int
+	Direction asefile.TagDirection
+	// Repeat is number of plays, 0 is infinite loop
+	Repeat int
+}
+
+// File is engine metadata file content
+type File struct {
+	Filename string
+	Data     []byte
+}
+
+// NewSheet renders frames of sprite selected layers and packs them in rows merging identical frames.
+// Animations are made of sprite tags, sprite without tags has one animation named as sprite
+func NewSheet(params Params) (*Sheet, error) {
+	if !files.CheckFileExists(params.SpriteFilename, false) ||
+		!files.CheckFileExtension(params.SpriteFilename, aseprite.SpritesExtensions()...) {
+		return nil, fmt.Errorf("invalid sprite filename: %q", params.SpriteFilename)
+	}
+	if !files.CheckFileExtension(params.ImageFilename, ".png") {
+		return nil, fmt.Errorf("engine sprite sheet must be .png file: %s", params.ImageFilename)
+	}
+
+	sprite, err := asefile.ReadFile(params.SpriteFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sprite: %w", err)
+	}
+
+	selected, err := exporter.SelectLayers(sprite, params.IncludeLayers, params.ExcludeLayers, params.IncludeHidden)
+	if err != nil {
+		return nil, err
+	}
+	include := func(l *asefile.Layer) bool { return slices.Contains(selected, l) }
+
+	frames, tags, err := packer.SpriteFrames(sprite, params.SpriteFilename, params.Tags, include)
+	if err != nil {
+		return nil, err
+	}
+
+	atlas, err := packer.Pack(frames, tags, packer.Options{Layout: packer.LayoutRows, MergeDuplicates: true})
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &Sheet{
+		Name:          strings.TrimSuffix(filepath.Base(params.SpriteFilename), filepath.Ext(params.SpriteFilename)),
+		ImageFilename: params.ImageFilename,
+		Atlas:         atlas,
+	}
+
+	for _, tag := range tags {
+		animation := Animation{Name: tag.Name, Repeat: tag.Repeat}
+		if spriteTag := sprite.TagByName(tag.Name); spriteTag != nil {
+			animation.Direction = spriteTag.Direction
+		}
+		for i := tag.From; i <= tag.To; i++ {
+			animation.Frames = append(animation.Frames, i)
+		}
+		sheet.Animations = append(sheet.Animations, animation)
+	}
+
+	if len(sheet.Animations) == 0 {
+		animation := Animation{Name: sheet.Name}
+		for i := range atlas.Placements {
+			animation.Frames = append(animation.Frames, i)
+		}
+		sheet.Animations = append(sheet.Animations, animation)
+	}
+
+	return sheet, nil
+}
+
+// Files returns metadata files of engine
+func (s *Sheet) Files(engine Engine) ([]File, error) {
+	switch engine {
+	case Godot:
+		return s.godotFiles()
+	case Phaser:
+		return s.phaserFiles()
+	case LibGDX:
+		return s.libgdxFiles()
+	case Unity:
+		return s.unityFiles()
+	default:
+		return nil, fmt.Errorf("unknown engine %q, available engines: %s", engine, strings.Join(Engines(), ", "))
+	}
+}
+
+// Export writes sprite sheet and metadata files of every engine, returns written filenames
+func Export(params Params) ([]string, error) {
+	if err := ValidateEngines(params.Engines); err != nil {
+		return nil, err
+	}
+
+	sheet, err := NewSheet(params)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := encodePNG(sheet.Atlas)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := []File{{Filename: params.ImageFilename, Data: image}}
+	for _, engine := range params.Engines {
+		engineFiles, err := sheet.Files(Engine(engine))
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, engineFiles...)
+	}
+
+	var written []string
+	for _, output := range outputs {
+		if err := files.EnsureDirExists(output.Filename); err != nil {
+			return written, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err := os.WriteFile(output.Filename, output.Data, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, output.Filename)
+	}
+
+	return written, nil
+}
+
+// sequence returns animation frames in play order with reverse and ping-pong directions expanded
+func (a Animation) sequence() []int {
+	frames := slices.Clone(a.Frames)
+	if a.Direction == asefile.TagReverse || a.Direction == asefile.TagPingPongReverse {
+		slices.Reverse(frames)
+	}
+
+	if (a.Direction == asefile.TagPingPong || a.Direction == asefile.TagPingPongReverse) && len(frames) > 2 {
+		back := slices.Clone(frames[1 : len(frames)-1])
+		slices.Reverse(back)
+		frames = append(frames, back...)
+	}
+
+	return frames
+}
+
+// baseDuration returns shortest frame duration of animation in milliseconds
+func (s *Sheet) baseDuration(frames []int) int {
+	base := 0
+	for _, i := range frames {
+		ms := int(s.Atlas.Placements[i].Frame.Duration.Milliseconds())
+		if ms > 0 && (base == 0 || ms < base) {
+			base = ms
+		}
+	}
+	if base == 0 {
+		return int(packer.DefaultFrameDuration.Milliseconds())
+	}
+	return base
+}
+
+// sibling returns filename next to sheet image with image extension replaced by suffix
+func (s *Sheet) sibling(suffix string) string {
+	return strings.TrimSuffix(s.ImageFilename, filepath.Ext(s.ImageFilename)) + suffix
+}
+
+func encodePNG(atlas *packer.Atlas) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, atlas.Image); err != nil {
+		return nil, fmt.Errorf("failed to encode sprite sheet: %w", err)
+	}
+	return buf.Bytes(), nil
+}
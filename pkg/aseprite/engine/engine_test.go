@@ -0,0 +1,132 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/engine"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/packer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSheet(t *testing.T) *engine.Sheet {
+	var frames []packer.Frame
+	for i, ms := range []int{100, 200, 100} {
+		img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+		img.Pix[i*4+3] = 255
+		frames = append(frames, packer.Frame{
+			Name:     "hero " + string(rune('0'+i)) + ".aseprite",
+			Image:    img,
+			Duration: time.Duration(ms) * time.Millisecond,
+		})
+	}
+
+	atlas, err := packer.Pack(frames, nil, packer.Options{Layout: packer.LayoutRows, Count: 2})
+	require.NoError(t, err)
+
+	return &engine.Sheet{
+		Name:          "hero",
+		ImageFilename: "out/hero.png",
+		Atlas:         atlas,
+		Animations: []engine.Animation{
+			{Name: "walk", Frames: []int{0, 1, 2}, Direction: asefile.TagPingPong},
+			{Name: "hit", Frames: []int{2}, Repeat: 1},
+		},
+	}
+}
+
+func filesData(t *testing.T, sheet *engine.Sheet, e engine.Engine) map[string]string {
+	files, err := sheet.Files(e)
+	require.NoError(t, err)
+
+	data := make(map[string]string)
+	for _, file := range files {
+		data[file.Filename] = string(file.Data)
+	}
+
+	// output must not change between runs to keep diffs clean
+	again, err := sheet.Files(e)
+	require.NoError(t, err)
+	for _, file := range again {
+		assert.Equal(t, data[file.Filename], string(file.Data), "%s output is not deterministic", e)
+	}
+
+	return data
+}
+
+func TestGodotSpriteFrames(t *testing.T) {
+	tres := filesData(t, testSheet(t), engine.Godot)["out/hero.tres"]
+
+	assert.True(t, strings.HasPrefix(tres, "[gd_resource type=\"SpriteFrames\" load_steps=5 format=3]"))
+	assert.Contains(t, tres, "path=\"hero.png\"")
+	assert.Contains(t, tres, "region = Rect2(8, 0, 8, 8)")
+	assert.Contains(t, tres, "\"duration\": 2.0")
+	assert.Contains(t, tres, "\"name\": &\"walk\"")
+	assert.Contains(t, tres, "\"speed\": 10.0")
+	assert.Contains(t, tres, "\"loop\": false")
+	// ping-pong walk is expanded to 0, 1, 2, 1 frames, hit has one frame
+	assert.Equal(t, 5, strings.Count(tres, "\"texture\": SubResource"))
+}
+
+func TestPhaserAnims(t *testing.T) {
+	data := filesData(t, testSheet(t), engine.Phaser)
+	require.Contains(t, data, "out/hero.json")
+
+	var anims struct {
+		Anims []struct {
+			Key    string `json:"key"`
+			Repeat int    `json:"repeat"`
+			Yoyo   bool   `json:"yoyo"`
+			Frames []struct {
+				Frame    string `json:"frame"`
+				Duration int    `json:"duration"`
+			} `json:"frames"`
+		} `json:"anims"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data["out/hero.anims.json"]), &anims))
+	require.Len(t, anims.Anims, 2)
+
+	assert.Equal(t, "walk", anims.Anims[0].Key)
+	assert.Equal(t, -1, anims.Anims[0].Repeat)
+	assert.True(t, anims.Anims[0].Yoyo)
+	assert.Equal(t, "hero 1.aseprite", anims.Anims[0].Frames[1].Frame)
+	assert.Equal(t, 100, anims.Anims[0].Frames[1].Duration)
+	assert.Equal(t, 0, anims.Anims[1].Repeat)
+}
+
+func TestLibGDXAndUnity(t *testing.T) {
+	sheet := testSheet(t)
+
+	atlas := filesData(t, sheet, engine.LibGDX)["out/hero.atlas"]
+	assert.True(t, strings.HasPrefix(atlas, "hero.png\nsize: 16, 16\n"))
+	assert.Contains(t, atlas, "walk\n  rotate: false\n  xy: 0, 8\n  size: 8, 8\n  orig: 8, 8\n  offset: 0, 0\n  index: 2\n")
+
+	meta := filesData(t, sheet, engine.Unity)["out/hero.png.meta"]
+	assert.Contains(t, meta, "spriteMode: 2")
+	// third frame is in second row, unity counts rows from bottom
+	assert.Contains(t, meta, "name: hero_2\n      rect:\n        serializedVersion: 2\n        x: 0\n        y: 0\n")
+	assert.Contains(t, meta, "name: hero_0\n      rect:\n        serializedVersion: 2\n        x: 0\n        y: 8\n")
+}
+
+func TestUnityKeepsExistingGUID(t *testing.T) {
+	sheet := testSheet(t)
+	sheet.ImageFilename = filepath.Join(t.TempDir(), "hero.png")
+
+	meta := filesData(t, sheet, engine.Unity)[sheet.ImageFilename+".meta"]
+	assert.Regexp(t, `(?m)^guid: [0-9a-f]{32}$`, meta)
+	assert.NotContains(t, meta, "guid: 0123456789abcdef0123456789abcdef")
+
+	// guid assigned by unity to imported sheet survives re-export
+	existing := "fileFormatVersion: 2\nguid: 0123456789ABCDEF0123456789abcdef\nTextureImporter:\n"
+	require.NoError(t, os.WriteFile(sheet.ImageFilename+".meta", []byte(existing), 0644))
+
+	meta = filesData(t, sheet, engine.Unity)[sheet.ImageFilename+".meta"]
+	assert.Contains(t, meta, "\nguid: 0123456789abcdef0123456789abcdef\n")
+}
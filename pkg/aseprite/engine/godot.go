@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// godotFiles writes Godot 4 SpriteFrames resource, texture path is relative to resource file
+func (s *Sheet) godotFiles() ([]File, error) {
+	// one AtlasTexture per atlas area, merged duplicates share it
+	var regions []image.Rectangle
+	regionIDs := make(map[image.Rectangle]int)
+	for _, p := range s.Atlas.Placements {
+		if _, found := regionIDs[p.Rect]; !found {
+			regions = append(regions, p.Rect)
+			regionIDs[p.Rect] = len(regions)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[gd_resource type=\"SpriteFrames\" load_steps=%d format=3]\n\n", len(regions)+2)
+	fmt.Fprintf(&b, "[ext_resource type=\"Texture2D\" path=%q id=\"1_sheet\"]\n", filepath.Base(s.ImageFilename))
+
+	for i, r := range regions {
+		fmt.Fprintf(&b, "\n[sub_resource type=\"AtlasTexture\" id=\"AtlasTexture_%d\"]\n", i+1)
+		b.WriteString("atlas = ExtResource(\"1_sheet\")\n")
+		fmt.Fprintf(&b, "region = Rect2(%d, %d, %d, %d)\n", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+
+	b.WriteString("\n[resource]\nanimations = [")
+	for i, animation := range s.Animations {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		// Godot frame duration is multiplier of animation speed (frames per second)
+		frames := animation.sequence()
+		base := s.baseDuration(frames)
+
+		b.WriteString("{\n\"frames\": [")
+		for j, frame := range frames {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			p := s.Atlas.Placements[frame]
+			fmt.Fprintf(&b, "{\n\"duration\": %s,\n\"texture\": SubResource(\"AtlasTexture_%d\")\n}",
+				godotFloat(float64(p.Frame.Duration.Milliseconds())/float64(base)), regionIDs[p.Rect])
+		}
+		b.WriteString("],\n")
+		fmt.Fprintf(&b, "\"loop\": %t,\n", animation.Repeat == 0)
+		fmt.Fprintf(&b, "\"name\": &%q,\n", animation.Name)
+		fmt.Fprintf(&b, "\"speed\": %s\n}", godotFloat(1000/float64(base)))
+	}
+	b.WriteString("]\n")
+
+	return []File{{Filename: s.sibling(".tres"), Data: []byte(b.String())}}, nil
+}
+
+// godotFloat formats float as Godot writes it (always with fractional part)
+func godotFloat(v float64) string {
+	formatted := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// libgdxFiles writes texture atlas with regions named by animation and indexed by frame position,
+// so animation frames are found by TextureAtlas.findRegions(name)
+func (s *Sheet) libgdxFiles() ([]File, error) {
+	size := s.Atlas.Image.Rect.Size()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", filepath.Base(s.ImageFilename))
+	fmt.Fprintf(&b, "size: %d, %d\n", size.X, size.Y)
+	b.WriteString("format: RGBA8888\n")
+	b.WriteString("filter: Nearest, Nearest\n")
+	b.WriteString("repeat: none\n")
+
+	for _, animation := range s.Animations {
+		for index, i := range animation.Frames {
+			p := s.Atlas.Placements[i]
+			// offset is measured from bottom left corner of original frame
+			offsetY := p.SourceSize.Y - p.SourceRect.Max.Y
+
+			fmt.Fprintf(&b, "%s\n", animation.Name)
+			b.WriteString("  rotate: false\n")
+			fmt.Fprintf(&b, "  xy: %d, %d\n", p.Rect.Min.X, p.Rect.Min.Y)
+			fmt.Fprintf(&b, "  size: %d, %d\n", p.Rect.Dx(), p.Rect.Dy())
+			fmt.Fprintf(&b, "  orig: %d, %d\n", p.SourceSize.X, p.SourceSize.Y)
+			fmt.Fprintf(&b, "  offset: %d, %d\n", p.SourceRect.Min.X, offsetY)
+			fmt.Fprintf(&b, "  index: %d\n", index)
+		}
+	}
+
+	return []File{{Filename: s.sibling(".atlas"), Data: []byte(b.String())}}, nil
+}
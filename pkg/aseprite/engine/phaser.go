@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/packer"
+)
+
+type phaserAnimFrame struct {
+	Key   string `json:"key"`
+	Frame string `json:"frame"`
+	// Duration is added to animation frame time
+	Duration int `json:"duration"`
+}
+
+type phaserAnim struct {
+	Key       string            `json:"key"`
+	Type      string            `json:"type"`
+	Frames    []phaserAnimFrame `json:"frames"`
+	FrameRate float64           `json:"frameRate"`
+	Repeat    int               `json:"repeat"`
+	Yoyo      bool              `json:"yoyo"`
+}
+
+type phaserAnims struct {
+	Anims []phaserAnim `json:"anims"`
+}
+
+// phaserFiles writes atlas in JSON hash format (load.atlas) and animations for anims.fromJSON,
+// frames refer to atlas texture by sheet name
+func (s *Sheet) phaserFiles() ([]File, error) {
+	atlas, err := s.Atlas.Metadata(packer.DataHash, filepath.Base(s.ImageFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	data := phaserAnims{Anims: []phaserAnim{}}
+	for _, animation := range s.Animations {
+		frames := slices.Clone(animation.Frames)
+		reversed := animation.Direction == asefile.TagReverse || animation.Direction == asefile.TagPingPongReverse
+		if reversed {
+			slices.Reverse(frames)
+		}
+
+		// frame rate of shortest frame, longer frames get extra duration
+		base := s.baseDuration(frames)
+		anim := phaserAnim{
+			Key:       animation.Name,
+			Type:      "frame",
+			FrameRate: 1000 / float64(base),
+			Repeat:    animation.Repeat - 1,
+			Yoyo:      animation.Direction == asefile.TagPingPong || animation.Direction == asefile.TagPingPongReverse,
+		}
+
+		for _, i := range frames {
+			p := s.Atlas.Placements[i]
+			anim.Frames = append(anim.Frames, phaserAnimFrame{
+				Key:      s.Name,
+				Frame:    p.Frame.Name,
+				Duration: int(p.Frame.Duration.Milliseconds()) - base,
+			})
+		}
+
+		data.Anims = append(data.Anims, anim)
+	}
+
+	anims, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []File{
+		{Filename: s.sibling(".json"), Data: atlas},
+		{Filename: s.sibling(".anims.json"), Data: anims},
+	}, nil
+}
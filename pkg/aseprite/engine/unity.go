@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// unityGUIDPattern matches asset GUID line of unity .meta file
+var unityGUIDPattern = regexp.MustCompile(`(?m)^guid:\s*([0-9a-fA-F]{32})\s*$`)
+
+// unityFiles writes texture importer metadata slicing sheet into sprites (Sprite Mode: Multiple).
+// GUID of existing metadata is kept so that scenes and prefabs referencing imported sheet stay valid,
+// new GUID is derived from sheet filename and its directory name, so it is stable between exports
+func (s *Sheet) unityFiles() ([]File, error) {
+	height := s.Atlas.Image.Rect.Dy()
+	metaFilename := s.ImageFilename + ".meta"
+	guid, err := unityGUID(metaFilename)
+	if err != nil {
+		return nil, err
+	}
+	if guid == "" {
+		guid = unityHash(filepath.Base(filepath.Dir(s.ImageFilename)) + "/" + filepath.Base(s.ImageFilename))
+	}
+
+	var b strings.Builder
+	b.WriteString("fileFormatVersion: 2\n")
+	fmt.Fprintf(&b, "guid: %s\n", guid)
+	b.WriteString("TextureImporter:\n")
+	b.WriteString("  serializedVersion: 12\n")
+	b.WriteString("  mipmaps:\n    enableMipMap: 0\n")
+	b.WriteString("  textureSettings:\n    serializedVersion: 2\n    filterMode: 0\n    aniso: 1\n    mipBias: 0\n    wrapU: 1\n    wrapV: 1\n    wrapW: 1\n")
+	b.WriteString("  textureType: 8\n")
+	b.WriteString("  textureShape: 1\n")
+	b.WriteString("  spriteMode: 2\n")
+	b.WriteString("  spritePixelsToUnits: 100\n")
+	b.WriteString("  alphaIsTransparency: 1\n")
+	b.WriteString("  textureCompression: 0\n")
+	b.WriteString("  spriteSheet:\n")
+	b.WriteString("    serializedVersion: 2\n")
+	b.WriteString("    sprites:\n")
+
+	for i, p := range s.Atlas.Placements {
+		name := fmt.Sprintf("%s_%d", s.Name, i)
+		fmt.Fprintf(&b, "    - serializedVersion: 2\n")
+		fmt.Fprintf(&b, "      name: %s\n", name)
+		b.WriteString("      rect:\n")
+		b.WriteString("        serializedVersion: 2\n")
+		// unity rect origin is bottom left corner of texture
+		fmt.Fprintf(&b, "        x: %d\n        y: %d\n", p.Rect.Min.X, height-p.Rect.Max.Y)
+		fmt.Fprintf(&b, "        width: %d\n        height: %d\n", p.Rect.Dx(), p.Rect.Dy())
+		b.WriteString("      alignment: 0\n")
+		b.WriteString("      pivot: {x: 0.5, y: 0.5}\n")
+		b.WriteString("      border: {x: 0, y: 0, z: 0, w: 0}\n")
+		fmt.Fprintf(&b, "      spriteID: %s\n", unityHash(guid+name))
+	}
+
+	b.WriteString("  spritePackingTag:\n")
+	b.WriteString("  userData:\n")
+	b.WriteString("  assetBundleName:\n")
+	b.WriteString("  assetBundleVariant:\n")
+
+	return []File{{Filename: metaFilename, Data: []byte(b.String())}}, nil
+}
+
+// unityGUID returns GUID of existing unity metadata file, or empty string when there is none
+func unityGUID(metaFilename string) (string, error) {
+	data, err := os.ReadFile(metaFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read unity metadata %s: %w", metaFilename, err)
+	}
+
+	match := unityGUIDPattern.FindSubmatch(data)
+	if match == nil {
+		return "", nil
+	}
+	return strings.ToLower(string(match[1])), nil
+}
+
+func unityHash(value string) string {
+	sum := md5.Sum([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
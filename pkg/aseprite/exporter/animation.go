@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/apng"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+// FormatAPNG is animated png assembled from sprite frames without aseprite
+const FormatAPNG = "apng"
+
+// LoopCountTag makes animated png loop as many times as its tag repeats (forever without tag or repeat count)
+const LoopCountTag = -1
+
+// IsAnimatedFormat reports whether outputs of format are animations assembled by exporter
+func IsAnimatedFormat(format string) bool {
+	return strings.EqualFold(strings.TrimPrefix(format, "."), FormatAPNG)
+}
+
+// Animation is animated output with frames played in tag direction
+type Animation struct {
+	Filename string
+	// Layer is split layer name of split layers export, all selected layers are rendered if empty
+	Layer string
+	// Frames are sprite frames indices in play order
+	Frames []int
+	Loops  int
+	Scale  int
+	// Size is output size of sizes export, frames are scaled by Scale if empty
+	Size image.Point
+}
+
+func newAnimation(sprite *asefile.Sprite, params Params, output templateOutput) Animation {
+	direction := asefile.TagForward
+	loops := max(params.LoopCount, 0)
+	if tag := sprite.TagByName(output.Tag); tag != nil && output.Tag != "" {
+		direction = tag.Direction
+		if params.LoopCount == LoopCountTag {
+			loops = tag.Repeat
+		}
+	}
+
+	// scales and sizes are validated before outputs are expanded
+	scale, _ := strconv.Atoi(output.Scale)
+	var size image.Point
+	if params.Sizes != "" {
+		w, h, _ := strings.Cut(output.Size, "x")
+		size.X, _ = strconv.Atoi(strings.TrimSpace(w))
+		size.Y, _ = strconv.Atoi(strings.TrimSpace(h))
+	}
+
+	return Animation{
+		Filename: output.Filename,
+		Layer:    output.Layer,
+		Frames:   framesSequence(output.From, output.To, direction),
+		Loops:    loops,
+		Scale:    max(scale, 1),
+		Size:     size,
+	}
+}
+
+// framesSequence returns frames of range in play order with reverse and ping-pong directions expanded
+func framesSequence(from, to int, direction asefile.TagDirection) []int {
+	var frames []int
+	for i := from; i <= to; i++ {
+		frames = append(frames, i)
+	}
+
+	if direction == asefile.TagReverse || direction == asefile.TagPingPongReverse {
+		slices.Reverse(frames)
+	}
+
+	if (direction == asefile.TagPingPong || direction == asefile.TagPingPongReverse) && len(frames) > 2 {
+		back := slices.Clone(frames[1 : len(frames)-1])
+		slices.Reverse(back)
+		frames = append(frames, back...)
+	}
+
+	return frames
+}
+
+// writeAnimations renders frames of job animations from selected layers and writes animated png outputs
+func writeAnimations(job *Job) error {
+	for _, animation := range job.Animations {
+		include := job.includeLayer(animation.Layer)
+
+		var frames []apng.Frame
+		for _, index := range animation.Frames {
+			if index < 0 || index >= len(job.sprite.Frames) {
+				return fmt.Errorf("frame %d is out of sprite frames range", index)
+			}
+
+			img := job.sprite.FrameImage(index, include)
+			if animation.Size != (image.Point{}) {
+				img = resizeNearest(img, animation.Size)
+			} else if animation.Scale > 1 {
+				var err error
+				if img, err = job.Upscaler.Upscale(img, animation.Scale); err != nil {
+					return err
+				}
+			}
+			frames = append(frames, apng.Frame{Image: img, Duration: job.sprite.Frames[index].Duration})
+		}
+
+		data, err := apng.Encode(frames, animation.Loops)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", animation.Filename, err)
+		}
+		if err := os.WriteFile(animation.Filename, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includeLayer returns filter of layers rendered to animation: selected layers (only split one if set)
+// matching selected layer name of params
+func (j *Job) includeLayer(split string) func(*asefile.Layer) bool {
+	return layerFilter(j.selected, j.Params.SelectedLayer, split)
+}
+
+func layerFilter(selected []*asefile.Layer, selectedLayer, split string) func(*asefile.Layer) bool {
+	return func(layer *asefile.Layer) bool {
+		if !slices.Contains(selected, layer) {
+			return false
+		}
+		if selectedLayer != "" && !matchesLayer([]string{selectedLayer}, layer) {
+			return false
+		}
+		return split == "" || splitLayerName(layer) == split
+	}
+}
+
+func resizeNearest(img *image.NRGBA, size image.Point) *image.NRGBA {
+	if img.Rect.Size() == size {
+		return img
+	}
+
+	result := image.NewNRGBA(image.Rectangle{Max: size})
+	src := img.Rect.Size()
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			result.SetNRGBA(x, y, img.NRGBAAt(img.Rect.Min.X+x*src.X/size.X, img.Rect.Min.Y+y*src.Y/size.Y))
+		}
+	}
+	return result
+}
+
+// animationsHash describes animations of job for build cache, they are not made by export script
+func (j *Job) animationsHash() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:%v:%v:%v:%q", FormatAPNG, j.Params.IncludeLayers, j.Params.ExcludeLayers, j.Params.IncludeHidden, j.Params.SelectedLayer)
+	for _, animation := range j.Animations {
+		fmt.Fprintf(&b, "|%+v", animation)
+	}
+	return b.String()
+}
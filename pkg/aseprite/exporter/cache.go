@@ -0,0 +1,199 @@
+package exporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// DefaultCacheFilename is build cache file stored in working directory
+const DefaultCacheFilename = ".aseprite-assets-cache.json"
+
+const cacheVersion = 1
+
+// Cache keeps hashes of exported outputs, export is skipped when sprite, export options and outputs did not change.
+// Paths are stored relative to cache file directory, so cache can be committed and checked on CI
+type Cache struct {
+	Version int                   `json:"version"`
+	Outputs map[string]CacheEntry `json:"outputs"`
+
+	path string
+	mu   sync.Mutex
+}
+
+type CacheEntry struct {
+	Source      string `json:"source"`
+	SourceHash  string `json:"source_hash"`
+	OptionsHash string `json:"options_hash"`
+	OutputHash  string `json:"output_hash"`
+}
+
+// LoadCache reads cache file, missing file gives empty cache
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{Version: cacheVersion, Outputs: map[string]CacheEntry{}, path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("invalid build cache %s: %w", path, err)
+	}
+
+	// outputs of other cache versions are exported again
+	if cache.Version != cacheVersion || cache.Outputs == nil {
+		cache.Version = cacheVersion
+		cache.Outputs = map[string]CacheEntry{}
+	}
+
+	return cache, nil
+}
+
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := files.EnsureDirExists(c.path); err != nil {
+		return err
+	}
+
+	// temporary file keeps cache valid if write is interrupted
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write build cache: %w", err)
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// stale returns job outputs which sprite, options or content differ from cached ones
+func (c *Cache) stale(job *Job, optionsHash string) ([]string, error) {
+	sourceHash, err := files.HashFile(job.Params.SpriteFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stale []string
+	for _, output := range job.Outputs {
+		entry, ok := c.Outputs[c.key(output)]
+		if !ok || entry.SourceHash != sourceHash || entry.OptionsHash != optionsHash {
+			stale = append(stale, output)
+			continue
+		}
+
+		if outputHash, err := files.HashFile(output); err != nil || outputHash != entry.OutputHash {
+			stale = append(stale, output)
+		}
+	}
+
+	return stale, nil
+}
+
+// update stores hashes of written job outputs
+func (c *Cache) update(job *Job, optionsHash string) error {
+	sourceHash, err := files.HashFile(job.Params.SpriteFilename)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, output := range job.Outputs {
+		outputHash, err := files.HashFile(output)
+		if err != nil {
+			// output is not written by aseprite, it stays stale
+			delete(c.Outputs, c.key(output))
+			continue
+		}
+
+		c.Outputs[c.key(output)] = CacheEntry{
+			Source:      c.key(job.Params.SpriteFilename),
+			SourceHash:  sourceHash,
+			OptionsHash: optionsHash,
+			OutputHash:  outputHash,
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache) key(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(c.path))
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+
+	if rel, err := filepath.Rel(base, abs); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(abs)
+}
+
+// optionsHash identifies export options and export script version of job
+func (e *Exporter) optionsHash(job *Job) string {
+	h := sha256.New()
+	if job.Command == nil {
+		h.Write([]byte(job.animationsHash()))
+		h.Write([]byte{0})
+	} else {
+		for _, arg := range job.Command.Args() {
+			h.Write([]byte(arg))
+			h.Write([]byte{0})
+		}
+	}
+
+	if !job.Upscaler.IsNearest() {
+		h.Write([]byte(job.Upscaler))
+		h.Write([]byte{0})
+	}
+
+	for _, op := range job.Post.Specs() {
+		h.Write([]byte(op))
+		h.Write([]byte{0})
+	}
+
+	if job.Params.Optimize {
+		h.Write([]byte("optimize"))
+		h.Write([]byte{0})
+	}
+
+	if len(job.Palettes) > 0 {
+		h.Write([]byte("palette-lut:" + job.paletteLUTHash()))
+		h.Write([]byte{0})
+	}
+
+	if job.Command == nil {
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	if script, err := os.ReadFile(filepath.Join(e.aseCli.ScriptsDirPath, job.Command.ScriptName())); err == nil {
+		h.Write(script)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/commands"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cacheFixture is sprite with exported output recorded in cache of the same directory
+type cacheFixture struct {
+	dir      string
+	script   string
+	exporter *Exporter
+	cache    *Cache
+	job      *Job
+}
+
+func newCacheFixture(t *testing.T) *cacheFixture {
+	dir := t.TempDir()
+	f := &cacheFixture{dir: dir, script: filepath.Join(dir, "scripts", "export-sprite.lua")}
+
+	writeTestFile(t, f.script, "-- export v1")
+	sprite := writeTestFile(t, filepath.Join(dir, "sprites", "hero.aseprite"), "sprite v1")
+	output := writeTestFile(t, filepath.Join(dir, "out", "hero.png"), "png v1")
+
+	cache, err := LoadCache(filepath.Join(dir, DefaultCacheFilename))
+	require.NoError(t, err)
+	assert.Empty(t, cache.Outputs, "missing cache file gives empty cache")
+
+	f.cache = cache
+	f.exporter = NewExporter(aseprite.NewCLI("aseprite", filepath.Dir(f.script), false)).WithCache(cache, false)
+	f.job = &Job{
+		Params:  Params{SpriteFilename: sprite, OutputFilename: output},
+		Outputs: []string{output},
+		Command: &commands.ExportSprite{SpriteFilename: sprite, OutputFilename: output, Format: "png"},
+	}
+	return f
+}
+
+func writeTestFile(t *testing.T, filename, content string) string {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+// record stores job outputs in cache as written by export
+func (f *cacheFixture) record(t *testing.T) {
+	require.NoError(t, f.cache.update(f.job, f.exporter.optionsHash(f.job)))
+}
+
+func (f *cacheFixture) stale(t *testing.T) []string {
+	stale, err := f.exporter.Stale(f.job)
+	require.NoError(t, err)
+	return stale
+}
+
+func TestCacheStale(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, f *cacheFixture)
+	}{
+		{"sprite content", func(t *testing.T, f *cacheFixture) {
+			writeTestFile(t, f.job.Params.SpriteFilename, "sprite v2")
+		}},
+		{"export options", func(t *testing.T, f *cacheFixture) {
+			f.job.Command.Scales = "2"
+		}},
+		{"post-processing", func(t *testing.T, f *cacheFixture) {
+			f.job.Params.Optimize = true
+		}},
+		{"output content", func(t *testing.T, f *cacheFixture) {
+			writeTestFile(t, f.job.Outputs[0], "png edited")
+		}},
+		{"deleted output", func(t *testing.T, f *cacheFixture) {
+			require.NoError(t, os.Remove(f.job.Outputs[0]))
+		}},
+		{"export script", func(t *testing.T, f *cacheFixture) {
+			writeTestFile(t, f.script, "-- export v2")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCacheFixture(t)
+			assert.Equal(t, f.job.Outputs, f.stale(t), "outputs missing in cache are stale")
+
+			f.record(t)
+			assert.Empty(t, f.stale(t))
+
+			tt.change(t, f)
+			assert.Equal(t, f.job.Outputs, f.stale(t))
+		})
+	}
+}
+
+func TestCacheUpdateMissingOutput(t *testing.T) {
+	f := newCacheFixture(t)
+	f.record(t)
+	require.Len(t, f.cache.Outputs, 1)
+
+	// output not written by export is removed from cache and stays stale
+	require.NoError(t, os.Remove(f.job.Outputs[0]))
+	f.record(t)
+	assert.Empty(t, f.cache.Outputs)
+	assert.Equal(t, f.job.Outputs, f.stale(t))
+}
+
+func TestCacheSaveLoad(t *testing.T) {
+	f := newCacheFixture(t)
+	f.record(t)
+	require.NoError(t, f.cache.Save())
+
+	_, err := os.Stat(f.cache.path + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist, "temporary file is renamed to cache file")
+
+	loaded, err := LoadCache(f.cache.path)
+	require.NoError(t, err)
+	assert.Equal(t, f.cache.Outputs, loaded.Outputs)
+
+	// paths are relative to cache file directory
+	entry, ok := loaded.Outputs["out/hero.png"]
+	require.True(t, ok)
+	assert.Equal(t, "sprites/hero.aseprite", entry.Source)
+
+	f.exporter.WithCache(loaded, false)
+	assert.Empty(t, f.stale(t))
+
+	writeTestFile(t, f.cache.path, `{"version": 0, "outputs": {"out/hero.png": {}}}`)
+	loaded, err = LoadCache(f.cache.path)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Outputs, "outputs of other cache versions are exported again")
+
+	writeTestFile(t, f.cache.path, "{")
+	_, err = LoadCache(f.cache.path)
+	assert.ErrorContains(t, err, "invalid build cache")
+}
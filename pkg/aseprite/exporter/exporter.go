@@ -0,0 +1,566 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/commands"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/optimize"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/upscale"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// ScriptErrorMarker starts export script output when export failed
+const ScriptErrorMarker = "Error during export:"
+
+var (
+	ErrScriptFailed = errors.New("export script failed")
+)
+
+type Exporter struct {
+	aseCli *aseprite.Cli
+	cache  *Cache
+	force  bool
+}
+
+func NewExporter(aseCli *aseprite.Cli) *Exporter {
+	return &Exporter{aseCli: aseCli}
+}
+
+// WithCache makes exporter skip jobs with up to date outputs (unless force is set) and record written outputs
+func (e *Exporter) WithCache(cache *Cache, force bool) *Exporter {
+	e.cache = cache
+	e.force = force
+	return e
+}
+
+// Params describes export of one sprite
+type Params struct {
+	SpriteFilename string
+	OutputFilename string
+	OutputTemplate string
+	// OutputDir replaces sprite directory in default output path and {dir} placeholder
+	OutputDir      string
+	Format         string
+	FramesIncluded string
+	SelectedLayer  string
+	IncludeLayers  []string
+	ExcludeLayers  []string
+	IncludeHidden  bool
+	Tags           []string
+	AllTags        bool
+	Scales         string
+	Sizes          string
+	SplitLayers    bool
+	SplitFrames    bool
+	// Upscaler replaces aseprite nearest neighbour scaling of scaled outputs (see upscale.Upscalers)
+	Upscaler string
+	// Post are post-processing operations applied to every output after export (see postprocess.Parse)
+	Post []string
+	// Optimize losslessly recompresses png outputs as last step of export
+	Optimize bool
+	// LoopCount is number of plays of animated png outputs, 0 loops forever, LoopCountTag uses tag repeat count
+	LoopCount int
+	// PaletteLUT are palette files of index textures export, the first one is base palette of sprite colors
+	// and every palette is row of written palette lookup texture
+	PaletteLUT []string
+}
+
+// Job is validated export ready to be run by aseprite
+type Job struct {
+	Params   Params
+	Tags     []string
+	Template OutputTemplate
+	// Outputs lists files written by export, Duplicates ones written several times
+	Outputs    []string
+	Duplicates []string
+	Command    *commands.ExportSprite
+	Upscaler   upscale.Upscaler
+	// Upscales maps scaled outputs to their scales when upscaler is not nearest neighbour
+	Upscales map[string]int
+	Post     postprocess.Pipeline
+	// Animations are animated png outputs assembled without aseprite, Command is nil for them
+	Animations []Animation
+	// Palettes are rows of palette lookup texture LUT written with index textures outputs
+	Palettes []*palette.Palette
+	LUT      string
+
+	sprite   *asefile.Sprite
+	selected []*asefile.Layer
+}
+
+type Result struct {
+	Job    *Job
+	Output string
+	Err    error
+	// Skipped is set when job outputs are up to date
+	Skipped bool
+	// Optimized are sizes of png outputs optimized by export
+	Optimized []optimize.Result
+}
+
+// Plan validates params against sprite and expands all outputs of export
+func Plan(params Params) (*Job, error) {
+	if params.Scales != "" && params.Sizes != "" {
+		return nil, fmt.Errorf("cannot specify both scales and sizes, choose one")
+	}
+
+	if params.SpriteFilename == "" ||
+		!files.CheckFileExists(params.SpriteFilename, false) ||
+		!files.CheckFileExtension(params.SpriteFilename, aseprite.SpritesExtensions()...) {
+		return nil, fmt.Errorf("invalid sprite filename: %q", params.SpriteFilename)
+	}
+
+	if params.FramesIncluded != "" {
+		if err := ValidateFramesInput(params.FramesIncluded); err != nil {
+			return nil, fmt.Errorf("invalid frames input: %w", err)
+		}
+	}
+
+	if params.Scales != "" {
+		if err := ValidateScalesInput(params.Scales); err != nil {
+			return nil, fmt.Errorf("invalid scales input: %w", err)
+		}
+	}
+
+	if params.Sizes != "" {
+		// predicted outputs must match sizes rendered by export script
+		sizes, err := NormalizeSizesInput(params.Sizes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sizes input: %w", err)
+		}
+		params.Sizes = sizes
+	}
+
+	upscaler, err := upscale.Parse(params.Upscaler)
+	if err != nil {
+		return nil, err
+	}
+	if !upscaler.IsNearest() {
+		if params.Sizes != "" {
+			return nil, fmt.Errorf("%s upscaler works with scales, sizes are exported with nearest neighbour only", upscaler)
+		}
+		for _, scale := range splitList(params.Scales) {
+			// scales are validated as numbers above
+			n, _ := strconv.Atoi(scale)
+			if err := upscaler.ValidateScale(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	post, err := postprocess.Parse(params.Post)
+	if err != nil {
+		return nil, err
+	}
+
+	sprite, err := asefile.ReadFile(params.SpriteFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sprite: %w", err)
+	}
+
+	var tags []string
+	if params.UsesTags() {
+		if tags, err = resolveTags(sprite, params); err != nil {
+			return nil, err
+		}
+	}
+
+	selection := params.layerSelection()
+	if err := selection.validate(); err != nil {
+		return nil, err
+	}
+
+	selected := selection.selectLayers(sprite)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no layers of sprite %s match layers selection, available layers: %s",
+			params.SpriteFilename, strings.Join(LayersPaths(sprite), ", "))
+	}
+
+	template, err := params.outputTemplate(len(tags))
+	if err != nil {
+		return nil, err
+	}
+	template = template.Expand(spriteValues(params.SpriteFilename, params.OutputDir))
+	if len(post) > 0 && !postprocess.SupportsFormat(template.Format()) {
+		return nil, fmt.Errorf("post-processing supports only png outputs, got %s", template.Format())
+	}
+	animated := IsAnimatedFormat(template.Format())
+	if !upscaler.IsNearest() && !postprocess.SupportsFormat(template.Format()) && !animated {
+		return nil, fmt.Errorf("%s upscaler supports only png outputs, got %s", upscaler, template.Format())
+	}
+
+	layers := []string{params.SelectedLayer}
+	if params.SplitLayers {
+		layers = splitLayersNames(selected)
+	}
+
+	export := templateExport{
+		Layers: layers,
+		Tags:   tags,
+		Frames: params.FramesIncluded,
+		Scales: splitList(params.Scales),
+		Sizes:  splitList(params.Sizes),
+	}
+	outputs := template.outputs(sprite, export)
+
+	if animated {
+		if len(params.PaletteLUT) > 0 {
+			return nil, fmt.Errorf("%s output is animated, palette LUT export writes png index textures", template.Format())
+		}
+		if params.SplitFrames || template.HasPlaceholder(PlaceholderFrame) {
+			return nil, fmt.Errorf("%s output is animated, frames cannot be split", template.Format())
+		}
+
+		job := &Job{
+			Params:     params,
+			Tags:       tags,
+			Template:   template,
+			Outputs:    outputs,
+			Duplicates: duplicateOutputs(outputs),
+			Upscaler:   upscaler,
+			Post:       post,
+			sprite:     sprite,
+			selected:   selected,
+		}
+		for _, output := range template.expandOutputs(sprite, export) {
+			job.Animations = append(job.Animations, newAnimation(sprite, params, output))
+		}
+		return job, nil
+	}
+
+	var (
+		palettes []*palette.Palette
+		lut      string
+	)
+	if len(params.PaletteLUT) > 0 {
+		if palettes, lut, err = planPaletteLUT(sprite, selected, params, template, export); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, lut)
+	}
+
+	var upscales map[string]int
+	if !upscaler.IsNearest() {
+		upscales = make(map[string]int)
+		for _, scale := range export.Scales {
+			n, _ := strconv.Atoi(scale)
+			if n == 1 {
+				continue
+			}
+			export.Scales = []string{scale}
+			for _, output := range template.outputs(sprite, export) {
+				upscales[output] = n
+			}
+		}
+	}
+
+	command := &commands.ExportSprite{
+		SpriteFilename:    params.SpriteFilename,
+		OutputFilename:    string(template),
+		Format:            template.Format(),
+		FramesIncluded:    params.FramesIncluded,
+		SelectedLayerName: params.SelectedLayer,
+		VisibleLayers:     joinIndices(visibleLayersIndices(selected)),
+		Tags:              strings.Join(tags, ","),
+		Scales:            params.Scales,
+		Sizes:             params.Sizes,
+		SplitLayers:       params.SplitLayers,
+		SplitFrames:       params.SplitFrames,
+	}
+
+	return &Job{
+		Params:     params,
+		Tags:       tags,
+		Template:   template,
+		Outputs:    outputs,
+		Duplicates: duplicateOutputs(outputs),
+		Command:    command,
+		Upscaler:   upscaler,
+		Upscales:   upscales,
+		Post:       post,
+		Palettes:   palettes,
+		LUT:        lut,
+	}, nil
+}
+
+// DuplicateOutputs returns outputs written by several jobs (e.g. by formats of one sprite sharing extension and scale),
+// outputs written several times by one job are reported in its Duplicates
+func DuplicateOutputs(jobs []*Job) []string {
+	seen := make(map[string]int)
+	var duplicates []string
+	for _, job := range jobs {
+		jobOutputs := make(map[string]bool, len(job.Outputs))
+		for _, output := range job.Outputs {
+			if jobOutputs[output] {
+				continue
+			}
+			jobOutputs[output] = true
+
+			seen[output]++
+			if seen[output] == 2 {
+				duplicates = append(duplicates, output)
+			}
+		}
+	}
+	return duplicates
+}
+
+// Stale returns job outputs that need export, all outputs are stale without cache
+func (e *Exporter) Stale(job *Job) ([]string, error) {
+	if e.cache == nil {
+		return job.Outputs, nil
+	}
+	return e.cache.stale(job, e.optionsHash(job))
+}
+
+// Run creates output directories and runs export script
+func (e *Exporter) Run(job *Job) Result {
+	if e.cache != nil && !e.force {
+		if stale, err := e.Stale(job); err == nil && len(stale) == 0 {
+			return Result{Job: job, Skipped: true}
+		}
+	}
+
+	for _, output := range job.Outputs {
+		if err := files.EnsureDirExists(output); err != nil {
+			return Result{Job: job, Err: fmt.Errorf("failed to create sprite output directory: %w", err)}
+		}
+	}
+
+	var output string
+	if job.Command == nil {
+		if err := writeAnimations(job); err != nil {
+			return Result{Job: job, Err: err}
+		}
+	} else {
+		var err error
+		output, err = e.aseCli.ExecuteCommandOutput(job.Command)
+		if err != nil {
+			return Result{Job: job, Output: output, Err: fmt.Errorf("failed to export sprite: %w", err)}
+		}
+
+		// export script reports its errors to output, aseprite itself exits successfully
+		if _, scriptErr, found := strings.Cut(output, ScriptErrorMarker); found {
+			return Result{Job: job, Output: output, Err: fmt.Errorf("%w: %s", ErrScriptFailed, strings.TrimSpace(scriptErr))}
+		}
+	}
+
+	if err := rescale(job); err != nil {
+		return Result{Job: job, Output: output, Err: err}
+	}
+
+	if err := postProcess(job); err != nil {
+		return Result{Job: job, Output: output, Err: err}
+	}
+
+	if err := writePaletteLUT(job); err != nil {
+		return Result{Job: job, Output: output, Err: err}
+	}
+
+	optimized, err := optimizeOutputs(job)
+	if err != nil {
+		return Result{Job: job, Output: output, Err: err}
+	}
+
+	if e.cache != nil {
+		if err := e.cache.update(job, e.optionsHash(job)); err != nil {
+			return Result{Job: job, Output: output, Err: fmt.Errorf("failed to update build cache: %w", err)}
+		}
+	}
+
+	return Result{Job: job, Output: output, Optimized: optimized}
+}
+
+// rescale replaces aseprite nearest neighbour scaling of written outputs by job upscaler,
+// it runs before post-processing, so operations see final size of outputs
+func rescale(job *Job) error {
+	for _, filename := range slices.Sorted(maps.Keys(job.Upscales)) {
+		if !files.CheckFileExists(filename, false) {
+			continue
+		}
+		if err := job.Upscaler.RescaleFile(filename, job.Upscales[filename]); err != nil {
+			return fmt.Errorf("failed to upscale output: %w", err)
+		}
+	}
+	return nil
+}
+
+// postProcess applies job post-processing to written outputs before they are recorded in cache,
+// so cache keeps hashes of final files
+func postProcess(job *Job) error {
+	if len(job.Post) == 0 {
+		return nil
+	}
+
+	for _, filename := range slices.Compact(slices.Sorted(slices.Values(job.Outputs))) {
+		if !files.CheckFileExists(filename, false) {
+			// output is not written by aseprite, cache keeps it stale
+			continue
+		}
+		if err := job.Post.ApplyFile(filename); err != nil {
+			return fmt.Errorf("failed to post-process output: %w", err)
+		}
+	}
+	return nil
+}
+
+// optimizeOutputs recompresses written png outputs when job asks for it, other formats are left as is
+func optimizeOutputs(job *Job) ([]optimize.Result, error) {
+	if !job.Params.Optimize {
+		return nil, nil
+	}
+
+	var results []optimize.Result
+	for _, filename := range slices.Compact(slices.Sorted(slices.Values(job.Outputs))) {
+		if !optimize.IsPNG(filename) || !files.CheckFileExists(filename, false) {
+			continue
+		}
+		result, err := optimize.File(filename, false)
+		if errors.Is(err, optimize.ErrAnimated) || errors.Is(err, optimize.ErrLossy) {
+			continue
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// RunAll runs jobs on pool of workers aseprite processes, done is called for every finished job one at a time
+func (e *Exporter) RunAll(jobs []*Job, workers int, done func(Result)) {
+	workers = max(1, min(workers, len(jobs)))
+
+	queue := make(chan *Job)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				result := e.Run(job)
+
+				mu.Lock()
+				done(result)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+
+	wg.Wait()
+}
+
+func (p Params) UsesTags() bool {
+	return p.AllTags || len(p.Tags) > 0
+}
+
+func (p Params) layerSelection() layerSelection {
+	selection := layerSelection{
+		Include:       slices.Clone(p.IncludeLayers),
+		Exclude:       p.ExcludeLayers,
+		IncludeHidden: p.IncludeHidden,
+	}
+
+	if p.SelectedLayer != "" {
+		selection.Include = append(selection.Include, p.SelectedLayer)
+	}
+
+	return selection
+}
+
+// outputTemplate returns template given in OutputTemplate or built from output filename (format)
+func (p Params) outputTemplate(tagsCount int) (OutputTemplate, error) {
+	if p.OutputTemplate != "" {
+		template, err := ParseOutputTemplate(p.OutputTemplate)
+		if err != nil {
+			return "", err
+		}
+
+		if p.SplitLayers && !template.HasPlaceholder(PlaceholderLayer) {
+			return "", errors.New("output template must contain {layer} placeholder to split layers")
+		}
+		if p.SplitFrames && !template.HasPlaceholder(PlaceholderFrame) {
+			return "", errors.New("output template must contain {frame} placeholder to split frames")
+		}
+
+		return template, nil
+	}
+
+	outputPath := p.OutputFilename
+	if outputPath == "" {
+		if p.Format == "" || !slices.Contains(aseprite.AvailableExportExtensions(), files.PrefExtension(p.Format)) {
+			return "", errors.New("format required when output filename is not specified")
+		}
+
+		outputPath = files.ChangeFilenameExtension(p.SpriteFilename, p.Format)
+		if p.OutputDir != "" {
+			outputPath = filepath.Join(p.OutputDir, filepath.Base(outputPath))
+		}
+	}
+
+	return defaultOutputTemplate(outputPath, defaultTemplateOptions{
+		Tags:        tagsCount > 1,
+		SplitLayers: p.SplitLayers,
+		SplitFrames: p.SplitFrames,
+		Scaled:      p.Scales != "",
+		Sized:       p.Sizes != "",
+	}), nil
+}
+
+// resolveTags checks requested tags against sprite tags and returns names of tags to export
+func resolveTags(sprite *asefile.Sprite, params Params) ([]string, error) {
+	available := TagsNames(sprite)
+	if len(available) == 0 {
+		return nil, fmt.Errorf("sprite %s has no tags", params.SpriteFilename)
+	}
+
+	requested := params.Tags
+	if params.AllTags {
+		requested = available
+	}
+
+	var tags []string
+	for _, name := range requested {
+		name = strings.TrimSpace(name)
+		if sprite.TagByName(name) == nil {
+			return nil, fmt.Errorf("tag %q not found, available tags: %s", name, strings.Join(available, ", "))
+		}
+		// tags are passed to export script as comma separated list
+		if strings.Contains(name, ",") {
+			return nil, fmt.Errorf("tag %q contains comma and cannot be exported by name", name)
+		}
+		if !slices.Contains(tags, name) {
+			tags = append(tags, name)
+		}
+	}
+
+	return tags, nil
+}
+
+func TagsNames(sprite *asefile.Sprite) []string {
+	var names []string
+	for _, tag := range sprite.Tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
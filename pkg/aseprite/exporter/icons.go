@@ -0,0 +1,198 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/icons"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// IconParams describes export of sprite frame to platform icon sets, frame is rendered natively (without aseprite)
+type IconParams struct {
+	SpriteFilename string
+	// OutputDir is directory of icon sets, <sprite>_icons next to sprite if empty
+	OutputDir string
+	Frame     int
+	// Targets are written icon sets, every target if empty
+	Targets []icons.Target
+	// Sizes are ico target sizes, icons.DefaultICOSizes if empty
+	Sizes []image.Point
+	// Trim removes transparent margins of frame before scaling
+	Trim bool
+	// Background fills padding and translucent pixels, iOS icons are flattened onto opaque
+	// background (white when it is transparent)
+	Background    color.NRGBA
+	IncludeLayers []string
+	ExcludeLayers []string
+	IncludeHidden bool
+}
+
+// IconsResult lists written icon files and icon sizes smaller than sprite image, which are not pixel perfect
+type IconsResult struct {
+	Written    []string
+	Downscaled []int
+}
+
+// ExportIcons renders sprite frame and writes it scaled by integer factor (padded to square) to icon sets of targets
+func ExportIcons(params IconParams) (IconsResult, error) {
+	var result IconsResult
+	if params.SpriteFilename == "" ||
+		!files.CheckFileExists(params.SpriteFilename, false) ||
+		!files.CheckFileExtension(params.SpriteFilename, aseprite.SpritesExtensions()...) {
+		return result, fmt.Errorf("invalid sprite filename: %q", params.SpriteFilename)
+	}
+
+	sprite, err := asefile.ReadFile(params.SpriteFilename)
+	if err != nil {
+		return result, fmt.Errorf("failed to read sprite: %w", err)
+	}
+	if params.Frame < 0 || params.Frame >= len(sprite.Frames) {
+		return result, fmt.Errorf("frame %d is out of sprite frames range 0-%d", params.Frame, len(sprite.Frames)-1)
+	}
+
+	selected, err := SelectLayers(sprite, params.IncludeLayers, params.ExcludeLayers, params.IncludeHidden)
+	if err != nil {
+		return result, err
+	}
+	img := sprite.FrameImage(params.Frame, func(l *asefile.Layer) bool { return slices.Contains(selected, l) })
+	if params.Trim {
+		img = postprocess.Trim(img)
+	}
+	if img.Rect.Empty() {
+		return result, errors.New("sprite frame is empty")
+	}
+
+	targets := params.Targets
+	if len(targets) == 0 {
+		targets, _ = icons.ParseTargets(nil)
+	}
+	sizes := params.Sizes
+	if len(sizes) == 0 {
+		sizes = icons.DefaultICOSizes
+	}
+
+	title := strings.TrimSuffix(filepath.Base(params.SpriteFilename), filepath.Ext(params.SpriteFilename))
+	outputDir := params.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Join(filepath.Dir(params.SpriteFilename), title+"_icons")
+	}
+
+	w := &iconsWriter{image: img, background: params.Background, result: &result}
+	for _, target := range targets {
+		dir := filepath.Join(outputDir, filepath.FromSlash(target.Dir()))
+
+		switch target {
+		case icons.ICO:
+			err = w.writeICO(filepath.Join(dir, title+".ico"), sizes)
+		case icons.Favicon:
+			err = w.writeFavicon(dir, title)
+		case icons.Android:
+			err = w.writeIcons(dir, icons.AndroidIcons(), w.background)
+		case icons.IOS:
+			err = w.writeIOS(dir)
+		}
+		if err != nil {
+			return result, fmt.Errorf("%s icons: %w", target, err)
+		}
+	}
+
+	slices.Sort(result.Downscaled)
+	return result, nil
+}
+
+// iconsWriter writes icons of sprite image and collects written files
+type iconsWriter struct {
+	image      *image.NRGBA
+	background color.NRGBA
+	result     *IconsResult
+}
+
+func (w *iconsWriter) fit(size image.Point, background color.NRGBA) *image.NRGBA {
+	img, exact := icons.Fit(w.image, size, background)
+	if !exact && !slices.Contains(w.result.Downscaled, size.X) {
+		w.result.Downscaled = append(w.result.Downscaled, size.X)
+	}
+	return img
+}
+
+func (w *iconsWriter) writeFile(filename string, data []byte) error {
+	if err := files.EnsureDirExists(filename); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return err
+	}
+	w.result.Written = append(w.result.Written, filename)
+	return nil
+}
+
+func (w *iconsWriter) writeICO(filename string, sizes []image.Point) error {
+	var images []*image.NRGBA
+	for _, size := range sizes {
+		images = append(images, w.fit(size, w.background))
+	}
+
+	data, err := icons.EncodeICO(images)
+	if err != nil {
+		return err
+	}
+	return w.writeFile(filename, data)
+}
+
+func (w *iconsWriter) writeIcons(dir string, set []icons.Icon, background color.NRGBA) error {
+	for _, icon := range set {
+		filename := filepath.Join(dir, filepath.FromSlash(icon.File))
+		if err := writePNG(filename, w.fit(image.Pt(icon.Size, icon.Size), background)); err != nil {
+			return err
+		}
+		w.result.Written = append(w.result.Written, filename)
+	}
+	return nil
+}
+
+func (w *iconsWriter) writeFavicon(dir, name string) error {
+	if err := w.writeICO(filepath.Join(dir, "favicon.ico"), icons.FaviconICOSizes); err != nil {
+		return err
+	}
+	if err := w.writeIcons(dir, icons.FaviconIcons(), w.background); err != nil {
+		return err
+	}
+
+	manifest, err := icons.WebManifest(name)
+	if err != nil {
+		return err
+	}
+	if err := w.writeFile(filepath.Join(dir, "site.webmanifest"), manifest); err != nil {
+		return err
+	}
+	return w.writeFile(filepath.Join(dir, "head.html"), []byte(icons.FaviconLinks()))
+}
+
+// writeIOS writes AppIcon.appiconset, app store rejects icons with alpha channel
+func (w *iconsWriter) writeIOS(dir string) error {
+	background := w.background
+	if background.A == 0 {
+		background = color.NRGBA{R: 255, G: 255, B: 255}
+	}
+	background.A = 255
+
+	if err := w.writeIcons(dir, icons.IOSIcons(), background); err != nil {
+		return err
+	}
+
+	contents, err := icons.IOSContents()
+	if err != nil {
+		return err
+	}
+	return w.writeFile(filepath.Join(dir, "Contents.json"), contents)
+}
@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+// layerSelection chooses sprite layers rendered to export.
+// Patterns are globs matched against layer path ("body/arm") or name ("arm"),
+// pattern matching group selects all layers of the group
+type layerSelection struct {
+	Include       []string
+	Exclude       []string
+	IncludeHidden bool
+}
+
+func (s layerSelection) validate() error {
+	for _, pattern := range slices.Concat(s.Include, s.Exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid layer pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// selectLayers returns image and tilemap layers to export. Hidden layers are selected only with IncludeHidden,
+// reference layers only when include pattern matches them
+func (s layerSelection) selectLayers(sprite *asefile.Sprite) []*asefile.Layer {
+	var selected []*asefile.Layer
+	for _, layer := range sprite.Layers {
+		if layer.IsGroup() {
+			continue
+		}
+
+		if len(s.Include) > 0 && !matchesLayer(s.Include, layer) {
+			continue
+		}
+		if matchesLayer(s.Exclude, layer) {
+			continue
+		}
+		if layer.IsReference() && len(s.Include) == 0 {
+			continue
+		}
+		if !s.IncludeHidden && !layer.VisibleInTree() {
+			continue
+		}
+
+		selected = append(selected, layer)
+	}
+	return selected
+}
+
+// SelectLayers returns layers rendered by export with given include and exclude patterns (see Params)
+func SelectLayers(sprite *asefile.Sprite, include, exclude []string, includeHidden bool) ([]*asefile.Layer, error) {
+	selection := layerSelection{Include: include, Exclude: exclude, IncludeHidden: includeHidden}
+	if err := selection.validate(); err != nil {
+		return nil, err
+	}
+
+	selected := selection.selectLayers(sprite)
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no layers match layers selection, available layers: %s", strings.Join(LayersPaths(sprite), ", "))
+	}
+	return selected, nil
+}
+
+// matchesLayer reports whether any pattern matches layer or one of its parent groups
+func matchesLayer(patterns []string, layer *asefile.Layer) bool {
+	for cur := layer; cur != nil; cur = cur.Parent {
+		for _, pattern := range patterns {
+			pattern = strings.Trim(pattern, "/")
+			if ok, _ := path.Match(pattern, cur.Path()); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, cur.Name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// visibleLayersIndices returns indices of selected layers and their parent groups,
+// all other layers are hidden during export
+func visibleLayersIndices(selected []*asefile.Layer) []int {
+	var indices []int
+	for _, layer := range selected {
+		for cur := layer; cur != nil; cur = cur.Parent {
+			if !slices.Contains(indices, cur.Index) {
+				indices = append(indices, cur.Index)
+			}
+		}
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+// splitLayersNames returns names of selected layers, each of them is exported to its own file by --split-layers
+func splitLayersNames(selected []*asefile.Layer) []string {
+	var names []string
+	for _, layer := range selected {
+		if name := splitLayerName(layer); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// splitLayerName returns {layer} value of split layer: layer path joined with "-" (e.g. "body-arm"),
+// so layers of the same name in different groups are written to different files
+func splitLayerName(layer *asefile.Layer) string {
+	return strings.ReplaceAll(layer.Path(), "/", "-")
+}
+
+// LayersPaths returns paths of all sprite layers (e.g. "body", "body/arm")
+func LayersPaths(sprite *asefile.Sprite) []string {
+	var paths []string
+	for _, layer := range sprite.Layers {
+		paths = append(paths, layer.Path())
+	}
+	return paths
+}
+
+func joinIndices(indices []int) string {
+	var items []string
+	for _, index := range indices {
+		items = append(items, strconv.Itoa(index))
+	}
+	return strings.Join(items, ",")
+}
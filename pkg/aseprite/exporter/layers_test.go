@@ -0,0 +1,147 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// layersSprite returns sprite of layers tree (bottom to top):
+//
+//	background
+//	body/           group
+//	  torso
+//	  arms/         group
+//	    left
+//	    right       hidden
+//	sketch          reference
+//	fx/             hidden group
+//	  glow
+func layersSprite() *asefile.Sprite {
+	layers := []*asefile.Layer{
+		{Name: "background", Flags: asefile.LayerFlagVisible},
+		{Name: "body", Type: asefile.LayerTypeGroup, Flags: asefile.LayerFlagVisible},
+		{Name: "torso", ChildLevel: 1, Flags: asefile.LayerFlagVisible},
+		{Name: "arms", Type: asefile.LayerTypeGroup, ChildLevel: 1, Flags: asefile.LayerFlagVisible},
+		{Name: "left", ChildLevel: 2, Flags: asefile.LayerFlagVisible},
+		{Name: "right", ChildLevel: 2},
+		{Name: "sketch", Flags: asefile.LayerFlagVisible | asefile.LayerFlagReference},
+		{Name: "fx", Type: asefile.LayerTypeGroup},
+		{Name: "glow", ChildLevel: 1, Flags: asefile.LayerFlagVisible},
+	}
+
+	// groups[level] is the last group of child level
+	var groups []*asefile.Layer
+	for i, layer := range layers {
+		layer.Index = i
+		groups = groups[:layer.ChildLevel]
+		if layer.ChildLevel > 0 {
+			layer.Parent = groups[layer.ChildLevel-1]
+			layer.Parent.Children = append(layer.Parent.Children, layer)
+		}
+		if layer.IsGroup() {
+			groups = append(groups, layer)
+		}
+	}
+	return &asefile.Sprite{Layers: layers}
+}
+
+func layersNames(layers []*asefile.Layer) []string {
+	var names []string
+	for _, layer := range layers {
+		names = append(names, layer.Name)
+	}
+	return names
+}
+
+func TestSelectLayers(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection layerSelection
+		want      []string
+	}{
+		{"visible by default", layerSelection{}, []string{"background", "torso", "left"}},
+		{"hidden included", layerSelection{IncludeHidden: true}, []string{"background", "torso", "left", "right", "glow"}},
+		{"group path", layerSelection{Include: []string{"body"}}, []string{"torso", "left"}},
+		{"nested group path", layerSelection{Include: []string{"body/arms"}}, []string{"left"}},
+		{"group name", layerSelection{Include: []string{"arms"}}, []string{"left"}},
+		{"path glob", layerSelection{Include: []string{"body/arms/*"}, IncludeHidden: true}, []string{"left", "right"}},
+		{"name glob", layerSelection{Include: []string{"*o*"}}, []string{"background", "torso", "left"}},
+		{"slashes trimmed", layerSelection{Include: []string{"/body/torso/"}}, []string{"torso"}},
+		{"excluded group", layerSelection{Exclude: []string{"arms"}}, []string{"background", "torso"}},
+		{"excluded path glob", layerSelection{Exclude: []string{"body/*"}}, []string{"background"}},
+		{"exclude wins", layerSelection{Include: []string{"body"}, Exclude: []string{"torso"}}, []string{"left"}},
+		{"reference included by pattern", layerSelection{Include: []string{"sketch"}}, []string{"sketch"}},
+		{"layer of hidden group", layerSelection{Include: []string{"glow"}}, nil},
+		{"layer of hidden group included", layerSelection{Include: []string{"fx"}, IncludeHidden: true}, []string{"glow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.selection.validate())
+			assert.Equal(t, tt.want, layersNames(tt.selection.selectLayers(layersSprite())))
+		})
+	}
+}
+
+func TestSelectLayersErrors(t *testing.T) {
+	for _, selection := range []layerSelection{{Include: []string{"body/["}}, {Exclude: []string{"["}}} {
+		assert.ErrorContains(t, selection.validate(), "invalid layer pattern")
+	}
+
+	_, err := SelectLayers(layersSprite(), []string{"["}, nil, false)
+	assert.ErrorContains(t, err, "invalid layer pattern")
+
+	_, err = SelectLayers(layersSprite(), []string{"head"}, nil, false)
+	assert.ErrorContains(t, err, "available layers: background, body, body/torso, body/arms, body/arms/left")
+
+	selected, err := SelectLayers(layersSprite(), []string{"arms"}, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"left", "right"}, layersNames(selected))
+}
+
+func TestMatchesLayer(t *testing.T) {
+	sprite := layersSprite()
+	left := sprite.Layers[4]
+
+	tests := []struct {
+		patterns []string
+		want     bool
+	}{
+		{[]string{"left"}, true},
+		{[]string{"body/arms/left"}, true},
+		{[]string{"arms"}, true},
+		{[]string{"body"}, true},
+		{[]string{"b*"}, true},
+		{[]string{"*/left"}, false},
+		{[]string{"*/*/left"}, true},
+		{[]string{"right", "torso"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchesLayer(tt.patterns, left), "%v", tt.patterns)
+	}
+}
+
+func TestVisibleLayersIndices(t *testing.T) {
+	sprite := layersSprite()
+
+	// parent groups of selected layers stay visible once
+	assert.Equal(t, []int{1, 2, 3, 4}, visibleLayersIndices([]*asefile.Layer{sprite.Layers[4], sprite.Layers[2]}))
+	assert.Equal(t, []int{0, 6}, visibleLayersIndices([]*asefile.Layer{sprite.Layers[6], sprite.Layers[0]}))
+	assert.Empty(t, visibleLayersIndices(nil))
+}
+
+func TestSplitLayersNames(t *testing.T) {
+	sprite := layersSprite()
+	selected := []*asefile.Layer{sprite.Layers[0], sprite.Layers[2], sprite.Layers[4], sprite.Layers[4]}
+	assert.Equal(t, []string{"background", "body-torso", "body-arms-left"}, splitLayersNames(selected))
+
+	// layers of split layer only are rendered by animation and index textures exports
+	include := layerFilter(selected, "", "body-arms-left")
+	assert.True(t, include(sprite.Layers[4]))
+	assert.False(t, include(sprite.Layers[2]))
+	assert.False(t, include(sprite.Layers[5]), "not selected")
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// PaletteLUTSuffix ends name of palette lookup texture written next to index textures of sprite
+const PaletteLUTSuffix = "_lut.png"
+
+// unmatchedColorsLimit is number of missing colors listed in errors
+const unmatchedColorsLimit = 8
+
+// planPaletteLUT loads palettes of params and checks that exported frames of indexed sprite use only colors
+// of base (first) palette, returns loaded palettes and filename of lookup texture
+func planPaletteLUT(sprite *asefile.Sprite, selected []*asefile.Layer, params Params, template OutputTemplate, export templateExport) ([]*palette.Palette, string, error) {
+	if sprite.ColorDepth != asefile.ColorDepthIndexed {
+		return nil, "", fmt.Errorf("palette LUT export works on indexed sprites, %s is %s", params.SpriteFilename, sprite.ColorDepth)
+	}
+	if !postprocess.SupportsFormat(template.Format()) {
+		return nil, "", fmt.Errorf("palette LUT export writes png index textures, got %s", template.Format())
+	}
+
+	var palettes []*palette.Palette
+	for _, filename := range params.PaletteLUT {
+		p, err := palette.Load(filename)
+		if err != nil {
+			return nil, "", err
+		}
+		palettes = append(palettes, p)
+	}
+	if _, err := palette.LUT(palettes); err != nil {
+		return nil, "", err
+	}
+
+	base := palettes[0]
+	unmatched := make(map[color.NRGBA]int)
+	checked := make(map[string]bool)
+	for _, output := range template.expandOutputs(sprite, export) {
+		include := layerFilter(selected, params.SelectedLayer, output.Layer)
+		for frame := max(output.From, 0); frame <= min(output.To, len(sprite.Frames)-1); frame++ {
+			key := fmt.Sprintf("%s:%d", output.Layer, frame)
+			if checked[key] {
+				continue
+			}
+			checked[key] = true
+
+			_, missing := palette.IndexImage(sprite.FrameImage(frame, include), base)
+			for c, count := range missing {
+				unmatched[c] += count
+			}
+		}
+	}
+	if len(unmatched) > 0 {
+		return nil, "", fmt.Errorf("sprite %s uses %d colors missing in base palette %s: %s",
+			params.SpriteFilename, len(unmatched), base.Name, palette.FormatCounts(unmatched, unmatchedColorsLimit))
+	}
+
+	outputs := template.outputs(sprite, export)
+	name := strings.TrimSuffix(filepath.Base(params.SpriteFilename), filepath.Ext(params.SpriteFilename))
+	lut := filepath.Join(filepath.Dir(outputs[0]), name+PaletteLUTSuffix)
+	if slices.Contains(outputs, lut) {
+		return nil, "", fmt.Errorf("palette lookup texture %s overwrites exported output", lut)
+	}
+
+	return palettes, lut, nil
+}
+
+// writePaletteLUT replaces written outputs by index textures of base palette and writes palette lookup texture,
+// colors added after export (e.g. by upscaler or post-processing) missing in base palette fail export
+func writePaletteLUT(job *Job) error {
+	if len(job.Palettes) == 0 {
+		return nil
+	}
+
+	base := job.Palettes[0]
+	for _, filename := range slices.Compact(slices.Sorted(slices.Values(job.Outputs))) {
+		if filename == job.LUT || !files.CheckFileExists(filename, false) {
+			continue
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", filename, err)
+		}
+
+		index, unmatched := palette.IndexImage(img, base)
+		if len(unmatched) > 0 {
+			return fmt.Errorf("output %s has %d colors missing in base palette %s: %s",
+				filename, len(unmatched), base.Name, palette.FormatCounts(unmatched, unmatchedColorsLimit))
+		}
+		if err := writePNG(filename, index); err != nil {
+			return err
+		}
+	}
+
+	// palettes are validated by plan
+	lut, err := palette.LUT(job.Palettes)
+	if err != nil {
+		return err
+	}
+	return writePNG(job.LUT, lut)
+}
+
+// paletteLUTHash describes palettes of job for build cache
+func (j *Job) paletteLUTHash() string {
+	var b strings.Builder
+	for _, p := range j.Palettes {
+		b.WriteString(p.Name)
+		for _, c := range p.Colors {
+			b.WriteString(palette.Hex(c))
+		}
+		b.WriteByte('|')
+	}
+	return b.String()
+}
@@ -0,0 +1,273 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// SliceParams describes export of sprite slices to images, slices are rendered natively (without aseprite)
+type SliceParams struct {
+	SpriteFilename string
+	// OutputDir is directory of slices images and sidecar, sprite directory if empty
+	OutputDir string
+	// FramesIncluded is range of frames which slices are exported, first frame if empty
+	FramesIncluded string
+	// Names are exported slices, every slice if empty
+	Names []string
+	// NinePatch writes slices with center as Android nine-patch images (.9.png)
+	NinePatch     bool
+	IncludeLayers []string
+	ExcludeLayers []string
+	IncludeHidden bool
+}
+
+type slicesSidecar struct {
+	Sprite string      `json:"sprite"`
+	Size   sizeJSON    `json:"size"`
+	Slices []sliceJSON `json:"slices"`
+}
+
+type sliceJSON struct {
+	Name   string           `json:"name"`
+	Color  string           `json:"color,omitempty"`
+	Data   string           `json:"data,omitempty"`
+	Keys   []sliceKeyJSON   `json:"keys"`
+	Images []sliceImageJSON `json:"images"`
+}
+
+type sliceKeyJSON struct {
+	Frame  int        `json:"frame"`
+	Bounds rectJSON   `json:"bounds"`
+	Center *rectJSON  `json:"center,omitempty"`
+	Pivot  *pointJSON `json:"pivot,omitempty"`
+}
+
+type sliceImageJSON struct {
+	Frame int `json:"frame"`
+	// File is relative to sidecar directory
+	File      string `json:"file"`
+	NinePatch bool   `json:"nine_patch,omitempty"`
+}
+
+type rectJSON struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type pointJSON struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type sizeJSON struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// ExportSlices writes image of every slice key active on included frames and JSON sidecar
+// (<sprite>.slices.json) with slices bounds, centers and pivots per key. Returns written filenames
+func ExportSlices(params SliceParams) ([]string, error) {
+	if params.SpriteFilename == "" ||
+		!files.CheckFileExists(params.SpriteFilename, false) ||
+		!files.CheckFileExtension(params.SpriteFilename, aseprite.SpritesExtensions()...) {
+		return nil, fmt.Errorf("invalid sprite filename: %q", params.SpriteFilename)
+	}
+
+	if params.FramesIncluded != "" {
+		if err := ValidateFramesInput(params.FramesIncluded); err != nil {
+			return nil, fmt.Errorf("invalid frames input: %w", err)
+		}
+	}
+
+	sprite, err := asefile.ReadFile(params.SpriteFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sprite: %w", err)
+	}
+
+	spriteSlices, err := selectSlices(sprite, params.Names)
+	if err != nil {
+		return nil, fmt.Errorf("sprite %s: %w", params.SpriteFilename, err)
+	}
+
+	selected, err := SelectLayers(sprite, params.IncludeLayers, params.ExcludeLayers, params.IncludeHidden)
+	if err != nil {
+		return nil, err
+	}
+	include := func(l *asefile.Layer) bool { return slices.Contains(selected, l) }
+
+	from, to := 0, 0
+	if params.FramesIncluded != "" {
+		from, to = parseFramesRange(params.FramesIncluded, len(sprite.Frames))
+	}
+	to = min(to, len(sprite.Frames)-1)
+
+	outputDir := params.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(params.SpriteFilename)
+	}
+	title := strings.TrimSuffix(filepath.Base(params.SpriteFilename), filepath.Ext(params.SpriteFilename))
+
+	sidecar := slicesSidecar{
+		Sprite: filepath.Base(params.SpriteFilename),
+		Size:   sizeJSON{W: sprite.Width, H: sprite.Height},
+	}
+
+	var written []string
+	frameImages := make(map[int]*image.NRGBA)
+
+	for _, slice := range spriteSlices {
+		data := sliceJSON{Name: slice.Name, Data: slice.UserData.Text, Images: []sliceImageJSON{}}
+		if c := slice.UserData.Color; c != nil {
+			data.Color = fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+		}
+		for _, key := range slice.Keys {
+			data.Keys = append(data.Keys, sliceKeyData(key))
+		}
+
+		for frame := from; frame <= to; frame++ {
+			key := slice.KeyAt(frame)
+			if key == nil || key.Bounds.Empty() {
+				continue
+			}
+
+			if frameImages[frame] == nil {
+				frameImages[frame] = sprite.FrameImage(frame, include)
+			}
+
+			img := cropImage(frameImages[frame], key.Bounds)
+			ninePatch := params.NinePatch && key.Center != nil
+
+			name := title + "_" + slice.Name
+			if to > from {
+				name = fmt.Sprintf("%s_%d", name, frame)
+			}
+			extension := ".png"
+			if ninePatch {
+				img = ninePatchImage(img, *key.Center)
+				extension = ".9.png"
+			}
+
+			filename := filepath.Join(outputDir, name+extension)
+			if err := writePNG(filename, img); err != nil {
+				return written, err
+			}
+			written = append(written, filename)
+			data.Images = append(data.Images, sliceImageJSON{Frame: frame, File: name + extension, NinePatch: ninePatch})
+		}
+
+		sidecar.Slices = append(sidecar.Slices, data)
+	}
+
+	content, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return written, err
+	}
+
+	sidecarFilename := filepath.Join(outputDir, title+".slices.json")
+	if err := os.WriteFile(sidecarFilename, append(content, '\n'), 0644); err != nil {
+		return written, err
+	}
+
+	return append(written, sidecarFilename), nil
+}
+
+// SlicesNames returns names of sprite slices
+func SlicesNames(sprite *asefile.Sprite) []string {
+	var names []string
+	for _, slice := range sprite.Slices {
+		names = append(names, slice.Name)
+	}
+	return names
+}
+
+// selectSlices returns slices with given names in sprite order, all slices without names
+func selectSlices(sprite *asefile.Sprite, names []string) ([]*asefile.Slice, error) {
+	available := SlicesNames(sprite)
+	if len(available) == 0 {
+		return nil, fmt.Errorf("sprite has no slices")
+	}
+
+	for _, name := range names {
+		if !slices.Contains(available, name) {
+			return nil, fmt.Errorf("slice %q not found, available slices: %s", name, strings.Join(available, ", "))
+		}
+	}
+
+	var selected []*asefile.Slice
+	for _, slice := range sprite.Slices {
+		if len(names) == 0 || slices.Contains(names, slice.Name) {
+			selected = append(selected, slice)
+		}
+	}
+	return selected, nil
+}
+
+func sliceKeyData(key asefile.SliceKey) sliceKeyJSON {
+	data := sliceKeyJSON{Frame: key.Frame, Bounds: toRectJSON(key.Bounds)}
+	if key.Center != nil {
+		center := toRectJSON(*key.Center)
+		data.Center = &center
+	}
+	if key.Pivot != nil {
+		data.Pivot = &pointJSON{X: key.Pivot.X, Y: key.Pivot.Y}
+	}
+	return data
+}
+
+// cropImage copies area of image (transparent outside of it) to new image starting at origin
+func cropImage(img *image.NRGBA, area image.Rectangle) *image.NRGBA {
+	cropped := image.NewNRGBA(image.Rectangle{Max: area.Size()})
+	draw.Draw(cropped, cropped.Rect, img, area.Min, draw.Src)
+	return cropped
+}
+
+// ninePatchImage adds 1px border marking stretchable area (top and left) and content area (bottom and right)
+// with center of slice, as Android nine-patch format expects
+func ninePatchImage(img *image.NRGBA, center image.Rectangle) *image.NRGBA {
+	size := img.Rect.Size()
+	patch := image.NewNRGBA(image.Rect(0, 0, size.X+2, size.Y+2))
+	draw.Draw(patch, img.Rect.Add(image.Pt(1, 1)), img, image.Point{}, draw.Src)
+
+	marker := color.NRGBA{A: 255}
+	for x := max(center.Min.X, 0); x < min(center.Max.X, size.X); x++ {
+		patch.SetNRGBA(x+1, 0, marker)
+		patch.SetNRGBA(x+1, size.Y+1, marker)
+	}
+	for y := max(center.Min.Y, 0); y < min(center.Max.Y, size.Y); y++ {
+		patch.SetNRGBA(0, y+1, marker)
+		patch.SetNRGBA(size.X+1, y+1, marker)
+	}
+
+	return patch
+}
+
+func writePNG(filename string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filename, err)
+	}
+
+	if err := files.EnsureDirExists(filename); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+func toRectJSON(r image.Rectangle) rectJSON {
+	return rectJSON{X: r.Min.X, Y: r.Min.Y, W: r.Dx(), H: r.Dy()}
+}
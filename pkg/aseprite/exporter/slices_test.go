@@ -0,0 +1,150 @@
+package exporter_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pixelColor is color of sliced sprite pixel, unique per position and frame
+func pixelColor(x, y, frame int) color.NRGBA {
+	return color.NRGBA{R: uint8(x * 40), G: uint8(y * 60), B: uint8(frame * 200), A: 255}
+}
+
+// writeSlicedSprite writes 6x4 sprite of two frames with nine-patch "button" slice moved on second frame
+// and plain "icon" slice
+func writeSlicedSprite(t *testing.T, filename string) {
+	const width, height = 6, 4
+
+	sprite := &asefile.Sprite{
+		Width: width, Height: height, ColorDepth: asefile.ColorDepthRGBA,
+		Layers: []*asefile.Layer{{Name: "body", Flags: asefile.LayerFlagVisible, Opacity: 255}},
+	}
+	for frame := range 2 {
+		var pixels []byte
+		for y := range height {
+			for x := range width {
+				c := pixelColor(x, y, frame)
+				pixels = append(pixels, c.R, c.G, c.B, c.A)
+			}
+		}
+		sprite.Frames = append(sprite.Frames, &asefile.Frame{Index: frame, Cels: []*asefile.Cel{
+			{Frame: frame, Opacity: 255, Width: width, Height: height, Pixels: pixels},
+		}})
+	}
+
+	center := image.Rect(1, 1, 3, 2)
+	pivot := image.Pt(2, 1)
+	sprite.Slices = []*asefile.Slice{
+		{Name: "button", Keys: []asefile.SliceKey{
+			{Frame: 0, Bounds: image.Rect(1, 1, 5, 4), Center: &center, Pivot: &pivot},
+			{Frame: 1, Bounds: image.Rect(0, 0, 4, 3), Center: &center, Pivot: &pivot},
+		}},
+		{Name: "icon", Keys: []asefile.SliceKey{{Frame: 0, Bounds: image.Rect(4, 0, 6, 2)}}},
+	}
+
+	require.NoError(t, asefile.WriteFile(filename, sprite))
+}
+
+func TestExportSlices(t *testing.T) {
+	dir := t.TempDir()
+	spriteFilename := filepath.Join(dir, "hero.aseprite")
+	writeSlicedSprite(t, spriteFilename)
+
+	outDir := filepath.Join(dir, "out")
+	written, err := exporter.ExportSlices(exporter.SliceParams{
+		SpriteFilename: spriteFilename,
+		OutputDir:      outDir,
+		FramesIncluded: "0:1",
+		NinePatch:      true,
+	})
+	require.NoError(t, err)
+	assert.Len(t, written, 5)
+
+	t.Run("sidecar", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(outDir, "hero.slices.json"))
+		require.NoError(t, err)
+
+		var sidecar struct {
+			Size   map[string]int `json:"size"`
+			Slices []struct {
+				Name string           `json:"name"`
+				Keys []map[string]any `json:"keys"`
+			} `json:"slices"`
+		}
+		require.NoError(t, json.Unmarshal(data, &sidecar))
+		assert.Equal(t, map[string]int{"w": 6, "h": 4}, sidecar.Size)
+		require.Len(t, sidecar.Slices, 2)
+
+		rect := func(x, y, w, h float64) map[string]any { return map[string]any{"x": x, "y": y, "w": w, "h": h} }
+		tests := []struct {
+			slice, key int
+			want       map[string]any
+		}{
+			// center and pivot are relative to bounds
+			{0, 0, map[string]any{"frame": 0.0, "bounds": rect(1, 1, 4, 3), "center": rect(1, 1, 2, 1), "pivot": map[string]any{"x": 2.0, "y": 1.0}}},
+			{0, 1, map[string]any{"frame": 1.0, "bounds": rect(0, 0, 4, 3), "center": rect(1, 1, 2, 1), "pivot": map[string]any{"x": 2.0, "y": 1.0}}},
+			{1, 0, map[string]any{"frame": 0.0, "bounds": rect(4, 0, 2, 2)}},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.want, sidecar.Slices[tt.slice].Keys[tt.key], "%s key %d", sidecar.Slices[tt.slice].Name, tt.key)
+		}
+	})
+
+	tests := []struct {
+		file   string
+		frame  int
+		bounds image.Rectangle
+		// ninePatch images have 1px border with markers of center
+		ninePatch bool
+	}{
+		{"hero_button_0.9.png", 0, image.Rect(1, 1, 5, 4), true},
+		{"hero_button_1.9.png", 1, image.Rect(0, 0, 4, 3), true},
+		// icon key of the first frame stays active on the second one
+		{"hero_icon_0.png", 0, image.Rect(4, 0, 6, 2), false},
+		{"hero_icon_1.png", 1, image.Rect(4, 0, 6, 2), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join(outDir, tt.file))
+			require.NoError(t, err)
+			defer f.Close()
+			decoded, err := png.Decode(f)
+			require.NoError(t, err)
+
+			border := 0
+			if tt.ninePatch {
+				border = 1
+			}
+			size := tt.bounds.Size().Add(image.Pt(2*border, 2*border))
+			assert.Equal(t, size, decoded.Bounds().Size())
+
+			for y := range tt.bounds.Dy() {
+				for x := range tt.bounds.Dx() {
+					want := pixelColor(tt.bounds.Min.X+x, tt.bounds.Min.Y+y, tt.frame)
+					assert.Equal(t, want, color.NRGBAModel.Convert(decoded.At(x+border, y+border)), "pixel %d,%d", x, y)
+				}
+			}
+
+			if tt.ninePatch {
+				black, none := color.NRGBA{A: 255}, color.NRGBA{}
+				at := func(x, y int) color.Color { return color.NRGBAModel.Convert(decoded.At(x, y)) }
+				// center x is 1..3 and y is 1..2 in slice
+				assert.Equal(t, []color.Color{none, none, black, black, none, none}, []color.Color{at(0, 0), at(1, 0), at(2, 0), at(3, 0), at(4, 0), at(5, 0)})
+				assert.Equal(t, []color.Color{none, none, black, none, none}, []color.Color{at(0, 0), at(0, 1), at(0, 2), at(0, 3), at(0, 4)})
+				assert.Equal(t, black, at(size.X-1, 2))
+				assert.Equal(t, black, at(2, size.Y-1))
+			}
+		})
+	}
+}
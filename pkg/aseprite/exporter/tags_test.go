@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagsSprite returns sprite of five frames with walk (forward), attack (ping-pong),
+// block (ping-pong reverse) and comma named tags
+func tagsSprite() *asefile.Sprite {
+	return &asefile.Sprite{
+		Width: 4, Height: 4,
+		Frames: make([]*asefile.Frame, 5),
+		Tags: []*asefile.Tag{
+			{Name: "walk", From: 0, To: 1, Direction: asefile.TagForward},
+			{Name: "attack", From: 1, To: 3, Direction: asefile.TagPingPong, Repeat: 2},
+			{Name: "block", From: 2, To: 4, Direction: asefile.TagPingPongReverse},
+			{Name: "hit,back", From: 4, To: 4},
+		},
+	}
+}
+
+func TestResolveTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  Params
+		want    []string
+		wantErr string
+	}{
+		{"tag", Params{Tags: []string{"attack"}}, []string{"attack"}, ""},
+		{"trimmed duplicates", Params{Tags: []string{" walk ", "attack", "walk"}}, []string{"walk", "attack"}, ""},
+		{"unknown tag", Params{Tags: []string{"walk", "run"}}, nil, `tag "run" not found, available tags: walk, attack, block, hit,back`},
+		{"comma", Params{Tags: []string{"hit,back"}}, nil, "contains comma"},
+		{"all tags with comma", Params{AllTags: true}, nil, "contains comma"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := resolveTags(tagsSprite(), tt.params)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tags)
+		})
+	}
+
+	sprite := tagsSprite()
+	sprite.Tags = sprite.Tags[:3]
+	tags, err := resolveTags(sprite, Params{AllTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"walk", "attack", "block"}, tags)
+
+	_, err = resolveTags(&asefile.Sprite{}, Params{SpriteFilename: "hero.aseprite", AllTags: true})
+	assert.ErrorContains(t, err, "sprite hero.aseprite has no tags")
+}
+
+func TestTagsOutputs(t *testing.T) {
+	sprite := tagsSprite()
+
+	// tag suffix is added to default naming only when several tags are exported
+	one, err := Params{OutputFilename: "out/hero.png"}.outputTemplate(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"out/hero.png"}, one.outputs(sprite, templateExport{Layers: []string{""}, Tags: []string{"walk"}}))
+
+	several, err := Params{OutputFilename: "out/hero.png"}.outputTemplate(2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"out/hero_walk.png", "out/hero_attack.png"},
+		several.outputs(sprite, templateExport{Layers: []string{""}, Tags: []string{"walk", "attack"}}))
+
+	// ping-pong tag frames are written once each
+	perFrame := OutputTemplate("out/{tag}/{frame:02}.png")
+	assert.Equal(t, []string{"out/attack/01.png", "out/attack/02.png", "out/attack/03.png"},
+		perFrame.outputs(sprite, templateExport{Layers: []string{""}, Tags: []string{"attack"}}))
+}
+
+func TestTagsAnimations(t *testing.T) {
+	sprite := tagsSprite()
+	template := OutputTemplate("out/hero_{tag}.apng")
+	outputs := template.expandOutputs(sprite, templateExport{Layers: []string{""}, Tags: []string{"walk", "attack", "block"}})
+	require.Len(t, outputs, 3)
+
+	tests := []struct {
+		filename string
+		frames   []int
+		loops    int
+	}{
+		{"out/hero_walk.apng", []int{0, 1}, 0},
+		{"out/hero_attack.apng", []int{1, 2, 3, 2}, 2},
+		{"out/hero_block.apng", []int{4, 3, 2, 3}, 0},
+	}
+	for i, tt := range tests {
+		animation := newAnimation(sprite, Params{LoopCount: LoopCountTag}, outputs[i])
+		assert.Equal(t, tt.filename, animation.Filename)
+		assert.Equal(t, tt.frames, animation.Frames, tt.filename)
+		assert.Equal(t, tt.loops, animation.Loops, tt.filename)
+	}
+}
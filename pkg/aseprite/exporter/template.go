@@ -0,0 +1,294 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// Output template placeholders
+const (
+	PlaceholderName  = "name"
+	PlaceholderDir   = "dir"
+	PlaceholderLayer = "layer"
+	PlaceholderTag   = "tag"
+	PlaceholderFrame = "frame"
+	PlaceholderScale = "scale"
+	PlaceholderSize  = "size"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// OutputTemplate is output filename with placeholders (e.g. "{dir}/{name}_{tag}_{frame:03}.png"),
+// {frame} placeholder makes every exported frame saved to its own file, {frame:N} pads frame index with zeros
+type OutputTemplate string
+
+func TemplatePlaceholders() []string {
+	return []string{
+		PlaceholderName,
+		PlaceholderDir,
+		PlaceholderLayer,
+		PlaceholderTag,
+		PlaceholderFrame,
+		PlaceholderScale,
+		PlaceholderSize,
+	}
+}
+
+// ParseOutputTemplate validates placeholders and extension of output template
+func ParseOutputTemplate(template string) (OutputTemplate, error) {
+	if strings.TrimSpace(template) == "" {
+		return "", errors.New("output template cannot be empty")
+	}
+
+	rest := placeholderPattern.ReplaceAllString(template, "")
+	if strings.ContainsAny(rest, "{}") {
+		return "", fmt.Errorf("unbalanced braces in output template %q", template)
+	}
+
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		name, padding, hasPadding := strings.Cut(match[1], ":")
+		if !slices.Contains(TemplatePlaceholders(), name) {
+			return "", fmt.Errorf("unknown placeholder %s in output template, available: %s", match[0], strings.Join(TemplatePlaceholders(), ", "))
+		}
+
+		if !hasPadding {
+			continue
+		}
+		if name != PlaceholderFrame {
+			return "", fmt.Errorf("placeholder %s does not support padding, only {frame:N} does", match[0])
+		}
+		if width, err := strconv.Atoi(padding); err != nil || width <= 0 {
+			return "", fmt.Errorf("invalid padding in placeholder %s, use positive number (e.g. {frame:03})", match[0])
+		}
+	}
+
+	ext := filepath.Ext(template)
+	if strings.ContainsAny(ext, "{}") || !files.CheckFileExtension(template, aseprite.AvailableExportExtensions()...) {
+		return "", fmt.Errorf("output template must end with export extension, allowed: %v", aseprite.AvailableExportExtensions())
+	}
+
+	return OutputTemplate(template), nil
+}
+
+// defaultTemplateOptions lists export dimensions that need own suffix in default output naming
+type defaultTemplateOptions struct {
+	Tags        bool
+	SplitLayers bool
+	SplitFrames bool
+	Scaled      bool
+	Sized       bool
+}
+
+// defaultOutputTemplate keeps output naming of exports without template:
+// several tags, split layers and frames, scales and sizes get "_<tag>", "_<layer>", "_<frame>", "_<scale>x" and "_<size>" suffixes
+func defaultOutputTemplate(outputPath string, opts defaultTemplateOptions) OutputTemplate {
+	ext := files.GetFileExtension(outputPath)
+	template := strings.TrimSuffix(outputPath, ext)
+
+	if opts.Tags {
+		template += "_{" + PlaceholderTag + "}"
+	}
+	if opts.SplitLayers {
+		template += "_{" + PlaceholderLayer + "}"
+	}
+	if opts.SplitFrames {
+		template += "_{" + PlaceholderFrame + "}"
+	}
+
+	switch {
+	case opts.Scaled:
+		template += "_{" + PlaceholderScale + "}x"
+	case opts.Sized:
+		template += "_{" + PlaceholderSize + "}"
+	}
+
+	return OutputTemplate(template + ext)
+}
+
+func (t OutputTemplate) Format() string {
+	return strings.TrimPrefix(filepath.Ext(string(t)), ".")
+}
+
+func (t OutputTemplate) HasPlaceholder(name string) bool {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(string(t), -1) {
+		if placeholder, _, _ := strings.Cut(match[1], ":"); placeholder == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand replaces placeholders with values, placeholders without values are kept
+func (t OutputTemplate) Expand(values map[string]string) OutputTemplate {
+	return OutputTemplate(placeholderPattern.ReplaceAllStringFunc(string(t), func(placeholder string) string {
+		name, padding, hasPadding := strings.Cut(placeholder[1:len(placeholder)-1], ":")
+		value, ok := values[name]
+		if !ok {
+			return placeholder
+		}
+
+		if hasPadding {
+			width, _ := strconv.Atoi(padding)
+			if n, err := strconv.Atoi(value); err == nil {
+				return fmt.Sprintf("%0*d", width, n)
+			}
+		}
+		return value
+	}))
+}
+
+// spriteValues returns values of placeholders known before export script is run,
+// outputDir replaces sprite directory if set
+func spriteValues(spriteFilename, outputDir string) map[string]string {
+	dir := filepath.Dir(spriteFilename)
+	if outputDir != "" {
+		dir = outputDir
+	}
+
+	return map[string]string{
+		PlaceholderName: strings.TrimSuffix(filepath.Base(spriteFilename), filepath.Ext(spriteFilename)),
+		PlaceholderDir:  dir,
+	}
+}
+
+// templateExport describes exported frames and resizing used to expand output template
+type templateExport struct {
+	Layers []string
+	Tags   []string
+	Frames string
+	Scales []string
+	Sizes  []string
+}
+
+// templateOutput is file written by export with export dimensions it was expanded from
+type templateOutput struct {
+	Filename string
+	Layer    string
+	Tag      string
+	// From and To are frames range of output, one frame with {frame} placeholder
+	From  int
+	To    int
+	Scale string
+	Size  string
+}
+
+// outputs expands template for every file written by export
+func (t OutputTemplate) outputs(sprite *asefile.Sprite, e templateExport) []string {
+	var outputs []string
+	for _, output := range t.expandOutputs(sprite, e) {
+		outputs = append(outputs, output.Filename)
+	}
+	return outputs
+}
+
+func (t OutputTemplate) expandOutputs(sprite *asefile.Sprite, e templateExport) []templateOutput {
+	type framesRange struct {
+		tag      string
+		from, to int
+	}
+
+	var ranges []framesRange
+	for _, name := range e.Tags {
+		if tag := sprite.TagByName(name); tag != nil {
+			ranges = append(ranges, framesRange{tag: name, from: tag.From, to: tag.To})
+		}
+	}
+	if len(e.Tags) == 0 {
+		from, to := parseFramesRange(e.Frames, len(sprite.Frames))
+		ranges = append(ranges, framesRange{from: from, to: to})
+	}
+
+	scales := e.Scales
+	if len(scales) == 0 {
+		scales = []string{"1"}
+	}
+
+	sizes := e.Sizes
+	if len(sizes) == 0 {
+		sizes = []string{fmt.Sprintf("%dx%d", sprite.Width, sprite.Height)}
+	}
+
+	perFrame := t.HasPlaceholder(PlaceholderFrame)
+
+	var outputs []templateOutput
+	for _, layer := range e.Layers {
+		for _, r := range ranges {
+			for _, scale := range scales {
+				for _, size := range sizes {
+					values := map[string]string{
+						PlaceholderLayer: layer,
+						PlaceholderTag:   r.tag,
+						PlaceholderScale: scale,
+						PlaceholderSize:  size,
+					}
+					output := templateOutput{Layer: layer, Tag: r.tag, From: r.from, To: r.to, Scale: scale, Size: size}
+
+					if !perFrame {
+						output.Filename = string(t.Expand(values))
+						outputs = append(outputs, output)
+						continue
+					}
+
+					for frame := r.from; frame <= r.to; frame++ {
+						values[PlaceholderFrame] = strconv.Itoa(frame)
+						output.Filename = string(t.Expand(values))
+						output.From, output.To = frame, frame
+						outputs = append(outputs, output)
+					}
+				}
+			}
+		}
+	}
+
+	return outputs
+}
+
+// duplicateOutputs returns outputs written more than once (later exports overwrite earlier ones)
+func duplicateOutputs(outputs []string) []string {
+	seen := make(map[string]int, len(outputs))
+	var duplicates []string
+	for _, output := range outputs {
+		seen[output]++
+		if seen[output] == 2 {
+			duplicates = append(duplicates, output)
+		}
+	}
+	return duplicates
+}
+
+// parseFramesRange converts validated frames input ("*", "N", "N:M") to zero based range
+func parseFramesRange(input string, framesCount int) (int, int) {
+	if input == "" || input == "*" {
+		return 0, framesCount - 1
+	}
+
+	from, to, found := strings.Cut(input, ":")
+	start, _ := strconv.Atoi(from)
+	if !found {
+		return start, start
+	}
+
+	end, _ := strconv.Atoi(to)
+	return start, end
+}
+
+func splitList(input string) []string {
+	if input == "" {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(input, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
@@ -0,0 +1,136 @@
+package exporter_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputTemplate(t *testing.T) {
+	valid := []string{
+		"{dir}/{name}.png",
+		"out/{name}/{tag}_{frame:03}.png",
+		"out/{layer}-{scale}x-{size}.gif",
+	}
+	for _, template := range valid {
+		_, err := exporter.ParseOutputTemplate(template)
+		assert.NoError(t, err, template)
+	}
+
+	invalid := []string{
+		"",
+		"out/{unknown}.png",
+		"out/{name.png",
+		"out/name}.png",
+		"out/{scale:2}.png",
+		"out/{frame:x}.png",
+		"out/{name}.txt",
+		"out/{name}",
+		"out/sprite.{tag}",
+	}
+	for _, template := range invalid {
+		_, err := exporter.ParseOutputTemplate(template)
+		assert.Error(t, err, template)
+	}
+}
+
+func TestOutputTemplateExpand(t *testing.T) {
+	template, err := exporter.ParseOutputTemplate("{dir}/{name}_{tag}_{frame:03}.png")
+	require.NoError(t, err)
+
+	partial := template.Expand(map[string]string{"dir": "sprites", "name": "hero"})
+	assert.Equal(t, exporter.OutputTemplate("sprites/hero_{tag}_{frame:03}.png"), partial)
+	assert.True(t, partial.HasPlaceholder("frame"))
+	assert.False(t, partial.HasPlaceholder("name"))
+
+	full := partial.Expand(map[string]string{"tag": "walk", "frame": "7"})
+	assert.Equal(t, exporter.OutputTemplate("sprites/hero_walk_007.png"), full)
+	assert.Equal(t, "png", full.Format())
+}
+
+// writeTemplateSprite writes 4x2 sprite of three frames with layers tree (bottom to top)
+// background, body/ (group of arm and leg) and hidden notes, and returns its filename
+func writeTemplateSprite(t *testing.T, dir string) string {
+	visible := asefile.LayerFlagVisible
+	sprite := &asefile.Sprite{
+		Width: 4, Height: 2, ColorDepth: asefile.ColorDepthRGBA,
+		Layers: []*asefile.Layer{
+			{Name: "background", Flags: visible, Opacity: 255},
+			{Name: "body", Type: asefile.LayerTypeGroup, Flags: visible, Opacity: 255},
+			{Name: "arm", ChildLevel: 1, Flags: visible, Opacity: 255},
+			{Name: "leg", ChildLevel: 1, Flags: visible, Opacity: 255},
+			{Name: "notes", Opacity: 255},
+		},
+		Frames: []*asefile.Frame{{Duration: 100}, {Duration: 100}, {Duration: 100}},
+	}
+
+	filename := filepath.Join(dir, "hero.aseprite")
+	require.NoError(t, asefile.WriteFile(filename, sprite))
+	return filename
+}
+
+func TestPlanSplitOutputs(t *testing.T) {
+	dir := t.TempDir()
+	sprite := writeTemplateSprite(t, dir)
+	path := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name   string
+		params exporter.Params
+		want   []string
+	}{
+		{
+			"split layers",
+			exporter.Params{Format: "png", SplitLayers: true},
+			// group is not one file, its layers are named by path
+			path("hero_background.png", "hero_body-arm.png", "hero_body-leg.png"),
+		},
+		{
+			"split layers of group",
+			exporter.Params{Format: "png", SplitLayers: true, IncludeLayers: []string{"body"}},
+			path("hero_body-arm.png", "hero_body-leg.png"),
+		},
+		{
+			"split frames",
+			exporter.Params{Format: "png", SplitFrames: true, FramesIncluded: "1:2"},
+			path("hero_1.png", "hero_2.png"),
+		},
+		{
+			"split layers and frames with scales",
+			exporter.Params{Format: "png", SplitLayers: true, SplitFrames: true, FramesIncluded: "0:1", Scales: "1,2", ExcludeLayers: []string{"body"}},
+			path("hero_background_0_1x.png", "hero_background_1_1x.png", "hero_background_0_2x.png", "hero_background_1_2x.png"),
+		},
+		{
+			"template",
+			exporter.Params{OutputTemplate: filepath.Join(dir, "out", "{layer}", "{name}_{frame:02}.png"), SplitLayers: true, SplitFrames: true, IncludeLayers: []string{"arm"}},
+			path("out/body-arm/hero_00.png", "out/body-arm/hero_01.png", "out/body-arm/hero_02.png"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.SpriteFilename = sprite
+			job, err := exporter.Plan(tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, job.Outputs)
+			assert.Empty(t, job.Duplicates)
+			assert.Equal(t, tt.params.SplitLayers, job.Command.SplitLayers)
+			assert.Equal(t, tt.params.SplitFrames, job.Command.SplitFrames)
+		})
+	}
+
+	for _, template := range []string{"{dir}/{name}_{frame}.png", "{dir}/{name}_{layer}.png"} {
+		_, err := exporter.Plan(exporter.Params{SpriteFilename: sprite, OutputTemplate: template, SplitLayers: true, SplitFrames: true})
+		assert.ErrorContains(t, err, "placeholder to split", template)
+	}
+}
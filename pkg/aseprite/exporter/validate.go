@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func ValidateScalesInput(input string) error {
+	if input == "" {
+		return errors.New("scales cannot be empty")
+	}
+
+	elements := strings.Split(input, ",")
+
+	if err := ValidateNumberList(elements); err == nil {
+		return nil
+	}
+
+	return errors.New("invalid format: scales must be a comma-separated list of numbers (e.g., \"1,2,3\")")
+}
+
+func ValidateSizesInput(input string) error {
+	if input == "" {
+		return errors.New("sizes cannot be empty")
+	}
+
+	elements := strings.Split(input, ",")
+
+	if err := ValidatePairList(elements); err == nil {
+		return nil
+	}
+
+	return errors.New("invalid format: sizes must be a comma-separated list of positive pairs (e.g., \"64x64,128x128\")")
+}
+
+// NormalizeSizesInput validates sizes and returns them as canonical "WxH" pairs (e.g. " 064 x 64" is "64x64"),
+// the only form export script accepts and renders to {size} placeholder
+func NormalizeSizesInput(input string) (string, error) {
+	if err := ValidateSizesInput(input); err != nil {
+		return "", err
+	}
+
+	var sizes []string
+	for _, elem := range strings.Split(input, ",") {
+		w, h, _ := strings.Cut(elem, "x")
+		width, _ := strconv.Atoi(strings.TrimSpace(w))
+		height, _ := strconv.Atoi(strings.TrimSpace(h))
+		sizes = append(sizes, fmt.Sprintf("%dx%d", width, height))
+	}
+	return strings.Join(sizes, ","), nil
+}
+
+func ValidateNumberList(elements []string) error {
+	for _, elem := range elements {
+		elem = strings.TrimSpace(elem)
+		if _, err := strconv.Atoi(elem); err != nil {
+			return fmt.Errorf("invalid number: %q", elem)
+		}
+	}
+	return nil
+}
+
+func ValidatePairList(elements []string) error {
+	for _, elem := range elements {
+		elem = strings.TrimSpace(elem)
+		parts := strings.Split(elem, "x")
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid pair format: %q", elem)
+		}
+
+		// Validate width and height
+		for _, part := range parts {
+			if n, err := strconv.Atoi(strings.TrimSpace(part)); err != nil || n <= 0 {
+				return fmt.Errorf("invalid positive number in pair: %q", part)
+			}
+		}
+	}
+	return nil
+}
+
+func ValidateFramesInput(input string) error {
+	if input == "" {
+		return errors.New("frames cannot be empty")
+	}
+
+	if input == "*" {
+		return nil
+	}
+
+	if _, err := strconv.Atoi(input); err == nil {
+		return nil
+	}
+
+	parts := strings.Split(input, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid frames format: %s", input)
+	}
+
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid start frame: %s", parts[0])
+	}
+
+	end, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid end frame: %s", parts[1])
+	}
+
+	if start > end {
+		return fmt.Errorf("start frame %d > end frame %d", start, end)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package exporter_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSizesInput(t *testing.T) {
+	valid := map[string]string{
+		"64x64":               "64x64",
+		" 64 x 64, 128x96 ":   "64x64,128x96",
+		"064x064":             "64x64",
+		"+32x16":              "32x16",
+		"16x16,16x16,0032x32": "16x16,16x16,32x32",
+	}
+	for input, want := range valid {
+		sizes, err := exporter.NormalizeSizesInput(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, sizes, input)
+	}
+
+	for _, input := range []string{"", "64", "64x", "x64", "64x64x64", "-64x64", "64x0", "64X64", "64*64", "64x64,", "6 4x64"} {
+		_, err := exporter.NormalizeSizesInput(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestPlanNormalizesSizes(t *testing.T) {
+	dir := t.TempDir()
+	sprite := writeTemplateSprite(t, dir)
+
+	job, err := exporter.Plan(exporter.Params{SpriteFilename: sprite, Format: "png", Sizes: " 064 x 32,8x8"})
+	require.NoError(t, err)
+	assert.Equal(t, "64x32,8x8", job.Command.Sizes, "export script gets canonical sizes")
+	assert.Equal(t, []string{filepath.Join(dir, "hero_64x32.png"), filepath.Join(dir, "hero_8x8.png")}, job.Outputs)
+
+	_, err = exporter.Plan(exporter.Params{SpriteFilename: sprite, Format: "png", Sizes: "-4x4"})
+	assert.ErrorContains(t, err, "invalid sizes input")
+}
@@ -67,6 +67,7 @@ func AvailableExportExtensions() []string {
 	extensions := []string{}
 	extensions = append(extensions, SpritesExtensions()...)
 	extensions = append(extensions,
+		".apng",
 		".bmp",
 		".css",
 		".flc",
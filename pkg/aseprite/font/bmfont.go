@@ -0,0 +1,181 @@
+package font
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Format is BMFont descriptor format
+type Format string
+
+const (
+	// FormatText is BMFont text descriptor
+	FormatText Format = "text"
+	// FormatXML is BMFont XML descriptor
+	FormatXML Format = "xml"
+)
+
+func Formats() []string {
+	return []string{string(FormatText), string(FormatXML)}
+}
+
+// ValidateFormat checks descriptor format name
+func ValidateFormat(format string) error {
+	if !slices.Contains(Formats(), format) {
+		return fmt.Errorf("unknown font format %q, available formats: %s", format, strings.Join(Formats(), ", "))
+	}
+	return nil
+}
+
+// Encode returns BMFont descriptor of font in format, page is filename of page image relative to descriptor
+func (f *Font) Encode(format Format, page string) ([]byte, error) {
+	if format == FormatXML {
+		return f.xml(page)
+	}
+	return f.text(page), nil
+}
+
+func (f *Font) text(page string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "info face=\"%s\" size=%d bold=0 italic=0 charset=\"\" unicode=1 stretchH=100 smooth=0 aa=1 padding=0,0,0,0 spacing=%d,%d outline=0\n",
+		quoteless(f.Face), f.LineHeight, f.Padding, f.Padding)
+	fmt.Fprintf(&b, "common lineHeight=%d base=%d scaleW=%d scaleH=%d pages=1 packed=0 alphaChnl=0 redChnl=0 greenChnl=0 blueChnl=0\n",
+		f.LineHeight, f.Base, f.Page.Rect.Dx(), f.Page.Rect.Dy())
+	fmt.Fprintf(&b, "page id=0 file=\"%s\"\n", quoteless(page))
+
+	fmt.Fprintf(&b, "chars count=%d\n", len(f.Chars))
+	for _, c := range f.Chars {
+		fmt.Fprintf(&b, "char id=%d x=%d y=%d width=%d height=%d xoffset=%d yoffset=%d xadvance=%d page=0 chnl=15\n",
+			c.ID, c.X, c.Y, c.Width, c.Height, c.XOffset, c.YOffset, c.XAdvance)
+	}
+
+	if len(f.Kernings) > 0 {
+		fmt.Fprintf(&b, "kernings count=%d\n", len(f.Kernings))
+		for _, k := range f.Kernings {
+			fmt.Fprintf(&b, "kerning first=%d second=%d amount=%d\n", k.First, k.Second, k.Amount)
+		}
+	}
+	return b.Bytes()
+}
+
+type xmlFont struct {
+	XMLName  xml.Name     `xml:"font"`
+	Info     xmlInfo      `xml:"info"`
+	Common   xmlCommon    `xml:"common"`
+	Pages    []xmlPage    `xml:"pages>page"`
+	Chars    xmlChars     `xml:"chars"`
+	Kernings *xmlKernings `xml:"kernings,omitempty"`
+}
+
+type xmlInfo struct {
+	Face     string `xml:"face,attr"`
+	Size     int    `xml:"size,attr"`
+	Bold     int    `xml:"bold,attr"`
+	Italic   int    `xml:"italic,attr"`
+	Charset  string `xml:"charset,attr"`
+	Unicode  int    `xml:"unicode,attr"`
+	StretchH int    `xml:"stretchH,attr"`
+	Smooth   int    `xml:"smooth,attr"`
+	AA       int    `xml:"aa,attr"`
+	Padding  string `xml:"padding,attr"`
+	Spacing  string `xml:"spacing,attr"`
+	Outline  int    `xml:"outline,attr"`
+}
+
+type xmlCommon struct {
+	LineHeight int `xml:"lineHeight,attr"`
+	Base       int `xml:"base,attr"`
+	ScaleW     int `xml:"scaleW,attr"`
+	ScaleH     int `xml:"scaleH,attr"`
+	Pages      int `xml:"pages,attr"`
+	Packed     int `xml:"packed,attr"`
+	AlphaChnl  int `xml:"alphaChnl,attr"`
+	RedChnl    int `xml:"redChnl,attr"`
+	GreenChnl  int `xml:"greenChnl,attr"`
+	BlueChnl   int `xml:"blueChnl,attr"`
+}
+
+type xmlPage struct {
+	ID   int    `xml:"id,attr"`
+	File string `xml:"file,attr"`
+}
+
+type xmlChars struct {
+	Count int       `xml:"count,attr"`
+	Chars []xmlChar `xml:"char"`
+}
+
+type xmlChar struct {
+	ID       rune `xml:"id,attr"`
+	X        int  `xml:"x,attr"`
+	Y        int  `xml:"y,attr"`
+	Width    int  `xml:"width,attr"`
+	Height   int  `xml:"height,attr"`
+	XOffset  int  `xml:"xoffset,attr"`
+	YOffset  int  `xml:"yoffset,attr"`
+	XAdvance int  `xml:"xadvance,attr"`
+	Page     int  `xml:"page,attr"`
+	Chnl     int  `xml:"chnl,attr"`
+}
+
+type xmlKernings struct {
+	Count    int          `xml:"count,attr"`
+	Kernings []xmlKerning `xml:"kerning"`
+}
+
+type xmlKerning struct {
+	First  rune `xml:"first,attr"`
+	Second rune `xml:"second,attr"`
+	Amount int  `xml:"amount,attr"`
+}
+
+func (f *Font) xml(page string) ([]byte, error) {
+	doc := xmlFont{
+		Info: xmlInfo{
+			Face:     f.Face,
+			Size:     f.LineHeight,
+			Unicode:  1,
+			StretchH: 100,
+			AA:       1,
+			Padding:  "0,0,0,0",
+			Spacing:  fmt.Sprintf("%d,%d", f.Padding, f.Padding),
+		},
+		Common: xmlCommon{
+			LineHeight: f.LineHeight,
+			Base:       f.Base,
+			ScaleW:     f.Page.Rect.Dx(),
+			ScaleH:     f.Page.Rect.Dy(),
+			Pages:      1,
+		},
+		Pages: []xmlPage{{ID: 0, File: page}},
+		Chars: xmlChars{Count: len(f.Chars)},
+	}
+
+	for _, c := range f.Chars {
+		doc.Chars.Chars = append(doc.Chars.Chars, xmlChar{
+			ID: c.ID, X: c.X, Y: c.Y, Width: c.Width, Height: c.Height,
+			XOffset: c.XOffset, YOffset: c.YOffset, XAdvance: c.XAdvance, Chnl: 15,
+		})
+	}
+
+	if len(f.Kernings) > 0 {
+		doc.Kernings = &xmlKernings{Count: len(f.Kernings)}
+		for _, k := range f.Kernings {
+			doc.Kernings.Kernings = append(doc.Kernings.Kernings, xmlKerning(k))
+		}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// quoteless replaces double quotes which text descriptor values cannot escape
+func quoteless(value string) string {
+	return strings.ReplaceAll(value, `"`, "'")
+}
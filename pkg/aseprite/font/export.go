@@ -0,0 +1,172 @@
+package font
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// Params describes bitmap font build from sprite with glyphs sheet (natively, without aseprite)
+type Params struct {
+	SpriteFilename string
+	// OutputDir is directory of descriptor and page image, sprite directory if empty
+	OutputDir string
+	// Name is font face and base name of written files, sprite name if empty
+	Name string
+	// Charmap are characters of glyph cells in order, with slices they can be omitted when slices are named
+	// by their characters
+	Charmap []rune
+	// Cell is size of glyph cells grid, sprite slices are glyphs if empty
+	Cell image.Point
+	// Frame is rendered frame of glyphs sheet
+	Frame int
+	// Format is descriptor format, text if empty
+	Format string
+	// KerningFilename is kerning pairs sidecar (see ParseKerning), no kerning if empty
+	KerningFilename string
+	Options         Options
+	IncludeLayers   []string
+	ExcludeLayers   []string
+	IncludeHidden   bool
+}
+
+// Export builds font from sprite glyphs and writes <name>.fnt descriptor with <name>_0.png page, returns written
+// filenames and built font
+func Export(params Params) ([]string, *Font, error) {
+	format := Format(params.Format)
+	if format == "" {
+		format = FormatText
+	}
+	if err := ValidateFormat(string(format)); err != nil {
+		return nil, nil, err
+	}
+
+	if params.SpriteFilename == "" ||
+		!files.CheckFileExists(params.SpriteFilename, false) ||
+		!files.CheckFileExtension(params.SpriteFilename, aseprite.SpritesExtensions()...) {
+		return nil, nil, fmt.Errorf("invalid sprite filename: %q", params.SpriteFilename)
+	}
+
+	sprite, err := asefile.ReadFile(params.SpriteFilename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read sprite: %w", err)
+	}
+	if params.Frame < 0 || params.Frame >= len(sprite.Frames) {
+		return nil, nil, fmt.Errorf("frame %d is out of sprite frames range 0-%d", params.Frame, len(sprite.Frames)-1)
+	}
+
+	var kernings []Kerning
+	if params.KerningFilename != "" {
+		data, err := os.ReadFile(params.KerningFilename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read kerning file: %w", err)
+		}
+		if kernings, err = ParseKerning(bytes.NewReader(data)); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", params.KerningFilename, err)
+		}
+	}
+
+	selected, err := exporter.SelectLayers(sprite, params.IncludeLayers, params.ExcludeLayers, params.IncludeHidden)
+	if err != nil {
+		return nil, nil, err
+	}
+	sheet := sprite.FrameImage(params.Frame, func(l *asefile.Layer) bool { return slices.Contains(selected, l) })
+
+	var glyphs []Glyph
+	if params.Cell != (image.Point{}) {
+		if len(params.Charmap) == 0 {
+			return nil, nil, errors.New("charmap is required for glyph cells grid")
+		}
+		glyphs, err = Cut(sheet, GridCells(sheet.Rect, params.Cell), params.Charmap)
+	} else {
+		glyphs, err = sliceGlyphs(sprite, sheet, params.Frame, params.Charmap)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := params.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(params.SpriteFilename), filepath.Ext(params.SpriteFilename))
+	}
+
+	f, err := Build(name, glyphs, kernings, params.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outputDir := params.OutputDir
+	if outputDir == "" {
+		outputDir = filepath.Dir(params.SpriteFilename)
+	}
+	pageName := name + "_0.png"
+
+	descriptor, err := f.Encode(format, pageName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var page bytes.Buffer
+	if err := png.Encode(&page, f.Page); err != nil {
+		return nil, nil, err
+	}
+
+	var written []string
+	for _, output := range []struct {
+		filename string
+		data     []byte
+	}{
+		{filepath.Join(outputDir, pageName), page.Bytes()},
+		{filepath.Join(outputDir, name+".fnt"), descriptor},
+	} {
+		if err := files.EnsureDirExists(output.filename); err != nil {
+			return written, nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err := os.WriteFile(output.filename, output.data, 0644); err != nil {
+			return written, nil, err
+		}
+		written = append(written, output.filename)
+	}
+
+	return written, f, nil
+}
+
+// sliceGlyphs returns glyphs of sprite slices keys at frame in sprite order, characters are taken from charmap
+// or from slices names
+func sliceGlyphs(sprite *asefile.Sprite, sheet *image.NRGBA, frame int, charmap []rune) ([]Glyph, error) {
+	var (
+		cells []image.Rectangle
+		names []string
+	)
+	for _, slice := range sprite.Slices {
+		if key := slice.KeyAt(frame); key != nil && !key.Bounds.Empty() {
+			cells = append(cells, key.Bounds)
+			names = append(names, slice.Name)
+		}
+	}
+	if len(cells) == 0 {
+		return nil, errors.New("sprite has no slices on frame, use glyph cells grid instead")
+	}
+
+	if len(charmap) == 0 {
+		for _, name := range names {
+			char, err := ParseChar(name)
+			if err != nil {
+				return nil, fmt.Errorf("slice %q must be named by its character without charmap: %w", name, err)
+			}
+			charmap = append(charmap, char)
+		}
+	}
+
+	return Cut(sheet, cells, charmap)
+}
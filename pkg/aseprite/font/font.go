@@ -0,0 +1,231 @@
+package font
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/packer"
+)
+
+// Glyph is character image cut from glyphs sheet, image bounds are glyph cell
+type Glyph struct {
+	Char  rune
+	Image *image.NRGBA
+}
+
+type Options struct {
+	// Spacing is added to advance of every glyph
+	Spacing int
+	// SpaceWidth is advance of empty glyphs (e.g. space), half of line height if 0
+	SpaceWidth int
+	// LineHeight is distance between lines, the tallest glyph cell if 0
+	LineHeight int
+	// Base is distance from line top to glyphs baseline, line height if 0
+	Base int
+	// Padding is transparent space between glyphs in page image
+	Padding    int
+	PowerOfTwo bool
+}
+
+// Char is BMFont character, X and Y are glyph position in page, offsets are relative to cursor at line top
+type Char struct {
+	ID       rune
+	X        int
+	Y        int
+	Width    int
+	Height   int
+	XOffset  int
+	YOffset  int
+	XAdvance int
+}
+
+// Kerning adjusts advance of First character when it is followed by Second one
+type Kerning struct {
+	First  rune
+	Second rune
+	Amount int
+}
+
+// Font is bitmap font with one page image
+type Font struct {
+	Face       string
+	LineHeight int
+	Base       int
+	Padding    int
+	Page       *image.NRGBA
+	Chars      []Char
+	Kernings   []Kerning
+}
+
+// Build packs trimmed glyphs into page image, glyph advance is width of its opaque columns plus spacing
+func Build(face string, glyphs []Glyph, kernings []Kerning, opts Options) (*Font, error) {
+	if len(glyphs) == 0 {
+		return nil, errors.New("font has no glyphs")
+	}
+	if opts.Spacing < 0 || opts.SpaceWidth < 0 || opts.LineHeight < 0 || opts.Base < 0 || opts.Padding < 0 {
+		return nil, errors.New("spacing, space width, line height, base and padding cannot be negative")
+	}
+
+	chars := make(map[rune]bool, len(glyphs))
+	lineHeight := opts.LineHeight
+	for _, glyph := range glyphs {
+		if chars[glyph.Char] {
+			return nil, fmt.Errorf("character %s has several glyphs", FormatChar(glyph.Char))
+		}
+		chars[glyph.Char] = true
+		if opts.LineHeight == 0 {
+			lineHeight = max(lineHeight, glyph.Image.Rect.Dy())
+		}
+	}
+	for _, kerning := range kernings {
+		if !chars[kerning.First] || !chars[kerning.Second] {
+			return nil, fmt.Errorf("kerning pair %s %s uses character without glyph", FormatChar(kerning.First), FormatChar(kerning.Second))
+		}
+	}
+
+	f := &Font{
+		Face:       face,
+		LineHeight: lineHeight,
+		Base:       opts.Base,
+		Padding:    opts.Padding,
+		Kernings:   kernings,
+	}
+	if f.Base == 0 {
+		f.Base = lineHeight
+	}
+
+	spaceWidth := opts.SpaceWidth
+	if spaceWidth == 0 {
+		spaceWidth = (lineHeight + 1) / 2
+	}
+
+	// empty glyphs only advance cursor and are not packed
+	var frames []packer.Frame
+	index := make(map[string]int)
+	for _, glyph := range glyphs {
+		char := Char{ID: glyph.Char, XAdvance: spaceWidth}
+		if left, right, found := opaqueColumns(glyph.Image); found {
+			char.XAdvance = right - left + opts.Spacing
+			index[strconv.Itoa(int(glyph.Char))] = len(f.Chars)
+			frames = append(frames, packer.Frame{Name: strconv.Itoa(int(glyph.Char)), Image: crop(glyph.Image, glyph.Image.Rect)})
+		}
+		f.Chars = append(f.Chars, char)
+	}
+
+	if len(frames) == 0 {
+		return nil, errors.New("every font glyph is empty")
+	}
+
+	atlas, err := packer.Pack(frames, nil, packer.Options{
+		Layout:          packer.LayoutPacked,
+		Padding:         opts.Padding,
+		Trim:            true,
+		MergeDuplicates: true,
+		PowerOfTwo:      opts.PowerOfTwo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.Page = atlas.Image
+
+	for _, placement := range atlas.Placements {
+		char := &f.Chars[index[placement.Frame.Name]]
+		char.X, char.Y = placement.Rect.Min.X, placement.Rect.Min.Y
+		char.Width, char.Height = placement.Rect.Dx(), placement.Rect.Dy()
+		// advance starts at the first opaque column, so trimmed glyph has no horizontal offset
+		char.YOffset = placement.SourceRect.Min.Y
+	}
+
+	return f, nil
+}
+
+// opaqueColumns returns range of image columns (relative to image origin) containing opaque pixels
+func opaqueColumns(img *image.NRGBA) (left, right int, found bool) {
+	left, right = img.Rect.Dx(), 0
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.NRGBAAt(x, y).A == 0 {
+				continue
+			}
+			left = min(left, x-img.Rect.Min.X)
+			right = max(right, x-img.Rect.Min.X+1)
+		}
+	}
+	return left, right, right > left
+}
+
+// GridCells returns cells of size covering area row by row, partial cells on right and bottom edges are skipped
+func GridCells(area image.Rectangle, cell image.Point) []image.Rectangle {
+	var cells []image.Rectangle
+	if cell.X <= 0 || cell.Y <= 0 {
+		return cells
+	}
+
+	for y := area.Min.Y; y+cell.Y <= area.Max.Y; y += cell.Y {
+		for x := area.Min.X; x+cell.X <= area.Max.X; x += cell.X {
+			cells = append(cells, image.Rectangle{Min: image.Pt(x, y), Max: image.Pt(x+cell.X, y+cell.Y)})
+		}
+	}
+	return cells
+}
+
+// Cut returns glyphs of image cells assigned to charmap characters in order, cells after charmap end are ignored
+func Cut(img *image.NRGBA, cells []image.Rectangle, charmap []rune) ([]Glyph, error) {
+	if len(charmap) > len(cells) {
+		return nil, fmt.Errorf("charmap has %d characters, but sheet has only %d glyph cells", len(charmap), len(cells))
+	}
+
+	glyphs := make([]Glyph, len(charmap))
+	for i, char := range charmap {
+		glyphs[i] = Glyph{Char: char, Image: crop(img, cells[i])}
+	}
+	return glyphs, nil
+}
+
+// ParseCharmap returns characters of charmap in order, line breaks only separate sheet rows and are skipped
+func ParseCharmap(charmap string) []rune {
+	var chars []rune
+	for _, char := range charmap {
+		if char != '\n' && char != '\r' {
+			chars = append(chars, char)
+		}
+	}
+	return chars
+}
+
+// ParseChar parses single character or its code point as U+XXXX
+func ParseChar(value string) (rune, error) {
+	if code, found := strings.CutPrefix(strings.ToUpper(value), "U+"); found && len(value) > 2 {
+		n, err := strconv.ParseUint(code, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(n)) {
+			return 0, fmt.Errorf("invalid code point %q", value)
+		}
+		return rune(n), nil
+	}
+
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("%q is not single character or U+XXXX code point", value)
+	}
+	char, _ := utf8.DecodeRuneInString(value)
+	return char, nil
+}
+
+// FormatChar returns printable character or its U+XXXX code point
+func FormatChar(char rune) string {
+	if char <= ' ' || !strconv.IsPrint(char) {
+		return fmt.Sprintf("U+%04X", char)
+	}
+	return strconv.QuoteRune(char)
+}
+
+// crop copies area of image to new image starting at origin
+func crop(img *image.NRGBA, area image.Rectangle) *image.NRGBA {
+	result := image.NewNRGBA(image.Rectangle{Max: area.Size()})
+	draw.Draw(result, result.Rect, img, area.Min, draw.Src)
+	return result
+}
@@ -0,0 +1,128 @@
+package font_test
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/font"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+// testSheet returns 4x6 cells sheet of glyphs: "I" (1px column at x=1), "-" (3px row at y=3), empty and second "I"
+func testSheet() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 6))
+	for _, cell := range []int{0, 12} {
+		for y := 1; y < 6; y++ {
+			img.SetNRGBA(cell+1, y, white)
+		}
+	}
+	for x := 4; x < 7; x++ {
+		img.SetNRGBA(x, 3, white)
+	}
+	return img
+}
+
+func build(t *testing.T, kernings []font.Kerning) *font.Font {
+	glyphs, err := font.Cut(testSheet(), font.GridCells(image.Rect(0, 0, 16, 6), image.Pt(4, 6)), []rune("I- i"))
+	require.NoError(t, err)
+
+	f, err := font.Build("test", glyphs, kernings, font.Options{Spacing: 1, Padding: 1})
+	require.NoError(t, err)
+	return f
+}
+
+func TestBuild(t *testing.T) {
+	f := build(t, nil)
+	assert.Equal(t, 6, f.LineHeight)
+	assert.Equal(t, 6, f.Base)
+	require.Len(t, f.Chars, 4)
+
+	i, dash, space, other := f.Chars[0], f.Chars[1], f.Chars[2], f.Chars[3]
+	assert.Equal(t, font.Char{ID: 'I', X: i.X, Y: i.Y, Width: 1, Height: 5, YOffset: 1, XAdvance: 2}, i)
+	assert.Equal(t, 3, dash.Width)
+	assert.Equal(t, 1, dash.Height)
+	assert.Equal(t, 3, dash.YOffset)
+	assert.Equal(t, 4, dash.XAdvance)
+
+	// empty glyph advances by half of line height
+	assert.Equal(t, font.Char{ID: ' ', XAdvance: 3}, space)
+
+	// identical glyphs share page area
+	assert.Equal(t, image.Pt(i.X, i.Y), image.Pt(other.X, other.Y))
+	assert.Equal(t, white, f.Page.NRGBAAt(i.X, i.Y+4))
+}
+
+func TestBuildErrors(t *testing.T) {
+	glyph := font.Glyph{Char: 'A', Image: testSheet()}
+
+	_, err := font.Build("test", []font.Glyph{glyph, glyph}, nil, font.Options{})
+	assert.ErrorContains(t, err, "several glyphs")
+
+	_, err = font.Build("test", []font.Glyph{glyph}, []font.Kerning{{First: 'A', Second: 'V', Amount: -1}}, font.Options{})
+	assert.ErrorContains(t, err, "without glyph")
+
+	_, err = font.Cut(testSheet(), font.GridCells(image.Rect(0, 0, 16, 6), image.Pt(8, 8)), []rune("A"))
+	assert.Error(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	f := build(t, []font.Kerning{{First: 'I', Second: '-', Amount: -1}})
+
+	text, err := f.Encode(font.FormatText, "test_0.png")
+	require.NoError(t, err)
+	lines := strings.Split(string(text), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], `info face="test" size=6 `))
+	assert.Contains(t, lines[1], "common lineHeight=6 base=6 ")
+	assert.Equal(t, `page id=0 file="test_0.png"`, lines[2])
+	assert.Equal(t, "chars count=4", lines[3])
+	assert.Contains(t, string(text), "char id=32 x=0 y=0 width=0 height=0 xoffset=0 yoffset=0 xadvance=3 page=0 chnl=15\n")
+	assert.Contains(t, string(text), "kernings count=1\nkerning first=73 second=45 amount=-1\n")
+
+	data, err := f.Encode(font.FormatXML, "test_0.png")
+	require.NoError(t, err)
+
+	var doc struct {
+		Pages []struct {
+			File string `xml:"file,attr"`
+		} `xml:"pages>page"`
+		Chars []struct {
+			ID       int `xml:"id,attr"`
+			XAdvance int `xml:"xadvance,attr"`
+		} `xml:"chars>char"`
+		Kernings []struct {
+			Amount int `xml:"amount,attr"`
+		} `xml:"kernings>kerning"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "test_0.png", doc.Pages[0].File)
+	require.Len(t, doc.Chars, 4)
+	assert.Equal(t, 'I', rune(doc.Chars[0].ID))
+	assert.Equal(t, 2, doc.Chars[0].XAdvance)
+	assert.Equal(t, -1, doc.Kernings[0].Amount)
+}
+
+func TestParseKerning(t *testing.T) {
+	kernings, err := font.ParseKerning(strings.NewReader("# pairs\nAV -1\n\nT o -2\nU+0020 A 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []font.Kerning{
+		{First: 'A', Second: 'V', Amount: -1},
+		{First: 'T', Second: 'o', Amount: -2},
+		{First: ' ', Second: 'A', Amount: 1},
+	}, kernings)
+
+	_, err = font.ParseKerning(strings.NewReader("AV -1\nAV 1\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = font.ParseKerning(strings.NewReader("AVX -1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseCharmap(t *testing.T) {
+	assert.Equal(t, []rune("AB CДé"), font.ParseCharmap("AB C\r\nДé\n"))
+}
@@ -0,0 +1,72 @@
+package font
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseKerning reads kerning pairs sidecar, every line is pair of characters and amount in pixels:
+// "AV -1" or "A V -1" (characters may be written as U+XXXX). Empty lines and lines starting with # are skipped
+func ParseKerning(r io.Reader) ([]Kerning, error) {
+	var kernings []Kerning
+	seen := make(map[[2]rune]bool)
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		kerning, err := parseKerningLine(strings.Fields(text))
+		if err != nil {
+			return nil, fmt.Errorf("kerning line %d: %w", line, err)
+		}
+
+		pair := [2]rune{kerning.First, kerning.Second}
+		if seen[pair] {
+			return nil, fmt.Errorf("kerning line %d: pair %s %s is repeated", line, FormatChar(pair[0]), FormatChar(pair[1]))
+		}
+		seen[pair] = true
+		kernings = append(kernings, kerning)
+	}
+
+	return kernings, scanner.Err()
+}
+
+func parseKerningLine(fields []string) (Kerning, error) {
+	var (
+		first, second rune
+		size          int
+		err           error
+	)
+
+	switch {
+	case len(fields) == 2 && utf8.RuneCountInString(fields[0]) == 2:
+		first, size = utf8.DecodeRuneInString(fields[0])
+		second, _ = utf8.DecodeRuneInString(fields[0][size:])
+		return kerningAmount(first, second, fields[1])
+	case len(fields) == 3:
+		if first, err = ParseChar(fields[0]); err != nil {
+			return Kerning{}, err
+		}
+		if second, err = ParseChar(fields[1]); err != nil {
+			return Kerning{}, err
+		}
+		return kerningAmount(first, second, fields[2])
+	}
+
+	return Kerning{}, fmt.Errorf("expected \"AV -1\" or \"A V -1\", got %q", strings.Join(fields, " "))
+}
+
+func kerningAmount(first, second rune, value string) (Kerning, error) {
+	amount, err := strconv.Atoi(value)
+	if err != nil {
+		return Kerning{}, fmt.Errorf("invalid kerning amount %q", value)
+	}
+	return Kerning{First: first, Second: second, Amount: amount}, nil
+}
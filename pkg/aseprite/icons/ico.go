@@ -0,0 +1,61 @@
+package icons
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/optimize"
+)
+
+const (
+	icoHeaderSize = 6
+	icoEntrySize  = 16
+	icoTypeIcon   = 1
+)
+
+// EncodeICO writes multi-resolution ico of images, every image is stored as 32-bit RGBA png
+// (supported since Windows Vista), other png color types are not loaded by Windows
+func EncodeICO(images []*image.NRGBA) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, errors.New("ico has no images")
+	}
+
+	payloads := make([][]byte, len(images))
+	for i, img := range images {
+		size := img.Rect.Size()
+		if size.X <= 0 || size.Y <= 0 || size.X > MaxICOSize || size.Y > MaxICOSize {
+			return nil, fmt.Errorf("invalid ico image size %dx%d, max %dx%d", size.X, size.Y, MaxICOSize, MaxICOSize)
+		}
+
+		data, err := optimize.EncodeRGBA(img)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = data
+	}
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, [3]uint16{0, icoTypeIcon, uint16(len(images))})
+
+	offset := icoHeaderSize + icoEntrySize*len(images)
+	for i, img := range images {
+		size := img.Rect.Size()
+		// 256 pixels are written as 0
+		buf.WriteByte(uint8(size.X))
+		buf.WriteByte(uint8(size.Y))
+		// no palette, reserved
+		buf.Write([]byte{0, 0})
+		// color planes, bits per pixel
+		_ = binary.Write(&buf, binary.LittleEndian, [2]uint16{1, 32})
+		_ = binary.Write(&buf, binary.LittleEndian, [2]uint32{uint32(len(payloads[i])), uint32(offset)})
+		offset += len(payloads[i])
+	}
+
+	for _, payload := range payloads {
+		buf.Write(payload)
+	}
+	return buf.Bytes(), nil
+}
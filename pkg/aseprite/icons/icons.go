@@ -0,0 +1,113 @@
+package icons
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+	"strings"
+)
+
+// Target is platform icon set written from sprite image
+type Target string
+
+const (
+	// ICO is multi-resolution windows icon
+	ICO Target = "ico"
+	// Favicon is set of web icons with web manifest
+	Favicon Target = "favicon"
+	// Android is set of launcher icons of mipmap densities
+	Android Target = "android"
+	// IOS is xcode AppIcon.appiconset with Contents.json
+	IOS Target = "ios"
+)
+
+// MaxICOSize is the largest icon size stored in ico file
+const MaxICOSize = 256
+
+// DefaultICOSizes are sizes of ico target when sizes are not given
+var DefaultICOSizes = []image.Point{{16, 16}, {24, 24}, {32, 32}, {48, 48}, {64, 64}, {128, 128}, {256, 256}}
+
+// Targets returns names of supported targets
+func Targets() []string {
+	return []string{string(ICO), string(Favicon), string(Android), string(IOS)}
+}
+
+// ParseTargets parses target names, empty names are every target
+func ParseTargets(names []string) ([]Target, error) {
+	if len(names) == 0 {
+		return []Target{ICO, Favicon, Android, IOS}, nil
+	}
+
+	var targets []Target
+	for _, name := range names {
+		target := Target(strings.ToLower(strings.TrimSpace(name)))
+		if !slices.Contains(Targets(), string(target)) {
+			return nil, fmt.Errorf("unknown icon target %q, available: %s", name, strings.Join(Targets(), ", "))
+		}
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// Fit scales image by the largest integer factor fitting size and centers it on background padding.
+// Image larger than size is downscaled with nearest neighbour keeping aspect ratio, exact reports
+// whether image was scaled by integer factor
+func Fit(img *image.NRGBA, size image.Point, background color.NRGBA) (result *image.NRGBA, exact bool) {
+	src := img.Rect.Size()
+	result = image.NewNRGBA(image.Rectangle{Max: size})
+	if src.X == 0 || src.Y == 0 {
+		return result, false
+	}
+
+	var scaled image.Point
+	if factor := min(size.X/src.X, size.Y/src.Y); factor >= 1 {
+		scaled = src.Mul(factor)
+		exact = true
+	} else if src.X*size.Y > src.Y*size.X {
+		scaled = image.Pt(size.X, max(1, src.Y*size.X/src.X))
+	} else {
+		scaled = image.Pt(max(1, src.X*size.Y/src.Y), size.Y)
+	}
+
+	offset := size.Sub(scaled).Div(2)
+	for y := 0; y < scaled.Y; y++ {
+		for x := 0; x < scaled.X; x++ {
+			c := img.NRGBAAt(img.Rect.Min.X+x*src.X/scaled.X, img.Rect.Min.Y+y*src.Y/scaled.Y)
+			result.SetNRGBA(offset.X+x, offset.Y+y, c)
+		}
+	}
+
+	// padding and translucent pixels are put over background
+	if background.A > 0 {
+		fitted := image.NewNRGBA(result.Rect)
+		draw.Draw(fitted, fitted.Rect, image.NewUniform(background), image.Point{}, draw.Src)
+		draw.Draw(fitted, fitted.Rect, result, image.Point{}, draw.Over)
+		result = fitted
+	}
+	return result, exact
+}
+
+// ParseSizes parses square icon sizes of ico target from validated sizes list (e.g. "16x16,32x32")
+func ParseSizes(items []string) ([]image.Point, error) {
+	var sizes []image.Point
+	for _, item := range items {
+		var size image.Point
+		if _, err := fmt.Sscanf(strings.ReplaceAll(item, " ", ""), "%dx%d", &size.X, &size.Y); err != nil {
+			return nil, fmt.Errorf("invalid icon size %q", item)
+		}
+		if size.X != size.Y || size.X <= 0 {
+			return nil, fmt.Errorf("icon size %q must be square", item)
+		}
+		if size.X > MaxICOSize {
+			return nil, fmt.Errorf("icon size %q exceeds ico limit %dx%d", item, MaxICOSize, MaxICOSize)
+		}
+		if !slices.Contains(sizes, size) {
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes, nil
+}
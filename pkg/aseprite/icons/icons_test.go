@@ -0,0 +1,158 @@
+package icons_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/icons"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var red = color.NRGBA{R: 255, A: 255}
+
+// testImage returns 3x2 image with red top-left pixel
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.SetNRGBA(0, 0, red)
+	img.SetNRGBA(2, 1, color.NRGBA{B: 255, A: 255})
+	return img
+}
+
+func TestFit(t *testing.T) {
+	// scaled by 5 to 15x10 and centered vertically
+	img, exact := icons.Fit(testImage(), image.Pt(16, 16), color.NRGBA{})
+	assert.True(t, exact)
+	assert.Equal(t, image.Rect(0, 0, 16, 16), img.Rect)
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(0, 2))
+	assert.Equal(t, red, img.NRGBAAt(0, 3))
+	assert.Equal(t, red, img.NRGBAAt(4, 7))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(5, 3))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(15, 3))
+
+	background := color.NRGBA{G: 255, A: 255}
+	img, _ = icons.Fit(testImage(), image.Pt(16, 16), background)
+	assert.Equal(t, background, img.NRGBAAt(0, 0))
+	assert.Equal(t, background, img.NRGBAAt(5, 3))
+	assert.Equal(t, red, img.NRGBAAt(0, 3))
+
+	// downscaled keeping aspect ratio
+	img, exact = icons.Fit(testImage(), image.Pt(2, 2), color.NRGBA{})
+	assert.False(t, exact)
+	assert.Equal(t, red, img.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(0, 1))
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := icons.ParseTargets(nil)
+	require.NoError(t, err)
+	assert.Len(t, targets, 4)
+
+	targets, err = icons.ParseTargets([]string{"IOS", "ico", "ios"})
+	require.NoError(t, err)
+	assert.Equal(t, []icons.Target{icons.IOS, icons.ICO}, targets)
+
+	_, err = icons.ParseTargets([]string{"macos"})
+	assert.Error(t, err)
+}
+
+func TestParseSizes(t *testing.T) {
+	sizes, err := icons.ParseSizes([]string{"16x16", " 32x32", "16x16"})
+	require.NoError(t, err)
+	assert.Equal(t, []image.Point{{16, 16}, {32, 32}}, sizes)
+
+	_, err = icons.ParseSizes([]string{"16x8"})
+	assert.Error(t, err)
+	_, err = icons.ParseSizes([]string{"512x512"})
+	assert.Error(t, err)
+}
+
+func TestEncodeICO(t *testing.T) {
+	small, _ := icons.Fit(testImage(), image.Pt(16, 16), color.NRGBA{})
+	large, _ := icons.Fit(testImage(), image.Pt(256, 256), color.NRGBA{})
+
+	data, err := icons.EncodeICO([]*image.NRGBA{small, large})
+	require.NoError(t, err)
+
+	assert.Equal(t, []uint16{0, 1, 2}, []uint16{
+		binary.LittleEndian.Uint16(data[0:]), binary.LittleEndian.Uint16(data[2:]), binary.LittleEndian.Uint16(data[4:]),
+	})
+
+	// red pixel is top-left one of centered image
+	for i, want := range []struct {
+		*image.NRGBA
+		red image.Point
+	}{{small, image.Pt(0, 3)}, {large, image.Pt(0, 43)}} {
+		entry := data[6+16*i:]
+		// 256 is stored as 0
+		assert.Equal(t, uint8(want.Rect.Dx()%256), entry[0])
+		size, offset := binary.LittleEndian.Uint32(entry[8:]), binary.LittleEndian.Uint32(entry[12:])
+		assert.Equal(t, uint16(32), binary.LittleEndian.Uint16(entry[6:]))
+		assertRGBAPNG(t, data[offset:offset+size])
+
+		img, err := png.Decode(bytes.NewReader(data[offset : offset+size]))
+		require.NoError(t, err)
+		assert.Equal(t, want.Rect, img.Bounds())
+		assert.Equal(t, red, color.NRGBAModel.Convert(img.At(want.red.X, want.red.Y)))
+	}
+
+	_, err = icons.EncodeICO([]*image.NRGBA{image.NewNRGBA(image.Rect(0, 0, 512, 512))})
+	assert.Error(t, err)
+}
+
+// assertRGBAPNG checks that png is 8-bit RGBA (color type 6) declared by 32 bpp ico entries
+func assertRGBAPNG(t *testing.T, data []byte) {
+	// IHDR data follows signature, chunk length and type, bit depth and color type follow width and height
+	require.Greater(t, len(data), 26)
+	assert.Equal(t, []byte{8, 6}, data[24:26])
+}
+
+func TestEncodeICOColorTypes(t *testing.T) {
+	gray := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	opaque := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for i := 0; i < len(gray.Pix); i += 4 {
+		copy(gray.Pix[i:], []byte{128, 128, 128, 255})
+		copy(opaque.Pix[i:], []byte{byte(i), byte(i / 4), byte(i / 8), 255})
+	}
+
+	// palette, grayscale and truecolor images would be smaller pngs of other color types
+	for _, img := range []*image.NRGBA{testImage(), gray, opaque} {
+		data, err := icons.EncodeICO([]*image.NRGBA{img})
+		require.NoError(t, err)
+
+		size, offset := binary.LittleEndian.Uint32(data[14:]), binary.LittleEndian.Uint32(data[18:])
+		payload := data[offset : offset+size]
+		assertRGBAPNG(t, payload)
+
+		decoded, err := png.Decode(bytes.NewReader(payload))
+		require.NoError(t, err)
+		assert.Equal(t, img.Pix, decoded.(*image.NRGBA).Pix)
+	}
+}
+
+func TestIOSContents(t *testing.T) {
+	data, err := icons.IOSContents()
+	require.NoError(t, err)
+
+	var contents struct {
+		Images []struct {
+			Filename string `json:"filename"`
+			Size     string `json:"size"`
+		} `json:"images"`
+	}
+	require.NoError(t, json.Unmarshal(data, &contents))
+
+	files := make(map[string]bool)
+	for _, icon := range icons.IOSIcons() {
+		files[icon.File] = true
+	}
+	for _, image := range contents.Images {
+		assert.True(t, files[image.Filename], image.Filename)
+	}
+	assert.Contains(t, string(data), `"83.5x83.5"`)
+}
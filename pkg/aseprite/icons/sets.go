@@ -0,0 +1,176 @@
+package icons
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"strconv"
+)
+
+// Icon is png image of icon set, File is relative to target directory
+type Icon struct {
+	File string
+	Size int
+}
+
+// FaviconICOSizes are sizes of favicon.ico of favicon target
+var FaviconICOSizes = []image.Point{{16, 16}, {32, 32}, {48, 48}}
+
+// Dir returns directory of target files relative to output directory
+func (t Target) Dir() string {
+	switch t {
+	case Favicon:
+		return "favicon"
+	case Android:
+		return "android"
+	case IOS:
+		return "ios/AppIcon.appiconset"
+	}
+	return ""
+}
+
+// FaviconIcons returns png images of favicon target
+func FaviconIcons() []Icon {
+	return []Icon{
+		{File: "favicon-16x16.png", Size: 16},
+		{File: "favicon-32x32.png", Size: 32},
+		{File: "apple-touch-icon.png", Size: 180},
+		{File: "android-chrome-192x192.png", Size: 192},
+		{File: "android-chrome-512x512.png", Size: 512},
+	}
+}
+
+// AndroidIcons returns launcher icons of mipmap densities and play store icon
+func AndroidIcons() []Icon {
+	return []Icon{
+		{File: "mipmap-mdpi/ic_launcher.png", Size: 48},
+		{File: "mipmap-hdpi/ic_launcher.png", Size: 72},
+		{File: "mipmap-xhdpi/ic_launcher.png", Size: 96},
+		{File: "mipmap-xxhdpi/ic_launcher.png", Size: 144},
+		{File: "mipmap-xxxhdpi/ic_launcher.png", Size: 192},
+		{File: "ic_launcher-playstore.png", Size: 512},
+	}
+}
+
+// iosIcon is AppIcon.appiconset image of idiom with size in points
+type iosIcon struct {
+	Idiom string
+	Size  float64
+	Scale int
+}
+
+var iosIconsSet = []iosIcon{
+	{"iphone", 20, 2}, {"iphone", 20, 3},
+	{"iphone", 29, 2}, {"iphone", 29, 3},
+	{"iphone", 40, 2}, {"iphone", 40, 3},
+	{"iphone", 60, 2}, {"iphone", 60, 3},
+	{"ipad", 20, 1}, {"ipad", 20, 2},
+	{"ipad", 29, 1}, {"ipad", 29, 2},
+	{"ipad", 40, 1}, {"ipad", 40, 2},
+	{"ipad", 76, 1}, {"ipad", 76, 2},
+	{"ipad", 83.5, 2},
+	{"ios-marketing", 1024, 1},
+}
+
+func (i iosIcon) pixels() int {
+	return int(i.Size * float64(i.Scale))
+}
+
+func (i iosIcon) file() string {
+	return fmt.Sprintf("Icon-%d.png", i.pixels())
+}
+
+// IOSIcons returns images of AppIcon.appiconset, images of the same pixels size are shared by idioms
+func IOSIcons() []Icon {
+	var icons []Icon
+	seen := make(map[int]bool)
+	for _, icon := range iosIconsSet {
+		if seen[icon.pixels()] {
+			continue
+		}
+		seen[icon.pixels()] = true
+		icons = append(icons, Icon{File: icon.file(), Size: icon.pixels()})
+	}
+	return icons
+}
+
+type appIconContents struct {
+	Images []appIconImage `json:"images"`
+	Info   appIconInfo    `json:"info"`
+}
+
+type appIconImage struct {
+	Filename string `json:"filename"`
+	Idiom    string `json:"idiom"`
+	Scale    string `json:"scale"`
+	Size     string `json:"size"`
+}
+
+type appIconInfo struct {
+	Author  string `json:"author"`
+	Version int    `json:"version"`
+}
+
+// IOSContents returns Contents.json of AppIcon.appiconset referencing IOSIcons images
+func IOSContents() ([]byte, error) {
+	contents := appIconContents{Info: appIconInfo{Author: "xcode", Version: 1}}
+	for _, icon := range iosIconsSet {
+		points := strconv.FormatFloat(icon.Size, 'f', -1, 64)
+		contents.Images = append(contents.Images, appIconImage{
+			Filename: icon.file(),
+			Idiom:    icon.Idiom,
+			Scale:    fmt.Sprintf("%dx", icon.Scale),
+			Size:     points + "x" + points,
+		})
+	}
+
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type webManifest struct {
+	Name      string            `json:"name"`
+	ShortName string            `json:"short_name"`
+	Icons     []webManifestIcon `json:"icons"`
+	Display   string            `json:"display"`
+}
+
+type webManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// WebManifest returns site.webmanifest of favicon target with android-chrome icons
+func WebManifest(name string) ([]byte, error) {
+	manifest := webManifest{Name: name, ShortName: name, Display: "standalone"}
+	for _, icon := range FaviconIcons() {
+		if icon.Size < 192 {
+			continue
+		}
+		manifest.Icons = append(manifest.Icons, webManifestIcon{
+			Src:   icon.File,
+			Sizes: fmt.Sprintf("%dx%d", icon.Size, icon.Size),
+			Type:  "image/png",
+		})
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// FaviconLinks returns html head snippet of favicon target files
+func FaviconLinks() string {
+	return `<link rel="icon" href="/favicon.ico" sizes="48x48">
+<link rel="icon" type="image/png" sizes="32x32" href="/favicon-32x32.png">
+<link rel="icon" type="image/png" sizes="16x16" href="/favicon-16x16.png">
+<link rel="apple-touch-icon" sizes="180x180" href="/apple-touch-icon.png">
+<link rel="manifest" href="/site.webmanifest">
+`
+}
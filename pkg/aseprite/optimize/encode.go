@@ -0,0 +1,375 @@
+package optimize
+
+import (
+	"bytes"
+	"cmp"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"slices"
+)
+
+// png color types
+const (
+	colorGray      = 0
+	colorRGB       = 2
+	colorPalette   = 3
+	colorGrayAlpha = 4
+	colorRGBA      = 6
+)
+
+// png row filters
+const (
+	filterNone = iota
+	filterSub
+	filterUp
+	filterAverage
+	filterPaeth
+	filtersCount
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// encoding is candidate png representation of images: color type with bit depth and palette
+type encoding struct {
+	colorType byte
+	bitDepth  byte
+	palette   []color.NRGBA
+	index     map[color.NRGBA]int
+	// bpp is number of bytes per complete pixel used by filters, rounded up to 1
+	bpp int
+}
+
+// Encode losslessly encodes image to smallest png found: indexed when image has at most 256 colors,
+// grayscale or truecolor without alpha when possible, every encoding is tried with every row filter
+// and per row adaptive filtering. Only critical chunks are written, so metadata is stripped
+func Encode(img *image.NRGBA) ([]byte, error) {
+	if img.Rect.Empty() {
+		return nil, errors.New("cannot encode empty image")
+	}
+
+	var best []byte
+	for _, enc := range encodings(img) {
+		data, err := enc.encode(img)
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || len(data) < len(best) {
+			best = data
+		}
+	}
+	return best, nil
+}
+
+// EncodeRGBA losslessly encodes image to 8-bit RGBA png whatever its colors are, for formats
+// declaring 32 bits per pixel (e.g. ico entries). Every row filter is tried as in Encode
+func EncodeRGBA(img *image.NRGBA) ([]byte, error) {
+	if img.Rect.Empty() {
+		return nil, errors.New("cannot encode empty image")
+	}
+	return (&encoding{colorType: colorRGBA, bitDepth: 8, bpp: 4}).encode(img)
+}
+
+// Encoder compresses several images of the same png header (color type, bit depth and palette),
+// e.g. frames of animated png
+type Encoder struct {
+	enc *encoding
+}
+
+// NewEncoder chooses encoding fitting every image: indexed when images have at most 256 colors together
+func NewEncoder(images []*image.NRGBA) *Encoder {
+	return &Encoder{enc: encodings(images...)[0]}
+}
+
+// WriteHeader writes png signature, IHDR chunk of w x h image and palette chunks
+func (e *Encoder) WriteHeader(buf *bytes.Buffer, w, h int) {
+	buf.Write(pngSignature)
+	e.enc.writeHeader(buf, w, h)
+}
+
+// ImageData returns smallest compressed data of image (IDAT content) found with every row filter
+// and adaptive per row filtering, image colors must be known to encoder
+func (e *Encoder) ImageData(img *image.NRGBA) ([]byte, error) {
+	return e.enc.imageData(img)
+}
+
+// encodings returns candidate encodings of images, indexed one goes first when colors fit palette
+func encodings(images ...*image.NRGBA) []*encoding {
+	opaque, gray := true, true
+	counts := make(map[color.NRGBA]int)
+	for _, img := range images {
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				c := img.NRGBAAt(x, y)
+				opaque = opaque && c.A == 255
+				gray = gray && c.R == c.G && c.G == c.B
+				if len(counts) <= 256 {
+					counts[c]++
+				}
+			}
+		}
+	}
+
+	var result []*encoding
+	if len(counts) <= 256 {
+		result = append(result, paletteEncoding(counts))
+	}
+
+	var colorType byte
+	switch {
+	case gray && opaque:
+		colorType = colorGray
+	case gray:
+		colorType = colorGrayAlpha
+	case opaque:
+		colorType = colorRGB
+	default:
+		colorType = colorRGBA
+	}
+	channels := map[byte]int{colorGray: 1, colorGrayAlpha: 2, colorRGB: 3, colorRGBA: 4}[colorType]
+	return append(result, &encoding{colorType: colorType, bitDepth: 8, bpp: channels})
+}
+
+func paletteEncoding(counts map[color.NRGBA]int) *encoding {
+	palette := make([]color.NRGBA, 0, len(counts))
+	for c := range counts {
+		palette = append(palette, c)
+	}
+	// translucent colors go first to keep tRNS chunk short, frequent colors go before rare ones
+	slices.SortFunc(palette, func(a, b color.NRGBA) int {
+		if (a.A == 255) != (b.A == 255) {
+			if a.A != 255 {
+				return -1
+			}
+			return 1
+		}
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return cmp.Compare(packColor(a), packColor(b))
+	})
+
+	index := make(map[color.NRGBA]int, len(palette))
+	for i, c := range palette {
+		index[c] = i
+	}
+
+	var depth int
+	switch {
+	case len(palette) <= 2:
+		depth = 1
+	case len(palette) <= 4:
+		depth = 2
+	case len(palette) <= 16:
+		depth = 4
+	default:
+		depth = 8
+	}
+
+	return &encoding{colorType: colorPalette, bitDepth: byte(depth), palette: palette, index: index, bpp: 1}
+}
+
+// encode writes whole png of image in encoding
+func (e *encoding) encode(img *image.NRGBA) ([]byte, error) {
+	idat, err := e.imageData(img)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	e.writeHeader(&buf, img.Rect.Dx(), img.Rect.Dy())
+	WriteChunk(&buf, "IDAT", idat)
+	WriteChunk(&buf, "IEND", nil)
+	return buf.Bytes(), nil
+}
+
+// rows returns raw (not filtered) image rows in encoding
+func (e *encoding) rows(img *image.NRGBA) [][]byte {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	rows := make([][]byte, h)
+	for y := range rows {
+		var row []byte
+		if e.colorType == colorPalette {
+			depth := int(e.bitDepth)
+			row = make([]byte, (w*depth+7)/8)
+			for x := 0; x < w; x++ {
+				i := e.index[img.NRGBAAt(img.Rect.Min.X+x, img.Rect.Min.Y+y)]
+				bit := x * depth
+				row[bit/8] |= byte(i << (8 - depth - bit%8))
+			}
+			rows[y] = row
+			continue
+		}
+
+		row = make([]byte, 0, w*e.bpp)
+		for x := 0; x < w; x++ {
+			c := img.NRGBAAt(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+			switch e.colorType {
+			case colorGray:
+				row = append(row, c.R)
+			case colorGrayAlpha:
+				row = append(row, c.R, c.A)
+			case colorRGB:
+				row = append(row, c.R, c.G, c.B)
+			default:
+				row = append(row, c.R, c.G, c.B, c.A)
+			}
+		}
+		rows[y] = row
+	}
+	return rows
+}
+
+// imageData returns smallest image data of fixed filters and adaptive per row filtering
+func (e *encoding) imageData(img *image.NRGBA) ([]byte, error) {
+	rows := e.rows(img)
+	var best []byte
+	for filter := -1; filter < filtersCount; filter++ {
+		data, err := e.compress(rows, filter)
+		if err != nil {
+			return nil, err
+		}
+		if best == nil || len(data) < len(best) {
+			best = data
+		}
+	}
+	return best, nil
+}
+
+// writeHeader writes IHDR chunk and palette chunks of indexed encoding
+func (e *encoding) writeHeader(buf *bytes.Buffer, w, h int) {
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], uint32(w))
+	binary.BigEndian.PutUint32(header[4:], uint32(h))
+	header[8] = e.bitDepth
+	header[9] = e.colorType
+	WriteChunk(buf, "IHDR", header)
+
+	if e.colorType == colorPalette {
+		plte := make([]byte, 0, len(e.palette)*3)
+		var trns []byte
+		for _, c := range e.palette {
+			plte = append(plte, c.R, c.G, c.B)
+			if c.A != 255 {
+				trns = append(trns, c.A)
+			}
+		}
+		WriteChunk(buf, "PLTE", plte)
+		if len(trns) > 0 {
+			WriteChunk(buf, "tRNS", trns)
+		}
+	}
+}
+
+// compress filters rows with filter (adaptive when negative) and deflates them
+func (e *encoding) compress(rows [][]byte, filter int) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := make([]byte, len(rows[0]))
+	filtered := make([]byte, len(prev)+1)
+	for _, row := range rows {
+		if filter < 0 {
+			copy(filtered, adaptiveFilter(row, prev, e.bpp))
+		} else {
+			filtered[0] = byte(filter)
+			applyFilter(filtered[1:], row, prev, e.bpp, filter)
+		}
+		if _, err := zw.Write(filtered); err != nil {
+			return nil, err
+		}
+		prev = row
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// adaptiveFilter returns filter type byte with row filtered by filter giving minimum sum of absolute
+// values of signed bytes, heuristic recommended by png specification
+func adaptiveFilter(row, prev []byte, bpp int) []byte {
+	var best []byte
+	bestSum := -1
+	candidate := make([]byte, len(row)+1)
+	for filter := range filtersCount {
+		candidate[0] = byte(filter)
+		applyFilter(candidate[1:], row, prev, bpp, filter)
+
+		sum := 0
+		for _, b := range candidate[1:] {
+			sum += min(int(b), 256-int(b))
+		}
+		if bestSum < 0 || sum < bestSum {
+			bestSum = sum
+			best = slices.Clone(candidate)
+		}
+	}
+	return best
+}
+
+func applyFilter(dst, row, prev []byte, bpp, filter int) {
+	for i, x := range row {
+		var a, c byte
+		if i >= bpp {
+			a, c = row[i-bpp], prev[i-bpp]
+		}
+		b := prev[i]
+
+		switch filter {
+		case filterNone:
+			dst[i] = x
+		case filterSub:
+			dst[i] = x - a
+		case filterUp:
+			dst[i] = x - b
+		case filterAverage:
+			dst[i] = x - byte((int(a)+int(b))/2)
+		case filterPaeth:
+			dst[i] = x - paeth(a, b, c)
+		}
+	}
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// WriteChunk writes png chunk with length and checksum
+func WriteChunk(buf *bytes.Buffer, name string, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(name)
+	buf.Write(data)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(name))
+	crc.Write(data)
+	_ = binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+func packColor(c color.NRGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
@@ -0,0 +1,165 @@
+package optimize
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrAnimated is returned for animated png (APNG), its frames are not kept by re-encoding
+	ErrAnimated = errors.New("animated png is not optimized")
+	// ErrLossy is returned for 16-bit png which colors cannot be stored in 8 bits per channel
+	ErrLossy = errors.New("16-bit png cannot be optimized losslessly")
+)
+
+// Result is size of png file before and after optimization, file is kept when optimized one is not smaller
+type Result struct {
+	Filename string
+	Before   int
+	After    int
+}
+
+// Saved returns number of bytes saved by optimization
+func (r Result) Saved() int {
+	return r.Before - r.After
+}
+
+func (r Result) String() string {
+	percent := 0.0
+	if r.Before > 0 {
+		percent = float64(r.Saved()) * 100 / float64(r.Before)
+	}
+	return fmt.Sprintf("%s: %s → %s (-%s, %.1f%%)", r.Filename, FormatBytes(r.Before), FormatBytes(r.After), FormatBytes(r.Saved()), percent)
+}
+
+// Total sums sizes of results
+func Total(results []Result) Result {
+	total := Result{Filename: "total"}
+	for _, r := range results {
+		total.Before += r.Before
+		total.After += r.After
+	}
+	return total
+}
+
+// Data losslessly re-encodes png data, original data is returned when it is already smaller
+func Data(data []byte) ([]byte, error) {
+	if isAnimated(data) {
+		return nil, ErrAnimated
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	nrgba, ok := toNRGBA(img)
+	if !ok {
+		return nil, ErrLossy
+	}
+
+	optimized, err := Encode(nrgba)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(optimized) >= len(data) {
+		return data, nil
+	}
+	return optimized, nil
+}
+
+// File optimizes png file in place, unless dryRun is set
+func File(filename string, dryRun bool) (Result, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Result{}, err
+	}
+
+	optimized, err := Data(data)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to optimize %s: %w", filename, err)
+	}
+
+	result := Result{Filename: filename, Before: len(data), After: len(optimized)}
+	if dryRun || result.Saved() == 0 {
+		return result, nil
+	}
+	return result, os.WriteFile(filename, optimized, 0644)
+}
+
+// IsPNG reports whether filename has png extension
+func IsPNG(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".png")
+}
+
+// FormatBytes formats size with binary units
+func FormatBytes(n int) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit && value > -unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// toNRGBA converts image to 8-bit straight alpha colors, ok is false when conversion loses precision.
+// Straight alpha colors (png palette entries and 16-bit pixels) are read as is, not through premultiplied RGBA
+func toNRGBA(img image.Image) (*image.NRGBA, bool) {
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return nrgba, true
+	}
+
+	bounds := img.Bounds()
+	result := image.NewNRGBA(image.Rectangle{Max: bounds.Size()})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var c color.NRGBA
+			switch value := img.At(x, y).(type) {
+			case color.NRGBA:
+				c = value
+			default:
+				wide := color.NRGBA64Model.Convert(value).(color.NRGBA64)
+				if wide.R%257 != 0 || wide.G%257 != 0 || wide.B%257 != 0 || wide.A%257 != 0 {
+					return nil, false
+				}
+				c = color.NRGBA{R: uint8(wide.R >> 8), G: uint8(wide.G >> 8), B: uint8(wide.B >> 8), A: uint8(wide.A >> 8)}
+			}
+			result.SetNRGBA(x-bounds.Min.X, y-bounds.Min.Y, c)
+		}
+	}
+	return result, true
+}
+
+// isAnimated reports whether png data has animation control chunk before image data
+func isAnimated(data []byte) bool {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return false
+	}
+
+	for offset := len(pngSignature); offset+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		switch string(data[offset+4 : offset+8]) {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+		offset += 12 + length
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package optimize_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/optimize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage returns image with gradient of colors count colors, some of them translucent
+func testImage(colors int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			i := (y*64 + x) % colors
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(i * 7), G: uint8(i * 13), B: uint8(i), A: uint8(255 - i%3*100)})
+		}
+	}
+	return img
+}
+
+func encode(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, data []byte) *image.NRGBA {
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	result := image.NewNRGBA(img.Bounds())
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			c := img.At(x, y)
+			if nrgba, ok := c.(color.NRGBA); ok {
+				result.SetNRGBA(x, y, nrgba)
+			} else {
+				result.Set(x, y, c)
+			}
+		}
+	}
+	return result
+}
+
+// chunk returns png chunk with name and data
+func chunk(name string, data []byte) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+	buf.WriteString(name)
+	buf.Write(data)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(name), data...)))
+	return buf.Bytes()
+}
+
+// withChunk inserts chunk after png IHDR chunk
+func withChunk(data []byte, name string, content []byte) []byte {
+	const ihdrEnd = 8 + 25
+	result := append([]byte{}, data[:ihdrEnd]...)
+	result = append(result, chunk(name, content)...)
+	return append(result, data[ihdrEnd:]...)
+}
+
+func TestEncode(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 300, 20))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 31 % 256)
+	}
+
+	for name, img := range map[string]*image.NRGBA{
+		"two colors": testImage(2),
+		"indexed":    testImage(200),
+		"truecolor":  testImage(1000),
+		"gray":       decode(t, encode(t, gray)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := optimize.Encode(img)
+			require.NoError(t, err)
+
+			assert.Equal(t, img.Pix, decode(t, data).Pix)
+			assert.LessOrEqual(t, len(data), len(encode(t, img)))
+		})
+	}
+}
+
+func TestData(t *testing.T) {
+	original := withChunk(encode(t, testImage(16)), "tEXt", []byte("Software\x00Aseprite"))
+
+	optimized, err := optimize.Data(original)
+	require.NoError(t, err)
+	assert.Less(t, len(optimized), len(original))
+	assert.NotContains(t, string(optimized), "tEXt")
+	assert.Equal(t, testImage(16).Pix, decode(t, optimized).Pix)
+
+	// optimized data is not optimized further
+	again, err := optimize.Data(optimized)
+	require.NoError(t, err)
+	assert.Equal(t, optimized, again)
+
+	_, err = optimize.Data(withChunk(original, "acTL", make([]byte, 8)))
+	assert.ErrorIs(t, err, optimize.ErrAnimated)
+
+	wide := image.NewNRGBA64(image.Rect(0, 0, 2, 2))
+	wide.SetNRGBA64(0, 0, color.NRGBA64{R: 1000, A: 0xffff})
+	_, err = optimize.Data(encode(t, wide))
+	assert.ErrorIs(t, err, optimize.ErrLossy)
+}
+
+func TestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hero.png")
+	original := encode(t, testImage(4))
+	require.NoError(t, os.WriteFile(filename, original, 0644))
+
+	result, err := optimize.File(filename, true)
+	require.NoError(t, err)
+	assert.Equal(t, len(original), result.Before)
+	assert.Positive(t, result.Saved())
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, original, data)
+
+	result, err = optimize.File(filename, false)
+	require.NoError(t, err)
+	data, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Len(t, data, result.After)
+
+	total := optimize.Total([]optimize.Result{result, {Before: 10, After: 4}})
+	assert.Equal(t, result.Saved()+6, total.Saved())
+	assert.Equal(t, "1.5 KiB", optimize.FormatBytes(1536))
+}
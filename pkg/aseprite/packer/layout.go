@@ -0,0 +1,235 @@
+package packer
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// maxPackedWidths limits number of atlas widths tried by packed layout
+const maxPackedWidths = 64
+
+// layout returns positions of cells and size of atlas containing them
+func layout(cells []image.Point, opts Options) ([]image.Point, image.Point, error) {
+	switch opts.Layout {
+	case LayoutRows:
+		positions, size := rowsLayout(cells, opts.Count, opts.MaxWidth, opts.Padding)
+		return positions, size, nil
+	case LayoutColumns:
+		// columns are rows of transposed cells
+		transposed := make([]image.Point, len(cells))
+		for i, c := range cells {
+			transposed[i] = image.Pt(c.Y, c.X)
+		}
+
+		positions, size := rowsLayout(transposed, opts.Count, opts.MaxHeight, opts.Padding)
+		for i, p := range positions {
+			positions[i] = image.Pt(p.Y, p.X)
+		}
+		return positions, image.Pt(size.Y, size.X), nil
+	default:
+		return packedLayout(cells, opts)
+	}
+}
+
+// rowsLayout places count cells per row (square grid if 0), rows are wrapped at limit width too
+func rowsLayout(cells []image.Point, count, limit, padding int) ([]image.Point, image.Point) {
+	if count == 0 {
+		count = int(math.Ceil(math.Sqrt(float64(len(cells)))))
+	}
+
+	positions := make([]image.Point, len(cells))
+	var (
+		x, y, rowHeight, inRow int
+		size                   image.Point
+	)
+
+	for i, cell := range cells {
+		if inRow > 0 && (inRow == count || (limit > 0 && x+cell.X > limit)) {
+			y += rowHeight + padding
+			x, rowHeight, inRow = 0, 0, 0
+		}
+
+		positions[i] = image.Pt(x, y)
+		x += cell.X + padding
+		rowHeight = max(rowHeight, cell.Y)
+		inRow++
+
+		size.X = max(size.X, x-padding)
+		size.Y = max(size.Y, y+rowHeight)
+	}
+
+	return positions, size
+}
+
+// packedLayout tries range of atlas widths and keeps layout with smallest area (squarer one on tie)
+func packedLayout(cells []image.Point, opts Options) ([]image.Point, image.Point, error) {
+	padded := make([]image.Point, len(cells))
+	var (
+		area       int
+		minW, sumH int
+	)
+	for i, c := range cells {
+		padded[i] = c.Add(image.Pt(opts.Padding, opts.Padding))
+		area += padded[i].X * padded[i].Y
+		minW = max(minW, padded[i].X)
+		sumH += padded[i].Y
+	}
+
+	// larger cells first, index keeps order of equal cells deterministic
+	order := make([]int, len(cells))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := padded[order[i]], padded[order[j]]
+		if max(a.X, a.Y) != max(b.X, b.Y) {
+			return max(a.X, a.Y) > max(b.X, b.Y)
+		}
+		return a.X*a.Y > b.X*b.Y
+	})
+
+	maxW := max(minW, int(math.Ceil(math.Sqrt(float64(area))))*2)
+	if opts.MaxWidth > 0 {
+		maxW = min(maxW, opts.MaxWidth+opts.Padding)
+	}
+	if maxW < minW {
+		return nil, image.Point{}, ErrAtlasTooLarge
+	}
+
+	step := max(1, (maxW-minW)/maxPackedWidths)
+
+	var (
+		best      []image.Point
+		bestSize  image.Point
+		bestScore = -1
+	)
+
+	for width := minW; width <= maxW; width += step {
+		positions, ok := packRects(padded, order, width, sumH)
+		if !ok {
+			continue
+		}
+
+		var size image.Point
+		for i, p := range positions {
+			size.X = max(size.X, p.X+padded[i].X-opts.Padding)
+			size.Y = max(size.Y, p.Y+padded[i].Y-opts.Padding)
+		}
+
+		scored := size
+		if opts.PowerOfTwo {
+			scored = image.Pt(nextPowerOfTwo(size.X), nextPowerOfTwo(size.Y))
+		}
+		if opts.MaxHeight > 0 && scored.Y > opts.MaxHeight {
+			continue
+		}
+
+		score := scored.X * scored.Y
+		if bestScore < 0 || score < bestScore ||
+			(score == bestScore && abs(scored.X-scored.Y) < abs(bestSize.X-bestSize.Y)) {
+			best, bestSize, bestScore = positions, scored, score
+		}
+	}
+
+	if best == nil {
+		return nil, image.Point{}, ErrAtlasTooLarge
+	}
+
+	var size image.Point
+	for i, p := range best {
+		size.X = max(size.X, p.X+padded[i].X-opts.Padding)
+		size.Y = max(size.Y, p.Y+padded[i].Y-opts.Padding)
+	}
+	return best, size, nil
+}
+
+// packRects places rects in given order into bin with MaxRects best short side fit
+func packRects(rects []image.Point, order []int, width, height int) ([]image.Point, bool) {
+	bin := &maxRects{free: []image.Rectangle{image.Rect(0, 0, width, height)}}
+	positions := make([]image.Point, len(rects))
+
+	for _, i := range order {
+		p, ok := bin.insert(rects[i])
+		if !ok {
+			return nil, false
+		}
+		positions[i] = p
+	}
+
+	return positions, true
+}
+
+type maxRects struct {
+	free []image.Rectangle
+}
+
+func (m *maxRects) insert(size image.Point) (image.Point, bool) {
+	bestIndex := -1
+	bestShort, bestLong := math.MaxInt, math.MaxInt
+
+	for i, free := range m.free {
+		dx, dy := free.Dx()-size.X, free.Dy()-size.Y
+		if dx < 0 || dy < 0 {
+			continue
+		}
+
+		short, long := min(dx, dy), max(dx, dy)
+		if short < bestShort || (short == bestShort && long < bestLong) {
+			bestIndex, bestShort, bestLong = i, short, long
+		}
+	}
+
+	if bestIndex < 0 {
+		return image.Point{}, false
+	}
+
+	placed := image.Rectangle{Min: m.free[bestIndex].Min, Max: m.free[bestIndex].Min.Add(size)}
+	m.split(placed)
+	return placed.Min, true
+}
+
+// split replaces free rects overlapped by placed rect with their remaining parts and drops contained ones
+func (m *maxRects) split(placed image.Rectangle) {
+	var free []image.Rectangle
+	for _, f := range m.free {
+		if !f.Overlaps(placed) {
+			free = append(free, f)
+			continue
+		}
+
+		if placed.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, placed.Min.X, f.Max.Y))
+		}
+		if placed.Max.X < f.Max.X {
+			free = append(free, image.Rect(placed.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if placed.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, placed.Min.Y))
+		}
+		if placed.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, placed.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+
+	m.free = m.free[:0]
+	for i, f := range free {
+		contained := false
+		for j, other := range free {
+			if i != j && f.In(other) && (f != other || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			m.free = append(m.free, f)
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,162 @@
+package packer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"slices"
+	"strconv"
+)
+
+// DataFormat is layout of frames in atlas metadata (as in Aseprite --format option)
+type DataFormat string
+
+const (
+	// DataHash stores frames in object keyed by frame name
+	DataHash DataFormat = "hash"
+	// DataArray stores frames in array with frame name in filename field
+	DataArray DataFormat = "array"
+)
+
+// MetadataApp is written to meta.app of atlas metadata
+const MetadataApp = "https://github.com/Spinozanilast/aseprite-assets-cli"
+
+func DataFormats() []string {
+	return []string{string(DataHash), string(DataArray)}
+}
+
+type rectData struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type sizeData struct {
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type frameData struct {
+	Filename         string   `json:"filename,omitempty"`
+	Frame            rectData `json:"frame"`
+	Rotated          bool     `json:"rotated"`
+	Trimmed          bool     `json:"trimmed"`
+	SpriteSourceSize rectData `json:"spriteSourceSize"`
+	SourceSize       sizeData `json:"sourceSize"`
+	Duration         int      `json:"duration"`
+}
+
+type frameTagData struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+	Repeat    string `json:"repeat,omitempty"`
+	Color     string `json:"color,omitempty"`
+}
+
+type metaData struct {
+	App       string         `json:"app"`
+	Image     string         `json:"image"`
+	Format    string         `json:"format"`
+	Size      sizeData       `json:"size"`
+	Scale     string         `json:"scale"`
+	FrameTags []frameTagData `json:"frameTags"`
+}
+
+type atlasData struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   metaData        `json:"meta"`
+}
+
+// Metadata returns atlas description in Aseprite JSON schema, imageName is written to meta.image
+func (a *Atlas) Metadata(format DataFormat, imageName string) ([]byte, error) {
+	if !slices.Contains(DataFormats(), string(format)) {
+		return nil, fmt.Errorf("unknown data format %q, available formats: %v", format, DataFormats())
+	}
+
+	frames := make([]frameData, len(a.Placements))
+	for i, p := range a.Placements {
+		frames[i] = frameData{
+			Frame:            toRectData(p.Rect),
+			Trimmed:          p.Trimmed,
+			SpriteSourceSize: toRectData(p.SourceRect),
+			SourceSize:       sizeData{W: p.SourceSize.X, H: p.SourceSize.Y},
+			Duration:         int(p.Frame.Duration.Milliseconds()),
+		}
+	}
+
+	var (
+		framesJSON []byte
+		err        error
+	)
+	if format == DataArray {
+		for i, p := range a.Placements {
+			frames[i].Filename = p.Frame.Name
+		}
+		framesJSON, err = json.Marshal(frames)
+	} else {
+		framesJSON, err = a.framesHash(frames)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]frameTagData, len(a.Tags))
+	for i, tag := range a.Tags {
+		tags[i] = frameTagData{Name: tag.Name, From: tag.From, To: tag.To, Direction: tag.Direction, Color: tag.Color}
+		// Aseprite writes repeat count as string and omits it for infinite loop
+		if tag.Repeat > 0 {
+			tags[i].Repeat = strconv.Itoa(tag.Repeat)
+		}
+	}
+
+	size := a.Image.Rect.Size()
+	data := atlasData{
+		Frames: framesJSON,
+		Meta: metaData{
+			App:       MetadataApp,
+			Image:     imageName,
+			Format:    "RGBA8888",
+			Size:      sizeData{W: size.X, H: size.Y},
+			Scale:     "1",
+			FrameTags: tags,
+		},
+	}
+
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// framesHash marshals frames to object keeping placements order (encoding/json sorts map keys)
+func (a *Atlas) framesHash(frames []frameData) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, frame := range frames {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(a.Placements[i].Frame.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(frame)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func toRectData(r image.Rectangle) rectData {
+	return rectData{X: r.Min.X, Y: r.Min.Y, W: r.Dx(), H: r.Dy()}
+}
@@ -0,0 +1,268 @@
+package packer
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"slices"
+	"time"
+)
+
+type Layout string
+
+const (
+	// LayoutRows places frames left to right in rows
+	LayoutRows Layout = "rows"
+	// LayoutColumns places frames top to bottom in columns
+	LayoutColumns Layout = "columns"
+	// LayoutPacked bin-packs frames into smallest atlas found (MaxRects)
+	LayoutPacked Layout = "packed"
+)
+
+func Layouts() []string {
+	return []string{string(LayoutRows), string(LayoutColumns), string(LayoutPacked)}
+}
+
+var (
+	ErrAtlasTooLarge = errors.New("frames do not fit into atlas max size")
+)
+
+// Frame is source image packed to atlas
+type Frame struct {
+	Name     string
+	Image    *image.NRGBA
+	Duration time.Duration
+}
+
+// FrameTag is animation over frames range (indices of packed frames)
+type FrameTag struct {
+	Name      string
+	From      int
+	To        int
+	Direction string
+	// Repeat is number of animation plays, 0 is infinite loop
+	Repeat int
+	Color  string
+}
+
+type Options struct {
+	Layout Layout
+	// Count is number of frames per row (rows layout) or column (columns layout), square grid if 0
+	Count int
+	// Padding is transparent space between frames
+	Padding int
+	// Extrude repeats frame border pixels around it to avoid texture bleeding
+	Extrude int
+	// Trim removes transparent frame borders, offsets are kept in placement source rect
+	Trim bool
+	// MergeDuplicates packs identical frames (after trim) once
+	MergeDuplicates bool
+	PowerOfTwo      bool
+	// MaxWidth and MaxHeight limit atlas size (0 is unlimited)
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Placement is frame position in atlas
+type Placement struct {
+	Frame Frame
+	// Rect is frame area in atlas (without extrusion)
+	Rect image.Rectangle
+	// SourceRect is area of source frame packed into Rect (smaller than source when trimmed)
+	SourceRect image.Rectangle
+	SourceSize image.Point
+	Trimmed    bool
+	// DuplicateOf is index of placement which image is shared by this one, -1 if none
+	DuplicateOf int
+}
+
+type Atlas struct {
+	Image      *image.NRGBA
+	Placements []Placement
+	Tags       []FrameTag
+}
+
+// Unique returns number of frames images stored in atlas
+func (a *Atlas) Unique() int {
+	unique := 0
+	for _, p := range a.Placements {
+		if p.DuplicateOf < 0 {
+			unique++
+		}
+	}
+	return unique
+}
+
+func (o Options) validate() error {
+	if !slices.Contains(Layouts(), string(o.Layout)) {
+		return fmt.Errorf("unknown layout %q, available layouts: %v", o.Layout, Layouts())
+	}
+	if o.Count < 0 || o.Padding < 0 || o.Extrude < 0 || o.MaxWidth < 0 || o.MaxHeight < 0 {
+		return errors.New("count, padding, extrude and max size cannot be negative")
+	}
+	return nil
+}
+
+// Pack places frames into atlas image, tags are copied to atlas as is
+func Pack(frames []Frame, tags []FrameTag, opts Options) (*Atlas, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, errors.New("no frames to pack")
+	}
+
+	names := make(map[string]bool, len(frames))
+	for _, frame := range frames {
+		if names[frame.Name] {
+			return nil, fmt.Errorf("frame name %q is used several times", frame.Name)
+		}
+		names[frame.Name] = true
+	}
+
+	atlas := &Atlas{Tags: tags}
+
+	// images are frames content after trim, placements refer to them by index
+	var (
+		images  []*image.NRGBA
+		imageOf []int
+	)
+	seen := make(map[[sha256.Size]byte]int)
+
+	for i, frame := range frames {
+		bounds := frame.Image.Bounds()
+		content := bounds
+		if opts.Trim {
+			content = opaqueBounds(frame.Image)
+		}
+
+		placement := Placement{
+			Frame:       frame,
+			SourceRect:  content.Sub(bounds.Min),
+			SourceSize:  bounds.Size(),
+			Trimmed:     content != bounds,
+			DuplicateOf: -1,
+		}
+
+		img := crop(frame.Image, content)
+		if opts.MergeDuplicates {
+			key := imageKey(img)
+			if first, found := seen[key]; found {
+				placement.DuplicateOf = first
+				atlas.Placements = append(atlas.Placements, placement)
+				imageOf = append(imageOf, imageOf[first])
+				continue
+			}
+			seen[key] = i
+		}
+
+		atlas.Placements = append(atlas.Placements, placement)
+		imageOf = append(imageOf, len(images))
+		images = append(images, img)
+	}
+
+	cells := make([]image.Point, len(images))
+	for i, img := range images {
+		cells[i] = img.Rect.Size().Add(image.Pt(opts.Extrude*2, opts.Extrude*2))
+	}
+
+	positions, size, err := layout(cells, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.PowerOfTwo {
+		size = image.Pt(nextPowerOfTwo(size.X), nextPowerOfTwo(size.Y))
+	}
+	if (opts.MaxWidth > 0 && size.X > opts.MaxWidth) || (opts.MaxHeight > 0 && size.Y > opts.MaxHeight) {
+		return nil, fmt.Errorf("%w: atlas needs %dx%d", ErrAtlasTooLarge, size.X, size.Y)
+	}
+
+	atlas.Image = image.NewNRGBA(image.Rectangle{Max: size})
+
+	rects := make([]image.Rectangle, len(images))
+	for i, img := range images {
+		origin := positions[i].Add(image.Pt(opts.Extrude, opts.Extrude))
+		rects[i] = image.Rectangle{Min: origin, Max: origin.Add(img.Rect.Size())}
+
+		draw.Draw(atlas.Image, rects[i], img, img.Rect.Min, draw.Src)
+		extrude(atlas.Image, rects[i], opts.Extrude)
+	}
+
+	for i := range atlas.Placements {
+		atlas.Placements[i].Rect = rects[imageOf[i]]
+	}
+
+	return atlas, nil
+}
+
+// opaqueBounds returns bounds of non transparent pixels, 1x1 rectangle at origin for empty image
+func opaqueBounds(img *image.NRGBA) image.Rectangle {
+	bounds := img.Bounds()
+	found := image.Rectangle{}
+	empty := true
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.NRGBAAt(x, y).A == 0 {
+				continue
+			}
+			pixel := image.Rect(x, y, x+1, y+1)
+			if empty {
+				found, empty = pixel, false
+			} else {
+				found = found.Union(pixel)
+			}
+		}
+	}
+
+	if empty {
+		return image.Rectangle{Min: bounds.Min, Max: bounds.Min.Add(image.Pt(1, 1))}
+	}
+	return found
+}
+
+// crop copies area of image to new image starting at origin
+func crop(img *image.NRGBA, area image.Rectangle) *image.NRGBA {
+	cropped := image.NewNRGBA(image.Rectangle{Max: area.Size()})
+	draw.Draw(cropped, cropped.Rect, img, area.Min, draw.Src)
+	return cropped
+}
+
+func imageKey(img *image.NRGBA) [sha256.Size]byte {
+	h := sha256.New()
+	fmt.Fprintf(h, "%dx%d:", img.Rect.Dx(), img.Rect.Dy())
+	h.Write(img.Pix)
+
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+// extrude repeats border pixels of rect n times outward
+func extrude(img *image.NRGBA, rect image.Rectangle, n int) {
+	if n == 0 {
+		return
+	}
+
+	for y := rect.Min.Y - n; y < rect.Max.Y+n; y++ {
+		for x := rect.Min.X - n; x < rect.Max.X+n; x++ {
+			if (image.Point{X: x, Y: y}).In(rect) {
+				continue
+			}
+			sx := min(max(x, rect.Min.X), rect.Max.X-1)
+			sy := min(max(y, rect.Min.Y), rect.Max.Y-1)
+			img.SetNRGBA(x, y, img.NRGBAAt(sx, sy))
+		}
+	}
+}
+
+func nextPowerOfTwo(v int) int {
+	p := 1
+	for p < v {
+		p <<= 1
+	}
+	return p
+}
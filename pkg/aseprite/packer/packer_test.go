@@ -0,0 +1,131 @@
+package packer_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/packer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// solidFrame returns frame of given size with opaque area filled by c
+func solidFrame(name string, size, opaque image.Rectangle, c color.NRGBA) packer.Frame {
+	img := image.NewNRGBA(size)
+	for y := opaque.Min.Y; y < opaque.Max.Y; y++ {
+		for x := opaque.Min.X; x < opaque.Max.X; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return packer.Frame{Name: name, Image: img, Duration: 100 * time.Millisecond}
+}
+
+func TestPackLayouts(t *testing.T) {
+	var frames []packer.Frame
+	for i, size := range []image.Point{{16, 16}, {8, 24}, {32, 8}, {12, 12}, {4, 4}} {
+		rect := image.Rectangle{Max: size}
+		frames = append(frames, solidFrame(string(rune('a'+i)), rect, rect, color.NRGBA{R: uint8(i * 40), A: 255}))
+	}
+
+	for _, layout := range packer.Layouts() {
+		atlas, err := packer.Pack(frames, nil, packer.Options{Layout: packer.Layout(layout), Padding: 2, Extrude: 1})
+		require.NoError(t, err, layout)
+		require.Len(t, atlas.Placements, len(frames))
+
+		for i, p := range atlas.Placements {
+			assert.True(t, p.Rect.Inset(-1).In(atlas.Image.Rect), "%s: frame %d outside atlas", layout, i)
+			assert.Equal(t, frames[i].Image.Rect.Size(), p.Rect.Size())
+
+			for j, other := range atlas.Placements[:i] {
+				assert.False(t, p.Rect.Inset(-2).Overlaps(other.Rect.Inset(-1)), "%s: frames %d and %d overlap", layout, i, j)
+			}
+		}
+	}
+
+	atlas, err := packer.Pack(frames, nil, packer.Options{Layout: packer.LayoutRows, Count: 2})
+	require.NoError(t, err)
+	assert.Equal(t, image.Pt(16, 0), atlas.Placements[1].Rect.Min)
+	assert.Equal(t, image.Pt(0, 24), atlas.Placements[2].Rect.Min)
+}
+
+func TestPackTrimAndMerge(t *testing.T) {
+	size := image.Rect(0, 0, 16, 16)
+	red := color.NRGBA{R: 255, A: 255}
+	frames := []packer.Frame{
+		solidFrame("a", size, image.Rect(4, 2, 8, 10), red),
+		solidFrame("b", size, image.Rect(1, 1, 5, 9), red),
+		solidFrame("c", size, image.Rectangle{}, red),
+	}
+
+	atlas, err := packer.Pack(frames, nil, packer.Options{Layout: packer.LayoutPacked, Trim: true, MergeDuplicates: true, PowerOfTwo: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, atlas.Unique())
+	assert.Equal(t, image.Rect(4, 2, 8, 10), atlas.Placements[0].SourceRect)
+	assert.Equal(t, image.Rect(1, 1, 5, 9), atlas.Placements[1].SourceRect)
+	assert.Equal(t, 0, atlas.Placements[1].DuplicateOf)
+	assert.Equal(t, atlas.Placements[0].Rect, atlas.Placements[1].Rect)
+	assert.Equal(t, image.Pt(1, 1), atlas.Placements[2].Rect.Size())
+	assert.True(t, atlas.Placements[0].Trimmed)
+
+	atlasSize := atlas.Image.Rect.Size()
+	assert.Equal(t, 0, atlasSize.X&(atlasSize.X-1), "width is not power of two")
+	assert.Equal(t, 0, atlasSize.Y&(atlasSize.Y-1), "height is not power of two")
+
+	_, err = packer.Pack(frames, nil, packer.Options{Layout: packer.LayoutRows, MaxWidth: 8, MaxHeight: 8})
+	assert.ErrorIs(t, err, packer.ErrAtlasTooLarge)
+
+	_, err = packer.Pack(append(frames, frames[0]), nil, packer.Options{Layout: packer.LayoutRows})
+	assert.Error(t, err, "duplicate frame names")
+}
+
+func TestAtlasMetadata(t *testing.T) {
+	rect := image.Rect(0, 0, 4, 4)
+	frames := []packer.Frame{
+		solidFrame("z 0.aseprite", rect, rect, color.NRGBA{A: 255}),
+		solidFrame("a 1.aseprite", rect, rect, color.NRGBA{G: 255, A: 255}),
+	}
+	tags := []packer.FrameTag{{Name: "idle", From: 0, To: 1, Direction: "forward"}}
+
+	atlas, err := packer.Pack(frames, tags, packer.Options{Layout: packer.LayoutRows})
+	require.NoError(t, err)
+
+	hash, err := atlas.Metadata(packer.DataHash, "atlas.png")
+	require.NoError(t, err)
+	// frames keep packing order instead of sorted keys
+	assert.Less(t, strings.Index(string(hash), "z 0.aseprite"), strings.Index(string(hash), "a 1.aseprite"))
+
+	var hashData struct {
+		Frames map[string]struct {
+			Frame    map[string]int `json:"frame"`
+			Duration int            `json:"duration"`
+		} `json:"frames"`
+		Meta struct {
+			Image     string           `json:"image"`
+			Size      map[string]int   `json:"size"`
+			FrameTags []map[string]any `json:"frameTags"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(hash, &hashData))
+	assert.Equal(t, map[string]int{"x": 4, "y": 0, "w": 4, "h": 4}, hashData.Frames["a 1.aseprite"].Frame)
+	assert.Equal(t, 100, hashData.Frames["a 1.aseprite"].Duration)
+	assert.Equal(t, "atlas.png", hashData.Meta.Image)
+	assert.Equal(t, map[string]int{"w": 8, "h": 4}, hashData.Meta.Size)
+	assert.Equal(t, "idle", hashData.Meta.FrameTags[0]["name"])
+
+	array, err := atlas.Metadata(packer.DataArray, "atlas.png")
+	require.NoError(t, err)
+
+	var arrayData struct {
+		Frames []struct {
+			Filename string `json:"filename"`
+		} `json:"frames"`
+	}
+	require.NoError(t, json.Unmarshal(array, &arrayData))
+	require.Len(t, arrayData.Frames, 2)
+	assert.Equal(t, "z 0.aseprite", arrayData.Frames[0].Filename)
+}
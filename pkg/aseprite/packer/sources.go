@@ -0,0 +1,102 @@
+package packer
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+// DefaultFrameDuration is duration of frames loaded from images
+const DefaultFrameDuration = 100 * time.Millisecond
+
+// SpriteFrames renders frames of sprite layers accepted by include (visible ones if nil), only frames of given tags if any.
+// Frames are named as Aseprite names them in sheet metadata ("hero 0.aseprite", "hero #walk 0.aseprite" for tags),
+// returned tags ranges are relative to returned frames
+func SpriteFrames(sprite *asefile.Sprite, filename string, tags []string, include func(*asefile.Layer) bool) ([]Frame, []FrameTag, error) {
+	title := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	extension := strings.TrimPrefix(filepath.Ext(filename), ".")
+
+	if len(tags) == 0 {
+		var frames []Frame
+		for i, f := range sprite.Frames {
+			name := fmt.Sprintf("%s %d.%s", title, i, extension)
+			if len(sprite.Frames) == 1 {
+				name = fmt.Sprintf("%s.%s", title, extension)
+			}
+			frames = append(frames, Frame{Name: name, Image: sprite.FrameImage(i, include), Duration: f.Duration})
+		}
+
+		var frameTags []FrameTag
+		for _, tag := range sprite.Tags {
+			// tags of damaged files could point past the last frame
+			if tag.From >= len(frames) {
+				continue
+			}
+			frameTags = append(frameTags, spriteTag(tag, tag.From, min(tag.To, len(frames)-1)))
+		}
+		return frames, frameTags, nil
+	}
+
+	var (
+		frames    []Frame
+		frameTags []FrameTag
+	)
+	for _, name := range tags {
+		tag := sprite.TagByName(name)
+		if tag == nil {
+			return nil, nil, fmt.Errorf("tag %q not found in sprite %s", name, filename)
+		}
+
+		from := len(frames)
+		for i := tag.From; i <= tag.To && i < len(sprite.Frames); i++ {
+			frames = append(frames, Frame{
+				Name:     fmt.Sprintf("%s #%s %d.%s", title, tag.Name, i, extension),
+				Image:    sprite.FrameImage(i, include),
+				Duration: sprite.Frames[i].Duration,
+			})
+		}
+		if len(frames) > from {
+			frameTags = append(frameTags, spriteTag(tag, from, len(frames)-1))
+		}
+	}
+
+	return frames, frameTags, nil
+}
+
+// ImageFrame loads image file (e.g. exported frame) as frame named by its filename
+func ImageFrame(filename string) (Frame, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Frame{}, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return Frame{}, fmt.Errorf("failed to decode image %s: %w", filename, err)
+	}
+
+	nrgba := image.NewNRGBA(image.Rectangle{Max: img.Bounds().Size()})
+	draw.Draw(nrgba, nrgba.Rect, img, img.Bounds().Min, draw.Src)
+
+	return Frame{Name: filepath.Base(filename), Image: nrgba, Duration: DefaultFrameDuration}, nil
+}
+
+func spriteTag(tag *asefile.Tag, from, to int) FrameTag {
+	return FrameTag{
+		Name: tag.Name,
+		From: from,
+		To:   to,
+		// Aseprite metadata uses underscore in direction names
+		Direction: strings.ReplaceAll(tag.Direction.String(), "-", "_"),
+		Repeat:    tag.Repeat,
+		Color:     fmt.Sprintf("#%02x%02x%02x%02x", tag.Color.R, tag.Color.G, tag.Color.B, tag.Color.A),
+	}
+}
@@ -0,0 +1,219 @@
+package palette
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// Metric is color distance used to find the nearest palette color
+type Metric string
+
+const (
+	// MetricRGB is euclidean distance of sRGB channels
+	MetricRGB Metric = "rgb"
+	// MetricCIE76 is euclidean distance in CIELAB (D65)
+	MetricCIE76 Metric = "cie76"
+	// MetricCIEDE2000 is CIEDE2000 color difference in CIELAB (D65)
+	MetricCIEDE2000 Metric = "ciede2000"
+	// MetricOKLab is euclidean distance in OKLab
+	MetricOKLab Metric = "oklab"
+)
+
+// Metrics returns names of supported color distance metrics
+func Metrics() []string {
+	return []string{string(MetricRGB), string(MetricCIE76), string(MetricCIEDE2000), string(MetricOKLab)}
+}
+
+// ParseMetric returns metric by its case insensitive name
+func ParseMetric(name string) (Metric, error) {
+	for _, metric := range Metrics() {
+		if strings.EqualFold(name, metric) {
+			return Metric(metric), nil
+		}
+	}
+	return "", fmt.Errorf("unknown color metric %q, supported metrics: %s", name, strings.Join(Metrics(), ", "))
+}
+
+// Distance returns distance of colors RGB by metric, alpha is not compared
+func Distance(metric Metric, a, b color.NRGBA) float64 {
+	d := metric.difference(metric.point(a), metric.point(b))
+	if metric == MetricCIEDE2000 {
+		return d
+	}
+	return math.Sqrt(d)
+}
+
+// Matcher finds the nearest opaque palette colors by metric, results are cached per color
+type Matcher struct {
+	metric  Metric
+	indices []int
+	points  [][3]float64
+	cache   map[color.NRGBA]int
+}
+
+// NewMatcher returns matcher of palette colors, fully transparent palette colors are never matched
+func NewMatcher(p *Palette, metric Metric) (*Matcher, error) {
+	m := &Matcher{metric: metric, cache: make(map[color.NRGBA]int)}
+	for i, c := range p.Colors {
+		if c.A == 0 {
+			continue
+		}
+		m.indices = append(m.indices, i)
+		m.points = append(m.points, metric.point(c))
+	}
+	if len(m.indices) == 0 {
+		return nil, fmt.Errorf("palette %s has no opaque colors", p.Name)
+	}
+	return m, nil
+}
+
+// Nearest returns index of palette color nearest to RGB of c, the first one of equally distant colors
+func (m *Matcher) Nearest(c color.NRGBA) int {
+	c = Opaque(c)
+	if index, found := m.cache[c]; found {
+		return index
+	}
+
+	point := m.metric.point(c)
+	best, bestDistance := 0, math.Inf(1)
+	for i, p := range m.points {
+		if d := m.metric.difference(point, p); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+
+	m.cache[c] = m.indices[best]
+	return m.indices[best]
+}
+
+// point converts color to coordinates of metric color space
+func (metric Metric) point(c color.NRGBA) [3]float64 {
+	switch metric {
+	case MetricCIE76, MetricCIEDE2000:
+		return lab(c)
+	case MetricOKLab:
+		return oklab(c)
+	default:
+		return [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+	}
+}
+
+// difference returns squared euclidean distance of points, or CIEDE2000 difference
+func (metric Metric) difference(a, b [3]float64) float64 {
+	if metric == MetricCIEDE2000 {
+		return ciede2000(a, b)
+	}
+	d0, d1, d2 := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return d0*d0 + d1*d1 + d2*d2
+}
+
+func linear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// lab converts sRGB color to CIELAB with D65 white point
+func lab(c color.NRGBA) [3]float64 {
+	r, g, b := linear(c.R), linear(c.G), linear(c.B)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// oklab converts sRGB color to OKLab
+func oklab(c color.NRGBA) [3]float64 {
+	r, g, b := linear(c.R), linear(c.G), linear(c.B)
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return [3]float64{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// ciede2000 returns CIEDE2000 difference of CIELAB colors with unit weighting factors
+func ciede2000(lab1, lab2 [3]float64) float64 {
+	l1, a1, b1 := lab1[0], lab1[1], lab1[2]
+	l2, a2, b2 := lab2[0], lab2[1], lab2[2]
+
+	c1, c2 := math.Hypot(a1, b1), math.Hypot(a2, b2)
+	cMean7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cMean7/(cMean7+math.Pow(25, 7))))
+
+	a1p, a2p := (1+g)*a1, (1+g)*a2
+	c1p, c2p := math.Hypot(a1p, b1), math.Hypot(a2p, b2)
+	h1p, h2p := hueAngle(b1, a1p), hueAngle(b2, a2p)
+
+	dLp := l2 - l1
+	dCp := c2p - c1p
+	var dhp float64
+	if c1p*c2p != 0 {
+		dhp = h2p - h1p
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(c1p*c2p) * math.Sin(radians(dhp/2))
+
+	lMean := (l1 + l2) / 2
+	cMeanP := (c1p + c2p) / 2
+	hMean := h1p + h2p
+	if c1p*c2p != 0 {
+		switch {
+		case math.Abs(h1p-h2p) <= 180:
+			hMean /= 2
+		case h1p+h2p < 360:
+			hMean = (hMean + 360) / 2
+		default:
+			hMean = (hMean - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos(radians(hMean-30)) + 0.24*math.Cos(radians(2*hMean)) +
+		0.32*math.Cos(radians(3*hMean+6)) - 0.20*math.Cos(radians(4*hMean-63))
+	dTheta := 30 * math.Exp(-math.Pow((hMean-275)/25, 2))
+	cMeanP7 := math.Pow(cMeanP, 7)
+	rc := 2 * math.Sqrt(cMeanP7/(cMeanP7+math.Pow(25, 7)))
+	lShift := (lMean - 50) * (lMean - 50)
+	sl := 1 + 0.015*lShift/math.Sqrt(20+lShift)
+	sc := 1 + 0.045*cMeanP
+	sh := 1 + 0.015*cMeanP*t
+	rt := -math.Sin(radians(2*dTheta)) * rc
+
+	dl, dc, dh := dLp/sl, dCp/sc, dHp/sh
+	return math.Sqrt(dl*dl + dc*dc + dh*dh + rt*dc*dh)
+}
+
+// hueAngle returns hue of a, b in degrees in range [0, 360)
+func hueAngle(b, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,84 @@
+package palette
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MaxIndexedColors is number of palette indices stored in 8 bits red channel of index texture
+const MaxIndexedColors = 256
+
+// LUT returns palette lookup texture with one row per palette, the first palette is base one and every
+// palette must have the same number of colors, so index of color in base palette selects its variant
+func LUT(palettes []*Palette) (*image.NRGBA, error) {
+	if len(palettes) == 0 {
+		return nil, fmt.Errorf("palette lookup texture needs at least one palette")
+	}
+
+	base := palettes[0]
+	if len(base.Colors) > MaxIndexedColors {
+		return nil, fmt.Errorf("base palette %s has %d colors, at most %d fit index texture", base.Name, len(base.Colors), MaxIndexedColors)
+	}
+
+	lut := image.NewNRGBA(image.Rect(0, 0, len(base.Colors), len(palettes)))
+	for y, p := range palettes {
+		if len(p.Colors) != len(base.Colors) {
+			return nil, fmt.Errorf("palette %s has %d colors, but base palette %s has %d", p.Name, len(p.Colors), base.Name, len(base.Colors))
+		}
+		for x, c := range p.Colors {
+			lut.SetNRGBA(x, y, c)
+		}
+	}
+	return lut, nil
+}
+
+// IndexImage converts image to index texture: red channel is index of pixel color in base palette and alpha
+// is pixel alpha, transparent pixels are left zero. Returns counts of opaque colors missing in palette
+func IndexImage(img image.Image, base *Palette) (*image.NRGBA, map[color.NRGBA]int) {
+	indices := base.Indices()
+	unmatched := make(map[color.NRGBA]int)
+
+	bounds := img.Bounds()
+	result := image.NewNRGBA(image.Rectangle{Max: bounds.Size()})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A == 0 {
+				continue
+			}
+
+			index, found := indices[Opaque(c)]
+			if !found {
+				unmatched[Opaque(c)]++
+				continue
+			}
+			result.SetNRGBA(x-bounds.Min.X, y-bounds.Min.Y, color.NRGBA{R: uint8(index), A: c.A})
+		}
+	}
+	return result, unmatched
+}
+
+// FormatCounts lists colors with their pixels counts from the most used ones, at most limit colors
+// are listed when limit is positive
+func FormatCounts(counts map[color.NRGBA]int, limit int) string {
+	colors := slices.SortedFunc(maps.Keys(counts), func(a, b color.NRGBA) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return strings.Compare(Hex(a), Hex(b))
+	})
+
+	var parts []string
+	for i, c := range colors {
+		if limit > 0 && i == limit {
+			parts = append(parts, fmt.Sprintf("and %d more", len(colors)-limit))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d px)", Hex(c), counts[c]))
+	}
+	return strings.Join(parts, ", ")
+}
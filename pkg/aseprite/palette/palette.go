@@ -0,0 +1,335 @@
+package palette
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// Palette is ordered list of colors loaded from palette file
+type Palette struct {
+	Name   string
+	Colors []color.NRGBA
+}
+
+// Extensions returns extensions of palette files which can be loaded
+func Extensions() []string {
+	return []string{".gpl", ".hex", ".pal", ".txt", ".act", ".png", ".gif", ".aseprite", ".ase"}
+}
+
+// Load reads palette file: GIMP (.gpl), hex lines (.hex), JASC (.pal), Paint.NET (.txt), Photoshop (.act),
+// image (.png, .gif, unique colors row by row) or sprite palette (.aseprite, .ase)
+func Load(filename string) (*Palette, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read palette: %w", err)
+	}
+
+	var colors []color.NRGBA
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gpl":
+		colors, err = parseGPL(data)
+	case ".hex":
+		colors, err = parseHex(data, 6)
+	case ".txt":
+		colors, err = parseHex(data, 8)
+	case ".pal":
+		colors, err = parseJASC(data)
+	case ".act":
+		colors, err = parseACT(data)
+	case ".png", ".gif":
+		colors, err = parseImage(data)
+	case ".aseprite", ".ase":
+		colors, err = parseSprite(data)
+	default:
+		return nil, fmt.Errorf("unsupported palette file %s, supported extensions: %s", filename, strings.Join(Extensions(), ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid palette %s: %w", filename, err)
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("palette %s has no colors", filename)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	return &Palette{Name: name, Colors: colors}, nil
+}
+
+// Find resolves palette file by path or by name (with or without extension) searched in palettes folders
+func Find(name string, folders []string) (string, error) {
+	if files.CheckFileExists(name, false) {
+		return name, nil
+	}
+
+	var available []string
+	for _, folder := range folders {
+		found, err := files.FindFilesOfExtensionsRecursiveFlatten(folder, Extensions()...)
+		if err != nil {
+			continue
+		}
+		for _, filename := range found {
+			base := filepath.Base(filename)
+			if strings.EqualFold(base, name) || strings.EqualFold(strings.TrimSuffix(base, filepath.Ext(base)), name) {
+				return filename, nil
+			}
+			available = append(available, base)
+		}
+	}
+
+	if len(available) == 0 {
+		return "", fmt.Errorf("palette %q not found, configured palettes folders have no palettes", name)
+	}
+	slices.Sort(available)
+	return "", fmt.Errorf("palette %q not found, available palettes: %s", name, strings.Join(slices.Compact(available), ", "))
+}
+
+// LoadAll resolves palettes by names in palettes folders and loads them
+func LoadAll(names []string, folders []string) ([]*Palette, error) {
+	var palettes []*Palette
+	for _, name := range names {
+		filename, err := Find(name, folders)
+		if err != nil {
+			return nil, err
+		}
+		p, err := Load(filename)
+		if err != nil {
+			return nil, err
+		}
+		palettes = append(palettes, p)
+	}
+	return palettes, nil
+}
+
+// Index returns index of the first palette color with RGB of c, alpha is not compared
+func (p *Palette) Index(c color.NRGBA) (int, bool) {
+	for i, pc := range p.Colors {
+		if pc.R == c.R && pc.G == c.G && pc.B == c.B {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Indices returns map of palette RGB colors (opaque) to indices of their first occurrence
+func (p *Palette) Indices() map[color.NRGBA]int {
+	indices := make(map[color.NRGBA]int, len(p.Colors))
+	for i, c := range p.Colors {
+		key := Opaque(c)
+		if _, found := indices[key]; !found {
+			indices[key] = i
+		}
+	}
+	return indices
+}
+
+// Opaque returns color with full alpha
+func Opaque(c color.NRGBA) color.NRGBA {
+	c.A = 255
+	return c
+}
+
+// Hex returns color as #rrggbb, or #rrggbbaa for translucent color
+func Hex(c color.NRGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+func parseGPL(data []byte) ([]color.NRGBA, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	if !scanner.Scan() || strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff")) != "GIMP Palette" {
+		return nil, errors.New(`missing "GIMP Palette" header`)
+	}
+
+	alpha := false
+	var colors []color.NRGBA
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, found := strings.Cut(line, ":"); found && !startsWithDigit(key) {
+			// aseprite writes translucent palettes with "Channels: RGBA"
+			if strings.TrimSpace(key) == "Channels" {
+				alpha = strings.TrimSpace(value) == "RGBA"
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		channels := 3
+		if alpha {
+			channels = 4
+		}
+		if len(fields) < channels {
+			return nil, fmt.Errorf("invalid color line %q", line)
+		}
+
+		values, err := parseChannels(fields[:channels])
+		if err != nil {
+			return nil, err
+		}
+		c := color.NRGBA{R: values[0], G: values[1], B: values[2], A: 255}
+		if alpha {
+			c.A = values[3]
+		}
+		colors = append(colors, c)
+	}
+	return colors, scanner.Err()
+}
+
+// parseHex parses color per line as rrggbb (.hex) or aarrggbb (Paint.NET .txt, ; starts comment)
+func parseHex(data []byte, digits int) ([]color.NRGBA, error) {
+	var colors []color.NRGBA
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "\ufeff")
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "#")
+		v, err := strconv.ParseUint(line, 16, 32)
+		if len(line) != digits || err != nil {
+			return nil, fmt.Errorf("invalid color %q", line)
+		}
+
+		if digits == 6 {
+			colors = append(colors, color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255})
+		} else {
+			colors = append(colors, color.NRGBA{A: uint8(v >> 24), R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)})
+		}
+	}
+	return colors, nil
+}
+
+func parseJASC(data []byte) ([]color.NRGBA, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\n")
+	if len(lines) < 3 || strings.TrimSpace(lines[0]) != "JASC-PAL" {
+		return nil, errors.New(`missing "JASC-PAL" header (binary RIFF palettes are not supported)`)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(lines[2]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid colors count %q", lines[2])
+	}
+
+	var colors []color.NRGBA
+	for _, line := range lines[3:] {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid color line %q", line)
+		}
+
+		values, err := parseChannels(fields)
+		if err != nil {
+			return nil, err
+		}
+		c := color.NRGBA{R: values[0], G: values[1], B: values[2], A: 255}
+		if len(values) > 3 {
+			c.A = values[3]
+		}
+		colors = append(colors, c)
+	}
+
+	if len(colors) != count {
+		return nil, fmt.Errorf("palette declares %d colors, but has %d", count, len(colors))
+	}
+	return colors, nil
+}
+
+// parseACT parses 256 RGB colors with optional colors count and transparent index
+func parseACT(data []byte) ([]color.NRGBA, error) {
+	if len(data) != 768 && len(data) != 772 {
+		return nil, fmt.Errorf("invalid size %d, expected 768 or 772 bytes", len(data))
+	}
+
+	count, transparent := 256, -1
+	if len(data) == 772 {
+		count = int(data[768])<<8 | int(data[769])
+		if t := int(data[770])<<8 | int(data[771]); t != 0xffff {
+			transparent = t
+		}
+	}
+
+	var colors []color.NRGBA
+	for i := range min(count, 256) {
+		c := color.NRGBA{R: data[i*3], G: data[i*3+1], B: data[i*3+2], A: 255}
+		if i == transparent {
+			c.A = 0
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+func parseImage(data []byte) ([]color.NRGBA, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var colors []color.NRGBA
+	seen := make(map[color.NRGBA]bool)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if !seen[c] {
+				seen[c] = true
+				colors = append(colors, c)
+			}
+		}
+	}
+	return colors, nil
+}
+
+func parseSprite(data []byte) ([]color.NRGBA, error) {
+	sprite, err := asefile.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var colors []color.NRGBA
+	for _, entry := range sprite.Palette.Entries {
+		colors = append(colors, entry.Color)
+	}
+	return colors, nil
+}
+
+func parseChannels(fields []string) ([]uint8, error) {
+	var values []uint8
+	for _, field := range fields {
+		v, err := strconv.ParseUint(field, 10, 8)
+		if err != nil {
+			// the rest of line is color name
+			break
+		}
+		values = append(values, uint8(v))
+	}
+	if len(values) < 3 {
+		return nil, fmt.Errorf("invalid color channels %q", strings.Join(fields, " "))
+	}
+	return values, nil
+}
+
+func startsWithDigit(value string) bool {
+	value = strings.TrimSpace(value)
+	return value != "" && value[0] >= '0' && value[0] <= '9'
+}
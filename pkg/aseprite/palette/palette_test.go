@@ -0,0 +1,161 @@
+package palette_test
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red   = color.NRGBA{R: 255, A: 255}
+	green = color.NRGBA{G: 255, A: 255}
+	blue  = color.NRGBA{B: 255, A: 255}
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []color.NRGBA
+	}{
+		{"base.gpl", "GIMP Palette\nName: base\nColumns: 2\n#\n255   0   0\tRed\n  0 255   0 Green\n", []color.NRGBA{red, green}},
+		{"alpha.gpl", "GIMP Palette\nChannels: RGBA\n#\n255 0 0 128 Red\n", []color.NRGBA{{R: 255, A: 128}}},
+		{"lines.hex", "ff0000\n#00ff00\n\n0000ff\n", []color.NRGBA{red, green, blue}},
+		{"paint.txt", "; paint.net palette\nFFFF0000\n8000FF00\n", []color.NRGBA{red, {G: 255, A: 128}}},
+		{"jasc.pal", "JASC-PAL\r\n0100\r\n2\r\n255 0 0\r\n0 0 255\r\n", []color.NRGBA{red, blue}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := palette.Load(writeFile(t, dir, tt.name, tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p.Colors)
+		})
+	}
+
+	act := make([]byte, 772)
+	copy(act, []byte{255, 0, 0, 0, 0, 255})
+	act[769], act[770], act[771] = 2, 0xff, 0xff
+	p, err := palette.Load(writeFile(t, dir, "photoshop.act", string(act)))
+	require.NoError(t, err)
+	assert.Equal(t, "photoshop", p.Name)
+	assert.Equal(t, []color.NRGBA{red, blue}, p.Colors)
+
+	_, err = palette.Load(writeFile(t, dir, "broken.gpl", "255 0 0\n"))
+	assert.ErrorContains(t, err, "GIMP Palette")
+	_, err = palette.Load(writeFile(t, dir, "short.pal", "JASC-PAL\n0100\n3\n255 0 0\n"))
+	assert.ErrorContains(t, err, "declares 3 colors")
+	_, err = palette.Load(writeFile(t, dir, "empty.hex", "\n"))
+	assert.ErrorContains(t, err, "no colors")
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	filename := writeFile(t, dir, "teams/red.gpl", "GIMP Palette\n255 0 0\n")
+	writeFile(t, dir, "blue.hex", "0000ff\n")
+
+	found, err := palette.Find("red", []string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, filename, found)
+
+	found, err = palette.Find("RED.gpl", []string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, filename, found)
+
+	found, err = palette.Find(filename, nil)
+	require.NoError(t, err)
+	assert.Equal(t, filename, found)
+
+	_, err = palette.Find("green", []string{dir})
+	assert.ErrorContains(t, err, "available palettes: blue.hex, red.gpl")
+}
+
+func TestLUT(t *testing.T) {
+	base := &palette.Palette{Name: "base", Colors: []color.NRGBA{red, green}}
+	variant := &palette.Palette{Name: "variant", Colors: []color.NRGBA{blue, red}}
+
+	lut, err := palette.LUT([]*palette.Palette{base, variant})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 2), lut.Rect)
+	assert.Equal(t, green, lut.NRGBAAt(1, 0))
+	assert.Equal(t, blue, lut.NRGBAAt(0, 1))
+
+	_, err = palette.LUT([]*palette.Palette{base, {Name: "short", Colors: []color.NRGBA{red}}})
+	assert.ErrorContains(t, err, "short has 1 colors")
+}
+
+func TestIndexImage(t *testing.T) {
+	base := &palette.Palette{Name: "base", Colors: []color.NRGBA{red, green, green}}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{G: 255, A: 128})
+	img.SetNRGBA(1, 0, red)
+	img.SetNRGBA(2, 0, blue)
+
+	index, unmatched := palette.IndexImage(img, base)
+	assert.Equal(t, color.NRGBA{R: 1, A: 128}, index.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{R: 0, A: 255}, index.NRGBAAt(1, 0))
+	assert.Equal(t, color.NRGBA{}, index.NRGBAAt(3, 0))
+	assert.Equal(t, map[color.NRGBA]int{blue: 1}, unmatched)
+
+	assert.Equal(t, "#0000ff (2 px), #00ff00 (1 px), and 1 more",
+		palette.FormatCounts(map[color.NRGBA]int{red: 1, blue: 2, green: 1}, 2))
+}
+
+func TestParseMetric(t *testing.T) {
+	metric, err := palette.ParseMetric("OKLab")
+	require.NoError(t, err)
+	assert.Equal(t, palette.MetricOKLab, metric)
+
+	_, err = palette.ParseMetric("hsv")
+	assert.ErrorContains(t, err, "rgb, cie76, ciede2000, oklab")
+}
+
+func TestDistance(t *testing.T) {
+	black, white := color.NRGBA{A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+	tests := []struct {
+		metric palette.Metric
+		want   float64
+	}{
+		{palette.MetricRGB, 441.67},
+		{palette.MetricCIE76, 100},
+		{palette.MetricCIEDE2000, 100},
+		{palette.MetricOKLab, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.metric), func(t *testing.T) {
+			assert.InDelta(t, tt.want, palette.Distance(tt.metric, black, white), 0.01)
+			assert.Zero(t, palette.Distance(tt.metric, red, color.NRGBA{R: 255, A: 128}))
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	dark := color.NRGBA{R: 128, A: 255}
+	p := &palette.Palette{Name: "base", Colors: []color.NRGBA{{}, red, dark, blue, red}}
+
+	m, err := palette.NewMatcher(p, palette.MetricCIEDE2000)
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Nearest(color.NRGBA{R: 230, G: 20, B: 20, A: 255}))
+	assert.Equal(t, 2, m.Nearest(color.NRGBA{A: 255}), "transparent color is never matched")
+	assert.Equal(t, 3, m.Nearest(color.NRGBA{G: 40, B: 200, A: 64}))
+
+	_, err = palette.NewMatcher(&palette.Palette{Name: "empty", Colors: []color.NRGBA{{}}}, palette.MetricRGB)
+	assert.ErrorContains(t, err, "empty has no opaque colors")
+}
@@ -0,0 +1,149 @@
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Outline surrounds opaque pixels (alpha > 0) with outline of color, canvas grows by thickness on every side.
+// Outline grows one pixel per step to 4 neighbours (plus shape) or to 8 neighbours with square,
+// existing pixels are never changed
+func Outline(img *image.NRGBA, c color.NRGBA, thickness int, square bool) *image.NRGBA {
+	result := expand(img, thickness, thickness, thickness, thickness)
+	size := result.Rect.Size()
+
+	// mask marks pixels covered by image or outline drawn on previous steps
+	mask := make([]bool, size.X*size.Y)
+	for i := range mask {
+		mask[i] = result.Pix[i*4+3] != 0
+	}
+
+	neighbours := []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	if square {
+		neighbours = append(neighbours, image.Point{-1, -1}, image.Point{1, -1}, image.Point{-1, 1}, image.Point{1, 1})
+	}
+
+	for range thickness {
+		grown := make([]bool, len(mask))
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				if mask[y*size.X+x] {
+					continue
+				}
+				for _, n := range neighbours {
+					nx, ny := x+n.X, y+n.Y
+					if nx >= 0 && ny >= 0 && nx < size.X && ny < size.Y && mask[ny*size.X+nx] {
+						grown[y*size.X+x] = true
+						break
+					}
+				}
+			}
+		}
+
+		for i, g := range grown {
+			if g {
+				mask[i] = true
+				result.SetNRGBA(i%size.X, i/size.X, c)
+			}
+		}
+	}
+
+	return result
+}
+
+// Shadow draws silhouette of opaque pixels in color shifted by offset under image, canvas grows to fit shadow.
+// Silhouette is hard-edged, only pixels of shadow under semi-transparent image pixels are blended
+func Shadow(img *image.NRGBA, offset image.Point, c color.NRGBA) *image.NRGBA {
+	result := expand(img, max(-offset.X, 0), max(-offset.Y, 0), max(offset.X, 0), max(offset.Y, 0))
+	origin := image.Pt(max(-offset.X, 0), max(-offset.Y, 0))
+	size := img.Rect.Size()
+
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if img.NRGBAAt(img.Rect.Min.X+x, img.Rect.Min.Y+y).A == 0 {
+				continue
+			}
+			p := origin.Add(offset).Add(image.Pt(x, y))
+			result.SetNRGBA(p.X, p.Y, over(result.NRGBAAt(p.X, p.Y), c))
+		}
+	}
+
+	return result
+}
+
+// Trim crops image to bounds of pixels with alpha > 0, fully transparent image is kept as is
+func Trim(img *image.NRGBA) *image.NRGBA {
+	bounds := image.Rectangle{}
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.NRGBAAt(x, y).A != 0 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if bounds.Empty() {
+		return img
+	}
+
+	result := image.NewNRGBA(image.Rectangle{Max: bounds.Size()})
+	draw.Draw(result, result.Rect, img, bounds.Min, draw.Src)
+	return result
+}
+
+// PadToMultiple adds transparent pixels on right and bottom (or around with center) so that
+// image width and height are multiples of width and height
+func PadToMultiple(img *image.NRGBA, width, height int, center bool) *image.NRGBA {
+	size := img.Rect.Size()
+	padX := (width - size.X%width) % width
+	padY := (height - size.Y%height) % height
+	if !center {
+		return expand(img, 0, 0, padX, padY)
+	}
+	return expand(img, padX/2, padY/2, padX-padX/2, padY-padY/2)
+}
+
+// Extrude repeats edge pixels of image outwards by pixels on every side (e.g. against atlas bleeding)
+func Extrude(img *image.NRGBA, pixels int) *image.NRGBA {
+	result := expand(img, pixels, pixels, pixels, pixels)
+	size := img.Rect.Size()
+	if size.X == 0 || size.Y == 0 {
+		return result
+	}
+
+	resultSize := result.Rect.Size()
+	for y := 0; y < resultSize.Y; y++ {
+		for x := 0; x < resultSize.X; x++ {
+			sx := min(max(x-pixels, 0), size.X-1)
+			sy := min(max(y-pixels, 0), size.Y-1)
+			result.SetNRGBA(x, y, img.NRGBAAt(img.Rect.Min.X+sx, img.Rect.Min.Y+sy))
+		}
+	}
+	return result
+}
+
+// expand returns copy of image with transparent margins
+func expand(img *image.NRGBA, left, top, right, bottom int) *image.NRGBA {
+	size := img.Rect.Size()
+	result := image.NewNRGBA(image.Rect(0, 0, size.X+left+right, size.Y+top+bottom))
+	draw.Draw(result, image.Rectangle{Min: image.Pt(left, top), Max: image.Pt(left+size.X, top+size.Y)}, img, img.Rect.Min, draw.Src)
+	return result
+}
+
+// over puts top pixel over bottom one in straight (not premultiplied) alpha,
+// opaque and fully transparent top pixels are returned exactly
+func over(top, bottom color.NRGBA) color.NRGBA {
+	switch {
+	case top.A == 255 || bottom.A == 0:
+		return top
+	case top.A == 0:
+		return bottom
+	}
+
+	ta, ba := float64(top.A)/255, float64(bottom.A)/255
+	a := ta + ba*(1-ta)
+	mix := func(t, b uint8) uint8 {
+		return uint8((float64(t)*ta+float64(b)*ba*(1-ta))/a + 0.5)
+	}
+	return color.NRGBA{R: mix(top.R, bottom.R), G: mix(top.G, bottom.G), B: mix(top.B, bottom.B), A: uint8(a*255 + 0.5)}
+}
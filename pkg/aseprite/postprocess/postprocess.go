@@ -0,0 +1,243 @@
+package postprocess
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// Operation is one step of post-processing pipeline parsed from spec "<name>[:<arg>[:<arg>...]]"
+type Operation struct {
+	// Spec is operation as given by user, it identifies operation in build cache
+	Spec  string
+	apply func(img *image.NRGBA) *image.NRGBA
+}
+
+// Pipeline is chain of operations applied in order
+type Pipeline []Operation
+
+// Operations returns names of supported operations with their specs syntax
+func Operations() []string {
+	return []string{
+		"outline[:<color>[:<thickness>[:square]]]",
+		"shadow[:<dx>,<dy>[:<color>]]",
+		"trim",
+		"pad:<N>|<W>x<H>[:center]",
+		"extrude[:<pixels>]",
+	}
+}
+
+func operationsNames() []string {
+	return []string{"outline", "shadow", "trim", "pad", "extrude"}
+}
+
+// Parse parses operations specs, e.g. "trim", "outline:#000:1", "pad:8"
+func Parse(specs []string) (Pipeline, error) {
+	var pipeline Pipeline
+	for _, spec := range specs {
+		op, err := parseOperation(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, fmt.Errorf("invalid post operation %q: %w", spec, err)
+		}
+		pipeline = append(pipeline, op)
+	}
+	return pipeline, nil
+}
+
+func parseOperation(spec string) (Operation, error) {
+	name, rest, _ := strings.Cut(spec, ":")
+	var args []string
+	if rest != "" {
+		args = strings.Split(rest, ":")
+	}
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	op := Operation{Spec: spec}
+	switch name {
+	case "outline":
+		if len(args) > 3 {
+			return op, fmt.Errorf("too many arguments, expected %s", Operations()[0])
+		}
+		c, err := ParseColor(arg(0), color.NRGBA{A: 255})
+		if err != nil {
+			return op, err
+		}
+		thickness, err := parseCount(arg(1), 1)
+		if err != nil {
+			return op, fmt.Errorf("invalid thickness: %w", err)
+		}
+		if arg(2) != "" && arg(2) != "square" {
+			return op, fmt.Errorf("unknown outline shape %q, only square is supported (plus shape by default)", arg(2))
+		}
+		square := arg(2) == "square"
+		op.apply = func(img *image.NRGBA) *image.NRGBA { return Outline(img, c, thickness, square) }
+	case "shadow":
+		if len(args) > 2 {
+			return op, fmt.Errorf("too many arguments, expected %s", Operations()[1])
+		}
+		offset := image.Pt(1, 1)
+		if arg(0) != "" {
+			x, y, found := strings.Cut(arg(0), ",")
+			dx, errX := strconv.Atoi(x)
+			dy, errY := strconv.Atoi(y)
+			if !found || errX != nil || errY != nil {
+				return op, fmt.Errorf("invalid shadow offset %q, expected <dx>,<dy>", arg(0))
+			}
+			offset = image.Pt(dx, dy)
+		}
+		c, err := ParseColor(arg(1), color.NRGBA{A: 128})
+		if err != nil {
+			return op, err
+		}
+		op.apply = func(img *image.NRGBA) *image.NRGBA { return Shadow(img, offset, c) }
+	case "trim":
+		if len(args) > 0 {
+			return op, fmt.Errorf("trim has no arguments")
+		}
+		op.apply = Trim
+	case "pad":
+		if len(args) == 0 || len(args) > 2 {
+			return op, fmt.Errorf("expected %s", Operations()[3])
+		}
+		w, h, found := strings.Cut(strings.ToLower(arg(0)), "x")
+		if !found {
+			h = w
+		}
+		width, errW := parseCount(w, 0)
+		height, errH := parseCount(h, 0)
+		if errW != nil || errH != nil || width == 0 || height == 0 {
+			return op, fmt.Errorf("invalid pad multiple %q, expected N or WxH", arg(0))
+		}
+		if arg(1) != "" && arg(1) != "center" {
+			return op, fmt.Errorf("unknown pad alignment %q, only center is supported (right and bottom by default)", arg(1))
+		}
+		center := arg(1) == "center"
+		op.apply = func(img *image.NRGBA) *image.NRGBA { return PadToMultiple(img, width, height, center) }
+	case "extrude":
+		if len(args) > 1 {
+			return op, fmt.Errorf("too many arguments, expected %s", Operations()[4])
+		}
+		pixels, err := parseCount(arg(0), 1)
+		if err != nil {
+			return op, fmt.Errorf("invalid extrude pixels: %w", err)
+		}
+		op.apply = func(img *image.NRGBA) *image.NRGBA { return Extrude(img, pixels) }
+	default:
+		return op, fmt.Errorf("unknown operation %q, available operations: %s", name, strings.Join(operationsNames(), ", "))
+	}
+
+	return op, nil
+}
+
+// Apply runs every operation on image, result may have different size
+func (p Pipeline) Apply(img *image.NRGBA) *image.NRGBA {
+	for _, op := range p {
+		img = op.apply(img)
+	}
+	return img
+}
+
+// Specs returns operations specs in order
+func (p Pipeline) Specs() []string {
+	var specs []string
+	for _, op := range p {
+		specs = append(specs, op.Spec)
+	}
+	return specs
+}
+
+// SupportsFormat reports whether outputs of format (extension) can be post-processed
+func SupportsFormat(format string) bool {
+	return slices.Contains([]string{".png"}, strings.ToLower(files.PrefExtension(format)))
+}
+
+// ApplyFile post-processes png file in place
+func (p Pipeline) ApplyFile(filename string) error {
+	if len(p) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filename, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, p.Apply(ToNRGBA(img))); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filename, err)
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// ToNRGBA converts image to NRGBA placed at origin, colors are converted without premultiplication
+// when image stores straight alpha (NRGBA or paletted image)
+func ToNRGBA(img image.Image) *image.NRGBA {
+	bounds := img.Bounds()
+	if nrgba, ok := img.(*image.NRGBA); ok && bounds.Min == (image.Point{}) {
+		return nrgba
+	}
+
+	result := image.NewNRGBA(image.Rectangle{Max: bounds.Size()})
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			result.SetNRGBA(x-bounds.Min.X, y-bounds.Min.Y, c)
+		}
+	}
+	return result
+}
+
+// ParseColor parses #rgb, #rgba, #rrggbb or #rrggbbaa color, empty value gives fallback
+func ParseColor(value string, fallback color.NRGBA) (color.NRGBA, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		var expanded strings.Builder
+		for _, digit := range hex {
+			expanded.WriteString(strings.Repeat(string(digit), 2))
+		}
+		hex = expanded.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #rgb, #rgba, #rrggbb or #rrggbbaa", value)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// parseCount parses positive number, empty value gives fallback
+func parseCount(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not positive number", value)
+	}
+	return n, nil
+}
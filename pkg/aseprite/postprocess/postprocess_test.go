@@ -0,0 +1,83 @@
+package postprocess_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red   = color.NRGBA{R: 255, A: 255}
+	black = color.NRGBA{A: 255}
+)
+
+// testImage returns 4x3 image with one red pixel at (1, 1)
+func testImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	img.SetNRGBA(1, 1, red)
+	return img
+}
+
+func TestParse(t *testing.T) {
+	pipeline, err := postprocess.Parse([]string{"trim", "outline:#000:2:square", "shadow:1,-1:#00000080", "pad:8x4:center", "extrude"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trim", "outline:#000:2:square", "shadow:1,-1:#00000080", "pad:8x4:center", "extrude"}, pipeline.Specs())
+
+	for _, spec := range []string{"blur", "outline:red", "outline:#000:0", "pad", "pad:8:left", "shadow:1", "trim:1", "extrude:-1"} {
+		_, err := postprocess.Parse([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline, err := postprocess.Parse([]string{"trim", "outline"})
+	require.NoError(t, err)
+
+	img := pipeline.Apply(testImage())
+	require.Equal(t, image.Rect(0, 0, 3, 3), img.Rect)
+	assert.Equal(t, red, img.NRGBAAt(1, 1))
+	// plus shaped outline leaves corners transparent
+	assert.Equal(t, black, img.NRGBAAt(1, 0))
+	assert.Equal(t, black, img.NRGBAAt(0, 1))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(0, 0))
+
+	img = postprocess.Outline(postprocess.Trim(testImage()), black, 1, true)
+	assert.Equal(t, black, img.NRGBAAt(0, 0))
+}
+
+func TestShadow(t *testing.T) {
+	shadow := color.NRGBA{B: 255, A: 128}
+	img := postprocess.Shadow(testImage(), image.Pt(1, -1), shadow)
+	require.Equal(t, image.Rect(0, 0, 5, 4), img.Rect)
+	// image is moved down by one pixel to fit shadow above it
+	assert.Equal(t, red, img.NRGBAAt(1, 2))
+	assert.Equal(t, shadow, img.NRGBAAt(2, 1))
+
+	// shadow under semi-transparent pixel is blended in straight alpha
+	semi := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	semi.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 128})
+	semi.SetNRGBA(1, 0, color.NRGBA{R: 255, A: 128})
+	img = postprocess.Shadow(semi, image.Pt(1, 0), color.NRGBA{B: 255, A: 255})
+	assert.Equal(t, color.NRGBA{R: 255, A: 128}, img.NRGBAAt(0, 0))
+	assert.Equal(t, color.NRGBA{R: 128, B: 127, A: 255}, img.NRGBAAt(1, 0))
+}
+
+func TestPadAndExtrude(t *testing.T) {
+	img := postprocess.PadToMultiple(testImage(), 8, 8, false)
+	require.Equal(t, image.Rect(0, 0, 8, 8), img.Rect)
+	assert.Equal(t, red, img.NRGBAAt(1, 1))
+
+	img = postprocess.PadToMultiple(testImage(), 3, 5, true)
+	require.Equal(t, image.Rect(0, 0, 6, 5), img.Rect)
+	assert.Equal(t, red, img.NRGBAAt(2, 2))
+
+	img = postprocess.Extrude(postprocess.Trim(testImage()), 2)
+	require.Equal(t, image.Rect(0, 0, 5, 5), img.Rect)
+	for _, p := range []image.Point{{0, 0}, {4, 4}, {2, 0}} {
+		assert.Equal(t, red, img.NRGBAAt(p.X, p.Y))
+	}
+}
@@ -0,0 +1,140 @@
+package recolor
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+)
+
+// Mapping maps colors of source palette to colors of target palette with the same index
+type Mapping struct {
+	Source  *palette.Palette
+	Target  *palette.Palette
+	indices map[color.NRGBA]int
+}
+
+// NewMapping returns mapping of source palette to target palette, palettes must have the same number of colors
+func NewMapping(source, target *palette.Palette) (*Mapping, error) {
+	if len(source.Colors) != len(target.Colors) {
+		return nil, fmt.Errorf("palette %s has %d colors, but source palette %s has %d", target.Name, len(target.Colors), source.Name, len(source.Colors))
+	}
+	return &Mapping{Source: source, Target: target, indices: source.Indices()}, nil
+}
+
+// Map returns target color of the first source color with RGB of c, alpha of c is multiplied by target alpha
+func (m *Mapping) Map(c color.NRGBA) (color.NRGBA, bool) {
+	index, found := m.indices[palette.Opaque(c)]
+	if !found {
+		return c, false
+	}
+	return m.at(index, c.A), true
+}
+
+func (m *Mapping) at(index int, alpha uint8) color.NRGBA {
+	target := m.Target.Colors[index]
+	target.A = uint8(int(target.A) * int(alpha) / 255)
+	return target
+}
+
+// Report counts recolored pixels of sprite cels and tiles, Unmatched are colors missing in source palette
+// with their pixels counts
+type Report struct {
+	Recolored int
+	Unmatched map[color.NRGBA]int
+}
+
+// UnmatchedPixels returns number of pixels with colors missing in source palette
+func (r Report) UnmatchedPixels() int {
+	var count int
+	for _, n := range r.Unmatched {
+		count += n
+	}
+	return count
+}
+
+// Sprite recolors sprite in place. Indexed sprite keeps its pixels and gets palette entries mapped by index
+// when entry matches source color of the same index or by color otherwise, RGB sprite pixels are mapped by color.
+// Transparent pixels are not counted
+func Sprite(sprite *asefile.Sprite, m *Mapping) (Report, error) {
+	report := Report{Unmatched: make(map[color.NRGBA]int)}
+
+	switch sprite.ColorDepth {
+	case asefile.ColorDepthIndexed:
+		matched := m.mapPalette(sprite, true)
+		eachPixels(sprite, func(pixels []byte, opaqueIndex bool) []byte {
+			for _, index := range pixels {
+				if index == sprite.TransparentIndex && !opaqueIndex {
+					continue
+				}
+				if matched[int(index)] {
+					report.Recolored++
+				} else if c := sprite.ColorAt([]byte{index}, opaqueIndex); c.A > 0 {
+					report.Unmatched[palette.Opaque(c)]++
+				}
+			}
+			return pixels
+		})
+	case asefile.ColorDepthRGBA:
+		m.mapPalette(sprite, false)
+		eachPixels(sprite, func(pixels []byte, _ bool) []byte {
+			for i := 0; i+3 < len(pixels); i += 4 {
+				c := color.NRGBA{R: pixels[i], G: pixels[i+1], B: pixels[i+2], A: pixels[i+3]}
+				if c.A == 0 {
+					continue
+				}
+
+				mapped, found := m.Map(c)
+				if !found {
+					report.Unmatched[palette.Opaque(c)]++
+					continue
+				}
+				pixels[i], pixels[i+1], pixels[i+2], pixels[i+3] = mapped.R, mapped.G, mapped.B, mapped.A
+				report.Recolored++
+			}
+			return pixels
+		})
+	default:
+		return report, fmt.Errorf("recolor works on indexed and RGB sprites, sprite is %s", sprite.ColorDepth)
+	}
+
+	return report, nil
+}
+
+// mapPalette maps sprite palette entries and returns indices of mapped ones, entries matching source color
+// of the same index are mapped by index when byIndex is set
+func (m *Mapping) mapPalette(sprite *asefile.Sprite, byIndex bool) map[int]bool {
+	matched := make(map[int]bool)
+	for i := range sprite.Palette.Entries {
+		entry := &sprite.Palette.Entries[i]
+		if byIndex && i < len(m.Source.Colors) && palette.Opaque(m.Source.Colors[i]) == palette.Opaque(entry.Color) {
+			entry.Color = m.at(i, entry.Color.A)
+			matched[i] = true
+			continue
+		}
+		if mapped, found := m.Map(entry.Color); found {
+			entry.Color = mapped
+			matched[i] = true
+		}
+	}
+	return matched
+}
+
+// eachPixels replaces pixels of every image cel and tileset of sprite by result of f, transparent index
+// is opaque on background layer cels
+func eachPixels(sprite *asefile.Sprite, f func(pixels []byte, opaqueIndex bool) []byte) {
+	for _, frame := range sprite.Frames {
+		for _, cel := range frame.Cels {
+			if cel.Tilemap != nil || len(cel.Pixels) == 0 {
+				continue
+			}
+			cel.Pixels = f(cel.Pixels, cel.LayerIndex < len(sprite.Layers) && sprite.Layers[cel.LayerIndex].IsBackground())
+		}
+	}
+	for _, tileset := range sprite.Tilesets {
+		if len(tileset.Pixels) > 0 {
+			tileset.Pixels = f(tileset.Pixels, false)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package recolor_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/recolor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red   = color.NRGBA{R: 255, A: 255}
+	green = color.NRGBA{G: 255, A: 255}
+	blue  = color.NRGBA{B: 255, A: 255}
+	dark  = color.NRGBA{R: 128, A: 255}
+	white = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+func mapping(t *testing.T, source, target []color.NRGBA) *recolor.Mapping {
+	m, err := recolor.NewMapping(&palette.Palette{Name: "source", Colors: source}, &palette.Palette{Name: "target", Colors: target})
+	require.NoError(t, err)
+	return m
+}
+
+func newSprite(depth asefile.ColorDepth, entries []color.NRGBA, pixels []byte) *asefile.Sprite {
+	sprite := &asefile.Sprite{
+		Width:      len(pixels) / depth.BytesPerPixel(),
+		Height:     1,
+		ColorDepth: depth,
+		Frames:     []*asefile.Frame{{}},
+		Layers:     []*asefile.Layer{{Name: "body", Flags: asefile.LayerFlagVisible, Opacity: 255}},
+	}
+	for _, c := range entries {
+		sprite.Palette.Entries = append(sprite.Palette.Entries, asefile.PaletteEntry{Color: c})
+	}
+	sprite.Frames[0].Cels = []*asefile.Cel{{Opacity: 255, Width: sprite.Width, Height: 1, Pixels: pixels}}
+	return sprite
+}
+
+func TestIndexedSprite(t *testing.T) {
+	// the second source color repeats the first one, index keeps them apart
+	m := mapping(t, []color.NRGBA{{}, red, red, green}, []color.NRGBA{{}, dark, blue, white})
+	sprite := newSprite(asefile.ColorDepthIndexed, []color.NRGBA{{}, red, red, blue, green}, []byte{0, 1, 2, 3, 4, 4})
+
+	report, err := recolor.Sprite(sprite, m)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Recolored)
+	assert.Equal(t, map[color.NRGBA]int{blue: 1}, report.Unmatched)
+
+	var colors []color.NRGBA
+	for _, entry := range sprite.Palette.Entries {
+		colors = append(colors, entry.Color)
+	}
+	assert.Equal(t, []color.NRGBA{{}, dark, blue, blue, white}, colors)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 4}, sprite.Frames[0].Cels[0].Pixels)
+}
+
+func TestRGBSprite(t *testing.T) {
+	m := mapping(t, []color.NRGBA{red, green}, []color.NRGBA{dark, white})
+	sprite := newSprite(asefile.ColorDepthRGBA, nil, []byte{
+		255, 0, 0, 255,
+		0, 255, 0, 128,
+		0, 0, 255, 255,
+		0, 0, 0, 0,
+	})
+
+	report, err := recolor.Sprite(sprite, m)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Recolored)
+	assert.Equal(t, 1, report.UnmatchedPixels())
+	assert.Equal(t, []byte{
+		128, 0, 0, 255,
+		255, 255, 255, 128,
+		0, 0, 255, 255,
+		0, 0, 0, 0,
+	}, sprite.Frames[0].Cels[0].Pixels)
+}
+
+func TestErrors(t *testing.T) {
+	_, err := recolor.NewMapping(&palette.Palette{Name: "source", Colors: []color.NRGBA{red, green}}, &palette.Palette{Name: "short", Colors: []color.NRGBA{red}})
+	assert.ErrorContains(t, err, "short has 1 colors")
+
+	_, err = recolor.Sprite(newSprite(asefile.ColorDepthGrayscale, nil, []byte{255, 255}), mapping(t, []color.NRGBA{red}, []color.NRGBA{blue}))
+	assert.ErrorContains(t, err, "grayscale")
+}
+
+func TestRemapRGBSprite(t *testing.T) {
+	p := &palette.Palette{Name: "base", Colors: []color.NRGBA{red, blue, white}}
+	sprite := newSprite(asefile.ColorDepthRGBA, nil, []byte{
+		240, 10, 10, 255,
+		255, 0, 0, 255,
+		20, 20, 230, 128,
+		0, 0, 0, 0,
+	})
+
+	report, err := recolor.Remap(sprite, p, palette.MetricRGB, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Pixels)
+	assert.Equal(t, map[recolor.Change]int{
+		{From: color.NRGBA{R: 240, G: 10, B: 10, A: 255}, To: red}:  1,
+		{From: color.NRGBA{R: 20, G: 20, B: 230, A: 255}, To: blue}: 1,
+	}, report.Changes)
+	assert.Equal(t, []byte{
+		255, 0, 0, 255,
+		255, 0, 0, 255,
+		0, 0, 255, 128,
+		0, 0, 0, 0,
+	}, sprite.Frames[0].Cels[0].Pixels)
+}
+
+func TestRemapToIndexed(t *testing.T) {
+	p := &palette.Palette{Name: "base", Colors: []color.NRGBA{red, blue}}
+	sprite := newSprite(asefile.ColorDepthRGBA, nil, []byte{
+		240, 10, 10, 255,
+		20, 20, 230, 200,
+		20, 20, 230, 40,
+		0, 0, 0, 0,
+	})
+
+	report, err := recolor.Remap(sprite, p, palette.MetricOKLab, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Pixels)
+	assert.Equal(t, 2, report.ChangedPixels())
+	assert.Equal(t, 2, report.Translucent)
+
+	assert.Equal(t, asefile.ColorDepthIndexed, sprite.ColorDepth)
+	assert.Equal(t, uint8(2), sprite.TransparentIndex)
+	assert.Equal(t, []asefile.PaletteEntry{{Color: red}, {Color: blue}, {}}, sprite.Palette.Entries)
+	assert.Equal(t, []byte{0, 1, 2, 2}, sprite.Frames[0].Cels[0].Pixels)
+}
+
+func TestRemapIndexedSprite(t *testing.T) {
+	p := &palette.Palette{Name: "base", Colors: []color.NRGBA{{}, red, blue}}
+	sprite := newSprite(asefile.ColorDepthIndexed, []color.NRGBA{{}, dark, blue, white}, []byte{0, 1, 1, 2, 3})
+
+	report, err := recolor.Remap(sprite, p, palette.MetricCIE76, false)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Pixels)
+	assert.Equal(t, map[recolor.Change]int{{From: dark, To: red}: 2, {From: white, To: red}: 1}, report.Changes)
+
+	var colors []color.NRGBA
+	for _, entry := range sprite.Palette.Entries {
+		colors = append(colors, entry.Color)
+	}
+	assert.Equal(t, []color.NRGBA{{}, red, blue, red}, colors)
+	assert.Equal(t, []byte{0, 1, 1, 2, 3}, sprite.Frames[0].Cels[0].Pixels)
+
+	report, err = recolor.Remap(sprite, p, palette.MetricCIE76, true)
+	require.NoError(t, err)
+	assert.Zero(t, report.ChangedPixels())
+	assert.Equal(t, []byte{0, 1, 1, 2, 1}, sprite.Frames[0].Cels[0].Pixels)
+	assert.Len(t, sprite.Palette.Entries, 3)
+
+	_, err = recolor.Remap(newSprite(asefile.ColorDepthGrayscale, nil, []byte{255, 255}), p, palette.MetricRGB, false)
+	assert.ErrorContains(t, err, "grayscale")
+}
@@ -0,0 +1,173 @@
+package recolor
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+)
+
+// indexedAlphaThreshold is the lowest alpha of pixels kept opaque when sprite is converted to indexed mode
+const indexedAlphaThreshold = 128
+
+// Change is original color of remapped pixels and palette color they got
+type Change struct {
+	From color.NRGBA
+	To   color.NRGBA
+}
+
+// RemapReport counts remapped pixels of sprite cels and tiles, Changes are counts of pixels which color was
+// changed by original and palette colors. Translucent is number of pixels which alpha was lost by conversion
+// to indexed mode
+type RemapReport struct {
+	Pixels      int
+	Changes     map[Change]int
+	Translucent int
+}
+
+// ChangedPixels returns number of pixels which color was changed
+func (r RemapReport) ChangedPixels() int {
+	var count int
+	for _, n := range r.Changes {
+		count += n
+	}
+	return count
+}
+
+// Remap snaps every color of sprite to the nearest opaque palette color by metric in place, alpha is kept.
+// RGB sprite gets pixels snapped and indexed sprite gets palette entries snapped. When toIndexed is set
+// sprite is converted to indexed mode with palette colors (and transparent color appended when palette has none),
+// pixels with alpha below half become transparent and the rest become opaque
+func Remap(sprite *asefile.Sprite, p *palette.Palette, metric palette.Metric, toIndexed bool) (RemapReport, error) {
+	report := RemapReport{Changes: make(map[Change]int)}
+
+	if sprite.ColorDepth != asefile.ColorDepthIndexed && sprite.ColorDepth != asefile.ColorDepthRGBA {
+		return report, fmt.Errorf("remap works on indexed and RGB sprites, sprite is %s", sprite.ColorDepth)
+	}
+
+	matcher, err := palette.NewMatcher(p, metric)
+	if err != nil {
+		return report, err
+	}
+
+	var (
+		entries     []asefile.PaletteEntry
+		transparent int
+	)
+	if toIndexed {
+		if entries, transparent, err = indexedPalette(p); err != nil {
+			return report, err
+		}
+	}
+
+	// snap returns palette index nearest to visible color and records its change
+	snap := func(c color.NRGBA) int {
+		index := matcher.Nearest(c)
+		report.Pixels++
+		if to := palette.Opaque(p.Colors[index]); to != palette.Opaque(c) {
+			report.Changes[Change{From: palette.Opaque(c), To: to}]++
+		}
+		return index
+	}
+
+	if sprite.ColorDepth == asefile.ColorDepthRGBA {
+		eachPixels(sprite, func(pixels []byte, _ bool) []byte {
+			var indexed []byte
+			if toIndexed {
+				indexed = make([]byte, len(pixels)/4)
+			}
+
+			for i := 0; i+3 < len(pixels); i += 4 {
+				c := color.NRGBA{R: pixels[i], G: pixels[i+1], B: pixels[i+2], A: pixels[i+3]}
+				switch {
+				case toIndexed && c.A < indexedAlphaThreshold:
+					if c.A > 0 {
+						report.Translucent++
+					}
+					indexed[i/4] = uint8(transparent)
+				case toIndexed:
+					if c.A < 255 {
+						report.Translucent++
+					}
+					indexed[i/4] = uint8(snap(c))
+				case c.A > 0:
+					to := p.Colors[snap(c)]
+					pixels[i], pixels[i+1], pixels[i+2] = to.R, to.G, to.B
+				}
+			}
+
+			if toIndexed {
+				return indexed
+			}
+			return pixels
+		})
+	} else {
+		// every palette entry is snapped once, pixels are counted by entries they use
+		indices := make([]int, len(sprite.Palette.Entries))
+		for i, entry := range sprite.Palette.Entries {
+			indices[i] = matcher.Nearest(entry.Color)
+		}
+
+		eachPixels(sprite, func(pixels []byte, opaqueIndex bool) []byte {
+			for i, index := range pixels {
+				c := sprite.ColorAt([]byte{index}, opaqueIndex)
+				if (index == sprite.TransparentIndex && !opaqueIndex) || int(index) >= len(indices) || c.A == 0 {
+					if toIndexed {
+						pixels[i] = uint8(transparent)
+					}
+					continue
+				}
+
+				snap(c)
+				if toIndexed {
+					pixels[i] = uint8(indices[index])
+				}
+			}
+			return pixels
+		})
+
+		if !toIndexed {
+			for i := range sprite.Palette.Entries {
+				entry := &sprite.Palette.Entries[i]
+				if entry.Color.A == 0 {
+					continue
+				}
+				to := p.Colors[indices[i]]
+				entry.Color = color.NRGBA{R: to.R, G: to.G, B: to.B, A: entry.Color.A}
+			}
+		}
+	}
+
+	if toIndexed {
+		sprite.ColorDepth = asefile.ColorDepthIndexed
+		sprite.Palette.Entries = entries
+		sprite.TransparentIndex = uint8(transparent)
+		sprite.NumColors = len(entries)
+	}
+
+	return report, nil
+}
+
+// indexedPalette returns sprite palette entries of palette colors with index of transparent color,
+// which is the first fully transparent palette color or appended one
+func indexedPalette(p *palette.Palette) ([]asefile.PaletteEntry, int, error) {
+	transparent := -1
+	var entries []asefile.PaletteEntry
+	for i, c := range p.Colors {
+		if c.A == 0 && transparent < 0 {
+			transparent = i
+		}
+		entries = append(entries, asefile.PaletteEntry{Color: c})
+	}
+
+	if transparent < 0 {
+		transparent = len(entries)
+		entries = append(entries, asefile.PaletteEntry{})
+	}
+	if len(entries) > palette.MaxIndexedColors {
+		return nil, 0, fmt.Errorf("palette %s has %d colors with transparent one, indexed sprite has at most %d",
+			p.Name, len(entries), palette.MaxIndexedColors)
+	}
+	return entries, transparent, nil
+}
@@ -0,0 +1,238 @@
+package tilemap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+var (
+	ErrNoTilesets      = errors.New("sprite has no tilesets")
+	ErrNoTilemapLayers = errors.New("sprite has no tilemap layers")
+)
+
+// MapFormat is format of exported tilemap
+type MapFormat string
+
+const (
+	// FormatTMX is Tiled XML map (.tmx)
+	FormatTMX MapFormat = "tmx"
+	// FormatJSON is Tiled JSON map (.tmj)
+	FormatJSON MapFormat = "json"
+	// FormatLDtk is LDtk project (.ldtk) with one level
+	FormatLDtk MapFormat = "ldtk"
+)
+
+func MapFormats() []string {
+	return []string{string(FormatTMX), string(FormatJSON), string(FormatLDtk)}
+}
+
+// ValidateMapFormats checks map formats names
+func ValidateMapFormats(formats []string) error {
+	for _, format := range formats {
+		if !slices.Contains(MapFormats(), format) {
+			return fmt.Errorf("unknown map format %q, available formats: %s", format, strings.Join(MapFormats(), ", "))
+		}
+	}
+	return nil
+}
+
+// Params describes tilesets and tilemap export of one sprite (natively, without aseprite)
+type Params struct {
+	SpriteFilename string
+	// OutputDir is directory of tilesets and maps, sprite directory if empty
+	OutputDir string
+	// Formats are written map formats, tmx if empty
+	Formats []string
+	// Frame is exported frame of tilemap layers
+	Frame int
+	// Columns is number of tiles in tileset image row, square grid if 0
+	Columns       int
+	IncludeLayers []string
+	ExcludeLayers []string
+	IncludeHidden bool
+}
+
+// ExportTilesets writes image (.png) and Tiled tileset (.tsx) of every sprite tileset, returns written filenames
+func ExportTilesets(params Params) ([]string, error) {
+	sprite, title, err := readSprite(params)
+	if err != nil {
+		return nil, err
+	}
+
+	tilesets, err := spriteTilesets(sprite, title, params.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return writeTilesets(outputDir(params), tilesets)
+}
+
+// ExportMap writes sprite tilesets and map of selected tilemap layers in every format, returns written filenames
+func ExportMap(params Params) ([]string, error) {
+	formats := params.Formats
+	if len(formats) == 0 {
+		formats = []string{string(FormatTMX)}
+	}
+	if err := ValidateMapFormats(formats); err != nil {
+		return nil, err
+	}
+
+	sprite, title, err := readSprite(params)
+	if err != nil {
+		return nil, err
+	}
+
+	tilesets, err := spriteTilesets(sprite, title, params.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := exporter.SelectLayers(sprite, params.IncludeLayers, params.ExcludeLayers, params.IncludeHidden)
+	if err != nil {
+		return nil, err
+	}
+	selected = slices.DeleteFunc(selected, func(l *asefile.Layer) bool { return l.Type != asefile.LayerTypeTilemap })
+
+	m, err := NewSpriteMap(sprite, title, tilesets, selected, params.Frame)
+	if err != nil {
+		return nil, err
+	}
+
+	// map is encoded before writing anything to not leave tilesets without map on error
+	outputs, err := encodeMap(m, outputDir(params), formats)
+	if err != nil {
+		return nil, err
+	}
+
+	written, err := writeTilesets(outputDir(params), tilesets)
+	if err != nil {
+		return written, err
+	}
+
+	for _, output := range outputs {
+		if err := writeFile(output.filename, output.data); err != nil {
+			return written, err
+		}
+		written = append(written, output.filename)
+	}
+
+	return written, nil
+}
+
+type file struct {
+	filename string
+	data     []byte
+}
+
+// encodeMap encodes map in every format to <dir>/<map name>.<extension>
+func encodeMap(m *Map, dir string, formats []string) ([]file, error) {
+	var outputs []file
+	for _, format := range formats {
+		var (
+			data      []byte
+			extension string
+			err       error
+		)
+		switch MapFormat(format) {
+		case FormatTMX:
+			data, err = m.TMX()
+			extension = ".tmx"
+		case FormatJSON:
+			data, err = m.TMJ()
+			extension = ".tmj"
+		case FormatLDtk:
+			data, err = m.LDtk()
+			extension = ".ldtk"
+		}
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, file{filename: filepath.Join(dir, m.Name+extension), data: data})
+	}
+	return outputs, nil
+}
+
+func readSprite(params Params) (*asefile.Sprite, string, error) {
+	if params.SpriteFilename == "" ||
+		!files.CheckFileExists(params.SpriteFilename, false) ||
+		!files.CheckFileExtension(params.SpriteFilename, aseprite.SpritesExtensions()...) {
+		return nil, "", fmt.Errorf("invalid sprite filename: %q", params.SpriteFilename)
+	}
+
+	sprite, err := asefile.ReadFile(params.SpriteFilename)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read sprite: %w", err)
+	}
+
+	title := strings.TrimSuffix(filepath.Base(params.SpriteFilename), filepath.Ext(params.SpriteFilename))
+	return sprite, title, nil
+}
+
+func spriteTilesets(sprite *asefile.Sprite, title string, columns int) ([]*Tileset, error) {
+	if len(sprite.Tilesets) == 0 {
+		return nil, ErrNoTilesets
+	}
+	if columns < 0 {
+		return nil, errors.New("tileset columns cannot be negative")
+	}
+
+	tilesets, err := SpriteTilesets(sprite, title)
+	if err != nil {
+		return nil, err
+	}
+	for _, tileset := range tilesets {
+		tileset.Columns = columns
+	}
+	return tilesets, nil
+}
+
+func writeTilesets(dir string, tilesets []*Tileset) ([]string, error) {
+	var written []string
+	for _, tileset := range tilesets {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, tileset.Image()); err != nil {
+			return written, fmt.Errorf("failed to encode tileset %s: %w", tileset.Name, err)
+		}
+
+		tsx, err := tileset.TSX(tileset.Filename + ".png")
+		if err != nil {
+			return written, err
+		}
+
+		for _, output := range []file{
+			{filename: filepath.Join(dir, tileset.Filename+".png"), data: buf.Bytes()},
+			{filename: filepath.Join(dir, tileset.Filename+".tsx"), data: tsx},
+		} {
+			if err := writeFile(output.filename, output.data); err != nil {
+				return written, err
+			}
+			written = append(written, output.filename)
+		}
+	}
+	return written, nil
+}
+
+func writeFile(filename string, data []byte) error {
+	if err := files.EnsureDirExists(filename); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+func outputDir(params Params) string {
+	if params.OutputDir != "" {
+		return params.OutputDir
+	}
+	return filepath.Dir(params.SpriteFilename)
+}
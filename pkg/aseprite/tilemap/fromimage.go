@@ -0,0 +1,217 @@
+package tilemap
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// CutOptions describes how image is cut to tiles and which tile variants are duplicates
+type CutOptions struct {
+	TileWidth  int
+	TileHeight int
+	// Flips makes horizontally and vertically flipped tiles duplicates
+	Flips bool
+	// Rotations makes tiles rotated by 90, 180 and 270 degrees duplicates (square tiles only)
+	Rotations bool
+}
+
+// CutStats are numbers of map cells, empty (fully transparent) cells and unique tiles found in image
+type CutStats struct {
+	Cells  int
+	Empty  int
+	Unique int
+}
+
+// FromImage cuts image into tiles (partial tiles on right and bottom edges are padded with transparency)
+// and builds map of one layer with tileset of unique tiles named name. Fully transparent tiles are empty cells,
+// duplicated tiles are stored once and tiles matching flipped or rotated variant of stored tile are placed with flips
+func FromImage(img image.Image, name string, opts CutOptions) (*Map, CutStats, error) {
+	if opts.TileWidth <= 0 || opts.TileHeight <= 0 {
+		return nil, CutStats{}, fmt.Errorf("invalid tile size %dx%d", opts.TileWidth, opts.TileHeight)
+	}
+	if opts.Rotations && opts.TileWidth != opts.TileHeight {
+		return nil, CutStats{}, fmt.Errorf("tiles %dx%d are not square, only square tiles can be rotated", opts.TileWidth, opts.TileHeight)
+	}
+
+	bounds := img.Bounds()
+	m := &Map{
+		Name:       name,
+		Width:      (bounds.Dx() + opts.TileWidth - 1) / opts.TileWidth,
+		Height:     (bounds.Dy() + opts.TileHeight - 1) / opts.TileHeight,
+		TileWidth:  opts.TileWidth,
+		TileHeight: opts.TileHeight,
+	}
+	tileset := &Tileset{Name: "tiles", Filename: name + "_tiles", TileWidth: opts.TileWidth, TileHeight: opts.TileHeight}
+	m.Tilesets = []*Tileset{tileset}
+
+	layer := Layer{Name: "tiles", Opacity: 1, Visible: true, GIDs: make([]uint32, m.Width*m.Height)}
+	stats := CutStats{Cells: len(layer.GIDs)}
+	transforms := tileTransforms(opts)
+	// known maps pixels of stored tiles and their variants to GIDs
+	known := map[string]uint32{}
+
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			tile := image.NewNRGBA(image.Rect(0, 0, opts.TileWidth, opts.TileHeight))
+			origin := bounds.Min.Add(image.Pt(x*opts.TileWidth, y*opts.TileHeight))
+			draw.Draw(tile, tile.Rect, img, origin, draw.Src)
+
+			if isTransparent(tile) {
+				stats.Empty++
+				continue
+			}
+
+			gid, ok := known[string(tile.Pix)]
+			if !ok {
+				tileset.Tiles = append(tileset.Tiles, tile)
+				gid = uint32(len(tileset.Tiles))
+				// identity goes first, so tiles symmetric to themselves are placed without flips
+				for _, transform := range transforms {
+					variant := asefile.FlipTileImage(tile, transform)
+					if _, exists := known[string(variant.Pix)]; !exists {
+						known[string(variant.Pix)] = gid | tileFlags(transform)
+					}
+				}
+			}
+			layer.GIDs[y*m.Width+x] = gid
+		}
+	}
+
+	m.Layers = []Layer{layer}
+	stats.Unique = len(tileset.Tiles)
+	return m, stats, nil
+}
+
+// tileTransforms returns tile variants treated as duplicates, starting with identity
+func tileTransforms(opts CutOptions) []asefile.Tile {
+	transforms := []asefile.Tile{{}}
+	if opts.Flips {
+		transforms = append(transforms, asefile.Tile{FlipX: true}, asefile.Tile{FlipY: true})
+	}
+	if opts.Rotations {
+		// diagonal flip with horizontal one is 90° clockwise rotation
+		transforms = append(transforms, asefile.Tile{FlipDiagonal: true, FlipX: true}, asefile.Tile{FlipDiagonal: true, FlipY: true})
+	}
+	if opts.Flips || opts.Rotations {
+		transforms = append(transforms, asefile.Tile{FlipX: true, FlipY: true})
+	}
+	if opts.Flips && opts.Rotations {
+		transforms = append(transforms, asefile.Tile{FlipDiagonal: true}, asefile.Tile{FlipDiagonal: true, FlipX: true, FlipY: true})
+	}
+	return transforms
+}
+
+func isTransparent(img *image.NRGBA) bool {
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ImageParams describes tileset and map export of flat image
+type ImageParams struct {
+	ImageFilename string
+	// OutputDir is directory of tileset and maps, image directory if empty
+	OutputDir string
+	// Formats are written map formats, tmx if empty
+	Formats []string
+	// Columns is number of tiles in tileset image row, square grid if 0
+	Columns int
+	Cut     CutOptions
+	// Aseprite writes <image>.aseprite with tileset and tilemap layer too
+	Aseprite bool
+}
+
+// ExportImage cuts image to unique tiles and writes tileset, maps and optionally sprite,
+// returns written filenames and cut stats
+func ExportImage(params ImageParams) ([]string, CutStats, error) {
+	formats := params.Formats
+	if len(formats) == 0 {
+		formats = []string{string(FormatTMX)}
+	}
+	if err := ValidateMapFormats(formats); err != nil {
+		return nil, CutStats{}, err
+	}
+	if params.Columns < 0 {
+		return nil, CutStats{}, errors.New("tileset columns cannot be negative")
+	}
+
+	img, err := readImage(params.ImageFilename)
+	if err != nil {
+		return nil, CutStats{}, err
+	}
+
+	title := strings.TrimSuffix(filepath.Base(params.ImageFilename), filepath.Ext(params.ImageFilename))
+	m, stats, err := FromImage(img, title, params.Cut)
+	if err != nil {
+		return nil, stats, err
+	}
+	m.Tilesets[0].Columns = params.Columns
+
+	dir := params.OutputDir
+	if dir == "" {
+		dir = filepath.Dir(params.ImageFilename)
+	}
+
+	outputs, err := encodeMap(m, dir, formats)
+	if err != nil {
+		return nil, stats, err
+	}
+
+	var sprite *asefile.Sprite
+	if params.Aseprite {
+		if sprite, err = m.Sprite(); err != nil {
+			return nil, stats, err
+		}
+	}
+
+	written, err := writeTilesets(dir, m.Tilesets)
+	if err != nil {
+		return written, stats, err
+	}
+
+	for _, output := range outputs {
+		if err := writeFile(output.filename, output.data); err != nil {
+			return written, stats, err
+		}
+		written = append(written, output.filename)
+	}
+
+	if sprite != nil {
+		filename := filepath.Join(dir, title+".aseprite")
+		if err := asefile.WriteFile(filename, sprite); err != nil {
+			return written, stats, fmt.Errorf("failed to write sprite: %w", err)
+		}
+		written = append(written, filename)
+	}
+
+	return written, stats, nil
+}
+
+func readImage(filename string) (image.Image, error) {
+	if filename == "" || !files.CheckFileExists(filename, false) {
+		return nil, fmt.Errorf("invalid image filename: %q", filename)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %s: %w", filename, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,367 @@
+package tilemap
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// LDtkVersion is LDtk project JSON version written to .ldtk files
+const LDtkVersion = "1.5.3"
+
+type ldtkProject struct {
+	Header             ldtkHeader  `json:"__header__"`
+	IID                string      `json:"iid"`
+	JSONVersion        string      `json:"jsonVersion"`
+	NextUID            int         `json:"nextUid"`
+	IdentifierStyle    string      `json:"identifierStyle"`
+	TOC                []any       `json:"toc"`
+	WorldLayout        string      `json:"worldLayout"`
+	WorldGridWidth     int         `json:"worldGridWidth"`
+	WorldGridHeight    int         `json:"worldGridHeight"`
+	DefaultLevelWidth  int         `json:"defaultLevelWidth"`
+	DefaultLevelHeight int         `json:"defaultLevelHeight"`
+	DefaultPivotX      float64     `json:"defaultPivotX"`
+	DefaultPivotY      float64     `json:"defaultPivotY"`
+	DefaultGridSize    int         `json:"defaultGridSize"`
+	BgColor            string      `json:"bgColor"`
+	DefaultLevelBg     string      `json:"defaultLevelBgColor"`
+	MinifyJSON         bool        `json:"minifyJson"`
+	ExternalLevels     bool        `json:"externalLevels"`
+	ExportTiled        bool        `json:"exportTiled"`
+	ImageExportMode    string      `json:"imageExportMode"`
+	LevelNamePattern   string      `json:"levelNamePattern"`
+	Flags              []string    `json:"flags"`
+	Defs               ldtkDefs    `json:"defs"`
+	Levels             []ldtkLevel `json:"levels"`
+	Worlds             []any       `json:"worlds"`
+}
+
+type ldtkHeader struct {
+	FileType   string `json:"fileType"`
+	App        string `json:"app"`
+	Doc        string `json:"doc"`
+	Schema     string `json:"schema"`
+	AppVersion string `json:"appVersion"`
+	URL        string `json:"url"`
+}
+
+type ldtkDefs struct {
+	Layers        []ldtkLayerDef   `json:"layers"`
+	Entities      []any            `json:"entities"`
+	Tilesets      []ldtkTilesetDef `json:"tilesets"`
+	Enums         []any            `json:"enums"`
+	ExternalEnums []any            `json:"externalEnums"`
+	LevelFields   []any            `json:"levelFields"`
+}
+
+type ldtkLayerDef struct {
+	Type            string  `json:"__type"`
+	Identifier      string  `json:"identifier"`
+	LayerType       string  `json:"type"`
+	UID             int     `json:"uid"`
+	GridSize        int     `json:"gridSize"`
+	GuideGridWid    int     `json:"guideGridWid"`
+	GuideGridHei    int     `json:"guideGridHei"`
+	DisplayOpacity  float64 `json:"displayOpacity"`
+	InactiveOpacity float64 `json:"inactiveOpacity"`
+	PxOffsetX       int     `json:"pxOffsetX"`
+	PxOffsetY       int     `json:"pxOffsetY"`
+	ParallaxFactorX float64 `json:"parallaxFactorX"`
+	ParallaxFactorY float64 `json:"parallaxFactorY"`
+	ParallaxScaling bool    `json:"parallaxScaling"`
+	RequiredTags    []any   `json:"requiredTags"`
+	ExcludedTags    []any   `json:"excludedTags"`
+	IntGridValues   []any   `json:"intGridValues"`
+	AutoRuleGroups  []any   `json:"autoRuleGroups"`
+	TilesetDefUID   int     `json:"tilesetDefUid"`
+	TilePivotX      float64 `json:"tilePivotX"`
+	TilePivotY      float64 `json:"tilePivotY"`
+}
+
+type ldtkTilesetDef struct {
+	CWid            int    `json:"__cWid"`
+	CHei            int    `json:"__cHei"`
+	Identifier      string `json:"identifier"`
+	UID             int    `json:"uid"`
+	RelPath         string `json:"relPath"`
+	PxWid           int    `json:"pxWid"`
+	PxHei           int    `json:"pxHei"`
+	TileGridSize    int    `json:"tileGridSize"`
+	Spacing         int    `json:"spacing"`
+	Padding         int    `json:"padding"`
+	Tags            []any  `json:"tags"`
+	EnumTags        []any  `json:"enumTags"`
+	CustomData      []any  `json:"customData"`
+	SavedSelections []any  `json:"savedSelections"`
+}
+
+type ldtkLevel struct {
+	Identifier     string              `json:"identifier"`
+	IID            string              `json:"iid"`
+	UID            int                 `json:"uid"`
+	WorldX         int                 `json:"worldX"`
+	WorldY         int                 `json:"worldY"`
+	WorldDepth     int                 `json:"worldDepth"`
+	PxWid          int                 `json:"pxWid"`
+	PxHei          int                 `json:"pxHei"`
+	BgColorValue   string              `json:"__bgColor"`
+	BgPivotX       float64             `json:"bgPivotX"`
+	BgPivotY       float64             `json:"bgPivotY"`
+	FieldInstances []any               `json:"fieldInstances"`
+	LayerInstances []ldtkLayerInstance `json:"layerInstances"`
+	Neighbours     []any               `json:"__neighbours"`
+}
+
+type ldtkLayerInstance struct {
+	Identifier      string         `json:"__identifier"`
+	Type            string         `json:"__type"`
+	CWid            int            `json:"__cWid"`
+	CHei            int            `json:"__cHei"`
+	GridSize        int            `json:"__gridSize"`
+	Opacity         float64        `json:"__opacity"`
+	PxTotalOffsetX  int            `json:"__pxTotalOffsetX"`
+	PxTotalOffsetY  int            `json:"__pxTotalOffsetY"`
+	TilesetDefUID   int            `json:"__tilesetDefUid"`
+	TilesetRelPath  string         `json:"__tilesetRelPath"`
+	IID             string         `json:"iid"`
+	LevelID         int            `json:"levelId"`
+	LayerDefUID     int            `json:"layerDefUid"`
+	PxOffsetX       int            `json:"pxOffsetX"`
+	PxOffsetY       int            `json:"pxOffsetY"`
+	Visible         bool           `json:"visible"`
+	OptionalRules   []any          `json:"optionalRules"`
+	IntGridCsv      []any          `json:"intGridCsv"`
+	AutoLayerTiles  []any          `json:"autoLayerTiles"`
+	Seed            int            `json:"seed"`
+	GridTiles       []ldtkGridTile `json:"gridTiles"`
+	EntityInstances []any          `json:"entityInstances"`
+}
+
+type ldtkGridTile struct {
+	Px [2]int `json:"px"`
+	// Src is tile position in tileset image
+	Src [2]int `json:"src"`
+	// F is flips: bit 0 is X flip, bit 1 is Y flip
+	F int `json:"f"`
+	// T is tile id in tileset (row by row)
+	T int     `json:"t"`
+	D []int   `json:"d"`
+	A float64 `json:"a"`
+}
+
+// LDtk returns LDtk project (.ldtk) with one level of map layers, tilesets images are referenced as
+// <Filename>.png. LDtk layer has one tileset and cannot rotate tiles, layers with tiles of several
+// tilesets or diagonally flipped tiles are not supported
+func (m *Map) LDtk() ([]byte, error) {
+	if m.TileWidth != m.TileHeight {
+		return nil, fmt.Errorf("LDtk supports square tiles only, map tiles are %dx%d", m.TileWidth, m.TileHeight)
+	}
+
+	uid := 0
+	nextUID := func() int {
+		uid++
+		return uid
+	}
+
+	project := ldtkProject{
+		Header: ldtkHeader{
+			FileType:   "LDtk Project JSON",
+			App:        "LDtk",
+			Doc:        "https://ldtk.io/json",
+			Schema:     "https://ldtk.io/files/JSON_SCHEMA.json",
+			AppVersion: LDtkVersion,
+			URL:        "https://ldtk.io",
+		},
+		IID:                m.iid("project", 0),
+		JSONVersion:        LDtkVersion,
+		IdentifierStyle:    "Capitalize",
+		TOC:                []any{},
+		WorldLayout:        "Free",
+		WorldGridWidth:     m.Width * m.TileWidth,
+		WorldGridHeight:    m.Height * m.TileHeight,
+		DefaultLevelWidth:  m.Width * m.TileWidth,
+		DefaultLevelHeight: m.Height * m.TileHeight,
+		DefaultGridSize:    m.TileWidth,
+		BgColor:            "#40465B",
+		DefaultLevelBg:     "#696A79",
+		ImageExportMode:    "None",
+		LevelNamePattern:   "Level_%idx",
+		Flags:              []string{},
+		Defs: ldtkDefs{
+			Layers:        []ldtkLayerDef{},
+			Entities:      []any{},
+			Tilesets:      []ldtkTilesetDef{},
+			Enums:         []any{},
+			ExternalEnums: []any{},
+			LevelFields:   []any{},
+		},
+		Worlds: []any{},
+	}
+
+	tilesetIdentifiers, layerIdentifiers := make(map[string]bool), make(map[string]bool)
+	tilesetUIDs := make([]int, len(m.Tilesets))
+	for i, ts := range m.Tilesets {
+		tilesetUIDs[i] = nextUID()
+		project.Defs.Tilesets = append(project.Defs.Tilesets, ldtkTilesetDef{
+			CWid:            ts.GridColumns(),
+			CHei:            ts.GridRows(),
+			Identifier:      uniqueIdentifier(tilesetIdentifiers, ts.Name),
+			UID:             tilesetUIDs[i],
+			RelPath:         ts.Filename + ".png",
+			PxWid:           ts.GridColumns() * ts.TileWidth,
+			PxHei:           ts.GridRows() * ts.TileHeight,
+			TileGridSize:    ts.TileWidth,
+			Tags:            []any{},
+			EnumTags:        []any{},
+			CustomData:      []any{},
+			SavedSelections: []any{},
+		})
+	}
+
+	level := ldtkLevel{
+		Identifier:     "Level_0",
+		IID:            m.iid("level", 0),
+		PxWid:          m.Width * m.TileWidth,
+		PxHei:          m.Height * m.TileHeight,
+		BgColorValue:   "#696A79",
+		BgPivotX:       0.5,
+		BgPivotY:       0.5,
+		FieldInstances: []any{},
+		Neighbours:     []any{},
+	}
+
+	// LDtk lists layers from top to bottom
+	var instances []ldtkLayerInstance
+	for i := len(m.Layers) - 1; i >= 0; i-- {
+		layer := m.Layers[i]
+
+		tileset, tiles, err := m.ldtkTiles(layer)
+		if err != nil {
+			return nil, err
+		}
+
+		def := ldtkLayerDef{
+			Type:            "Tiles",
+			Identifier:      uniqueIdentifier(layerIdentifiers, layer.Name),
+			LayerType:       "Tiles",
+			UID:             nextUID(),
+			GridSize:        m.TileWidth,
+			DisplayOpacity:  1,
+			InactiveOpacity: 1,
+			ParallaxScaling: true,
+			RequiredTags:    []any{},
+			ExcludedTags:    []any{},
+			IntGridValues:   []any{},
+			AutoRuleGroups:  []any{},
+			TilesetDefUID:   tilesetUIDs[tileset],
+		}
+		project.Defs.Layers = append(project.Defs.Layers, def)
+
+		instances = append(instances, ldtkLayerInstance{
+			Identifier:      def.Identifier,
+			Type:            "Tiles",
+			CWid:            m.Width,
+			CHei:            m.Height,
+			GridSize:        m.TileWidth,
+			Opacity:         layer.Opacity,
+			PxTotalOffsetX:  layer.OffsetX,
+			PxTotalOffsetY:  layer.OffsetY,
+			TilesetDefUID:   tilesetUIDs[tileset],
+			TilesetRelPath:  m.Tilesets[tileset].Filename + ".png",
+			IID:             m.iid("layer", i),
+			LayerDefUID:     def.UID,
+			PxOffsetX:       layer.OffsetX,
+			PxOffsetY:       layer.OffsetY,
+			Visible:         layer.Visible,
+			OptionalRules:   []any{},
+			IntGridCsv:      []any{},
+			AutoLayerTiles:  []any{},
+			GridTiles:       tiles,
+			EntityInstances: []any{},
+		})
+	}
+
+	level.UID = nextUID()
+	for i := range instances {
+		instances[i].LevelID = level.UID
+	}
+	level.LayerInstances = instances
+	project.Levels = []ldtkLevel{level}
+	project.NextUID = nextUID()
+
+	content, err := json.MarshalIndent(project, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// ldtkTiles returns tileset of layer (first tileset if layer is empty) and layer tiles
+func (m *Map) ldtkTiles(layer Layer) (int, []ldtkGridTile, error) {
+	tileset := -1
+	tiles := []ldtkGridTile{}
+
+	for i, gid := range layer.GIDs {
+		ts, index, ok := m.ResolveGID(gid)
+		if !ok {
+			continue
+		}
+		if tileset >= 0 && ts != tileset {
+			return 0, nil, fmt.Errorf("layer %q uses several tilesets, LDtk layer has one tileset", layer.Name)
+		}
+		if gid&FlipDiagonal != 0 {
+			return 0, nil, fmt.Errorf("layer %q has rotated (diagonally flipped) tiles, LDtk does not support tiles rotation", layer.Name)
+		}
+		tileset = ts
+
+		x, y := i%m.Width, i/m.Width
+		src := m.Tilesets[ts].TileRect(index).Min
+		flips := 0
+		if gid&FlipHorizontal != 0 {
+			flips |= 1
+		}
+		if gid&FlipVertical != 0 {
+			flips |= 2
+		}
+
+		tiles = append(tiles, ldtkGridTile{
+			Px:  [2]int{x * m.TileWidth, y * m.TileHeight},
+			Src: [2]int{src.X, src.Y},
+			F:   flips,
+			T:   index,
+			D:   []int{i},
+			A:   1,
+		})
+	}
+
+	return max(tileset, 0), tiles, nil
+}
+
+// iid returns stable UUID-formatted instance id so that output does not change between runs
+func (m *Map) iid(kind string, index int) string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s/%s/%d", m.Name, kind, index)))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+var invalidIdentifierChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// uniqueIdentifier converts name to LDtk identifier (letters, digits and underscores, capitalized)
+// which is not used yet
+func uniqueIdentifier(used map[string]bool, name string) string {
+	identifier := strings.Trim(invalidIdentifierChars.ReplaceAllString(name, "_"), "_")
+	if identifier == "" || unicode.IsDigit(rune(identifier[0])) {
+		identifier = "_" + identifier
+	}
+	identifier = strings.ToUpper(identifier[:1]) + identifier[1:]
+
+	unique := identifier
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", identifier, i)
+	}
+	used[unique] = true
+	return unique
+}
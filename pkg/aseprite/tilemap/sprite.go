@@ -0,0 +1,97 @@
+package tilemap
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+// Sprite returns one frame RGB sprite of map: every map tileset becomes sprite tileset (with empty tile 0)
+// and every map layer becomes tilemap layer. Aseprite tilemap layer has one tileset, so layers mixing tilesets are refused
+func (m *Map) Sprite() (*asefile.Sprite, error) {
+	sprite := &asefile.Sprite{
+		Width:       m.Width * m.TileWidth,
+		Height:      m.Height * m.TileHeight,
+		ColorDepth:  asefile.ColorDepthRGBA,
+		Flags:       asefile.HeaderFlagLayerOpacityValid,
+		PixelWidth:  1,
+		PixelHeight: 1,
+		Grid:        image.Rect(0, 0, m.TileWidth, m.TileHeight),
+		Frames:      []*asefile.Frame{{Duration: 100 * time.Millisecond}},
+	}
+
+	for _, tileset := range m.Tilesets {
+		sprite.Tilesets = append(sprite.Tilesets, tileset.spriteTileset(len(sprite.Tilesets)))
+	}
+
+	for i, layer := range m.Layers {
+		tileset := -1
+		tiles := make([]asefile.Tile, len(layer.GIDs))
+		for j, gid := range layer.GIDs {
+			ts, index, ok := m.ResolveGID(gid)
+			if !ok {
+				continue
+			}
+			if tileset >= 0 && ts != tileset {
+				return nil, fmt.Errorf("layer %q uses several tilesets, Aseprite tilemap layer has one tileset", layer.Name)
+			}
+			tileset = ts
+
+			tiles[j] = asefile.Tile{
+				ID:           uint32(index + 1),
+				FlipX:        gid&FlipHorizontal != 0,
+				FlipY:        gid&FlipVertical != 0,
+				FlipDiagonal: gid&FlipDiagonal != 0,
+			}
+		}
+
+		flags := asefile.LayerFlagEditable
+		if layer.Visible {
+			flags |= asefile.LayerFlagVisible
+		}
+		sprite.Layers = append(sprite.Layers, &asefile.Layer{
+			Index:        i,
+			Name:         layer.Name,
+			Flags:        flags,
+			Type:         asefile.LayerTypeTilemap,
+			Opacity:      uint8(layer.Opacity*255 + 0.5),
+			TilesetIndex: max(tileset, 0),
+		})
+		sprite.Frames[0].Cels = append(sprite.Frames[0].Cels, &asefile.Cel{
+			LayerIndex: i,
+			X:          layer.OffsetX,
+			Y:          layer.OffsetY,
+			Opacity:    255,
+			Type:       asefile.CelTypeCompressedTilemap,
+			Width:      m.Width,
+			Height:     m.Height,
+			Tilemap:    asefile.NewTilemap(m.Width, m.Height, tiles),
+		})
+	}
+
+	return sprite, nil
+}
+
+// spriteTileset converts tileset to embedded RGBA sprite tileset, empty tile 0 is added before tiles
+func (t *Tileset) spriteTileset(id int) *asefile.Tileset {
+	tileSize := t.TileWidth * t.TileHeight * 4
+	pixels := make([]byte, tileSize*(len(t.Tiles)+1))
+	for i, tile := range t.Tiles {
+		img := &image.NRGBA{Pix: pixels[(i+1)*tileSize : (i+2)*tileSize], Stride: t.TileWidth * 4, Rect: image.Rect(0, 0, t.TileWidth, t.TileHeight)}
+		draw.Draw(img, img.Rect, tile, tile.Rect.Min, draw.Src)
+	}
+
+	return &asefile.Tileset{
+		ID:         uint32(id),
+		Flags:      asefile.TilesetFlagEmbedded | asefile.TilesetFlagEmptyTileID,
+		NumTiles:   len(t.Tiles) + 1,
+		TileWidth:  t.TileWidth,
+		TileHeight: t.TileHeight,
+		BaseIndex:  1,
+		Name:       t.Name,
+		Pixels:     pixels,
+	}
+}
@@ -0,0 +1,352 @@
+package tilemap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+// Tiled global tile id (GID) flags, GID without flags is FirstGID of tileset plus tile index.
+// Tiled applies diagonal flip first, then horizontal and vertical ones, as Aseprite does
+const (
+	FlipHorizontal uint32 = 0x80000000
+	FlipVertical   uint32 = 0x40000000
+	FlipDiagonal   uint32 = 0x20000000
+	// gidFlags are all flag bits reserved by Tiled (including hexagonal rotation)
+	gidFlags uint32 = 0xf0000000
+)
+
+// Map is orthogonal map of tilemap layers sharing tile size
+type Map struct {
+	Name string
+	// Width and Height are map size in tiles
+	Width      int
+	Height     int
+	TileWidth  int
+	TileHeight int
+	Tilesets   []*Tileset
+	Layers     []Layer
+}
+
+// Layer is tile layer of map, GIDs are stored row by row (0 is empty cell)
+type Layer struct {
+	Name string
+	// OffsetX and OffsetY are layer offset in pixels when layer is not aligned to map grid
+	OffsetX int
+	OffsetY int
+	Opacity float64
+	Visible bool
+	GIDs    []uint32
+}
+
+// NewSpriteMap builds map of tilemap layers on frame, tilesets are sprite tilesets in sprite order
+// (see SpriteTilesets). Map covers sprite canvas, tiles outside of it are dropped
+func NewSpriteMap(sprite *asefile.Sprite, name string, tilesets []*Tileset, layers []*asefile.Layer, frame int) (*Map, error) {
+	if frame < 0 || frame >= len(sprite.Frames) {
+		return nil, fmt.Errorf("frame %d is out of range, sprite has %d frames", frame, len(sprite.Frames))
+	}
+
+	m := &Map{Name: name, Tilesets: tilesets}
+	for _, layer := range layers {
+		if layer.Type != asefile.LayerTypeTilemap {
+			return nil, fmt.Errorf("layer %q is not tilemap layer", layer.Path())
+		}
+		if layer.TilesetIndex < 0 || layer.TilesetIndex >= len(tilesets) {
+			return nil, fmt.Errorf("layer %q refers to missing tileset %d", layer.Path(), layer.TilesetIndex)
+		}
+
+		tileset := tilesets[layer.TilesetIndex]
+		if m.TileWidth == 0 {
+			m.TileWidth, m.TileHeight = tileset.TileWidth, tileset.TileHeight
+		} else if m.TileWidth != tileset.TileWidth || m.TileHeight != tileset.TileHeight {
+			return nil, fmt.Errorf("layer %q tiles are %dx%d while map tiles are %dx%d, export layers with different tile sizes separately",
+				layer.Path(), tileset.TileWidth, tileset.TileHeight, m.TileWidth, m.TileHeight)
+		}
+	}
+	if m.TileWidth == 0 {
+		return nil, ErrNoTilemapLayers
+	}
+
+	m.Width = (sprite.Width + m.TileWidth - 1) / m.TileWidth
+	m.Height = (sprite.Height + m.TileHeight - 1) / m.TileHeight
+
+	for _, layer := range layers {
+		m.Layers = append(m.Layers, m.spriteLayer(sprite, layer, frame))
+	}
+
+	return m, nil
+}
+
+// spriteLayer converts tilemap cel of layer on frame to map layer, cel position is split to
+// whole tiles and pixel offset of layer
+func (m *Map) spriteLayer(sprite *asefile.Sprite, layer *asefile.Layer, frame int) Layer {
+	result := Layer{
+		Name:    layer.Path(),
+		Opacity: float64(layer.Opacity) / 255,
+		Visible: layer.VisibleInTree(),
+		GIDs:    make([]uint32, m.Width*m.Height),
+	}
+
+	cel := sprite.Cel(frame, layer.Index)
+	if cel == nil || cel.Tilemap == nil {
+		return result
+	}
+
+	shiftX, shiftY := floorDiv(cel.X, m.TileWidth), floorDiv(cel.Y, m.TileHeight)
+	result.OffsetX, result.OffsetY = cel.X-shiftX*m.TileWidth, cel.Y-shiftY*m.TileHeight
+
+	tm := cel.Tilemap
+	for ty := 0; ty < tm.Height; ty++ {
+		for tx := 0; tx < tm.Width; tx++ {
+			x, y := shiftX+tx, shiftY+ty
+			if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+				continue
+			}
+			result.GIDs[y*m.Width+x] = m.GID(layer.TilesetIndex, tm.TileAt(tx, ty))
+		}
+	}
+
+	return result
+}
+
+// FirstGID returns GID of first tile of tileset, tilesets GIDs follow each other starting from 1
+func (m *Map) FirstGID(tileset int) uint32 {
+	gid := uint32(1)
+	for _, ts := range m.Tilesets[:tileset] {
+		gid += uint32(len(ts.Tiles))
+	}
+	return gid
+}
+
+// GID returns Tiled GID of Aseprite tile, empty and unknown tiles are 0
+func (m *Map) GID(tileset int, tile asefile.Tile) uint32 {
+	if tile.ID == 0 || int(tile.ID) > len(m.Tilesets[tileset].Tiles) {
+		return 0
+	}
+
+	return (m.FirstGID(tileset) + tile.ID - 1) | tileFlags(tile)
+}
+
+// tileFlags returns GID flags of Aseprite tile flips
+func tileFlags(tile asefile.Tile) uint32 {
+	var flags uint32
+	if tile.FlipX {
+		flags |= FlipHorizontal
+	}
+	if tile.FlipY {
+		flags |= FlipVertical
+	}
+	if tile.FlipDiagonal {
+		flags |= FlipDiagonal
+	}
+	return flags
+}
+
+// ResolveGID returns tileset and tile index (without empty tile) of GID, false for empty cell
+func (m *Map) ResolveGID(gid uint32) (tileset, index int, ok bool) {
+	id := gid &^ gidFlags
+	if id == 0 {
+		return 0, 0, false
+	}
+	for i, ts := range m.Tilesets {
+		first := m.FirstGID(i)
+		if id >= first && id < first+uint32(len(ts.Tiles)) {
+			return i, int(id - first), true
+		}
+	}
+	return 0, 0, false
+}
+
+type tmxMap struct {
+	XMLName      xml.Name         `xml:"map"`
+	Version      string           `xml:"version,attr"`
+	TiledVersion string           `xml:"tiledversion,attr"`
+	Orientation  string           `xml:"orientation,attr"`
+	RenderOrder  string           `xml:"renderorder,attr"`
+	Width        int              `xml:"width,attr"`
+	Height       int              `xml:"height,attr"`
+	TileWidth    int              `xml:"tilewidth,attr"`
+	TileHeight   int              `xml:"tileheight,attr"`
+	Infinite     int              `xml:"infinite,attr"`
+	NextLayerID  int              `xml:"nextlayerid,attr"`
+	NextObjectID int              `xml:"nextobjectid,attr"`
+	Tilesets     []tmxTilesetLink `xml:"tileset"`
+	Layers       []tmxLayer       `xml:"layer"`
+}
+
+type tmxTilesetLink struct {
+	FirstGID uint32 `xml:"firstgid,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+type tmxLayer struct {
+	ID      int     `xml:"id,attr"`
+	Name    string  `xml:"name,attr"`
+	Width   int     `xml:"width,attr"`
+	Height  int     `xml:"height,attr"`
+	Opacity string  `xml:"opacity,attr,omitempty"`
+	Visible string  `xml:"visible,attr,omitempty"`
+	OffsetX int     `xml:"offsetx,attr,omitempty"`
+	OffsetY int     `xml:"offsety,attr,omitempty"`
+	Data    tmxData `xml:"data"`
+}
+
+type tmxData struct {
+	Encoding string `xml:"encoding,attr"`
+	// CSV is written as is, escaped line breaks are not read by Tiled
+	CSV string `xml:",innerxml"`
+}
+
+// TMX returns Tiled XML map (.tmx) with CSV encoded layers, tilesets are referenced as <Filename>.tsx
+func (m *Map) TMX() ([]byte, error) {
+	data := tmxMap{
+		Version:      tiledFormat,
+		TiledVersion: TiledVersion,
+		Orientation:  "orthogonal",
+		RenderOrder:  "right-down",
+		Width:        m.Width,
+		Height:       m.Height,
+		TileWidth:    m.TileWidth,
+		TileHeight:   m.TileHeight,
+		NextLayerID:  len(m.Layers) + 1,
+		NextObjectID: 1,
+	}
+
+	for i, ts := range m.Tilesets {
+		data.Tilesets = append(data.Tilesets, tmxTilesetLink{FirstGID: m.FirstGID(i), Source: ts.Filename + ".tsx"})
+	}
+
+	for i, layer := range m.Layers {
+		tmx := tmxLayer{
+			ID:      i + 1,
+			Name:    layer.Name,
+			Width:   m.Width,
+			Height:  m.Height,
+			OffsetX: layer.OffsetX,
+			OffsetY: layer.OffsetY,
+			Data:    tmxData{Encoding: "csv", CSV: m.csv(layer.GIDs)},
+		}
+		if layer.Opacity != 1 {
+			tmx.Opacity = strconv.FormatFloat(layer.Opacity, 'f', -1, 64)
+		}
+		if !layer.Visible {
+			tmx.Visible = "0"
+		}
+		data.Layers = append(data.Layers, tmx)
+	}
+
+	return encodeXML(data)
+}
+
+// csv formats GIDs as Tiled does: one map row per line, rows separated with commas
+func (m *Map) csv(gids []uint32) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			b.WriteString(strconv.FormatUint(uint64(gids[y*m.Width+x]), 10))
+			if x < m.Width-1 || y < m.Height-1 {
+				b.WriteString(",")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// tmjMap fields are in Tiled JSON keys order (alphabetical)
+type tmjMap struct {
+	CompressionLevel int              `json:"compressionlevel"`
+	Height           int              `json:"height"`
+	Infinite         bool             `json:"infinite"`
+	Layers           []tmjLayer       `json:"layers"`
+	NextLayerID      int              `json:"nextlayerid"`
+	NextObjectID     int              `json:"nextobjectid"`
+	Orientation      string           `json:"orientation"`
+	RenderOrder      string           `json:"renderorder"`
+	TiledVersion     string           `json:"tiledversion"`
+	TileHeight       int              `json:"tileheight"`
+	Tilesets         []tmjTilesetLink `json:"tilesets"`
+	TileWidth        int              `json:"tilewidth"`
+	Type             string           `json:"type"`
+	Version          string           `json:"version"`
+	Width            int              `json:"width"`
+}
+
+type tmjTilesetLink struct {
+	FirstGID uint32 `json:"firstgid"`
+	Source   string `json:"source"`
+}
+
+type tmjLayer struct {
+	Data    []uint32 `json:"data"`
+	Height  int      `json:"height"`
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	OffsetX int      `json:"offsetx,omitempty"`
+	OffsetY int      `json:"offsety,omitempty"`
+	Opacity float64  `json:"opacity"`
+	Type    string   `json:"type"`
+	Visible bool     `json:"visible"`
+	Width   int      `json:"width"`
+	X       int      `json:"x"`
+	Y       int      `json:"y"`
+}
+
+// TMJ returns Tiled JSON map (.tmj), tilesets are referenced as <Filename>.tsx
+func (m *Map) TMJ() ([]byte, error) {
+	data := tmjMap{
+		CompressionLevel: -1,
+		Height:           m.Height,
+		Layers:           []tmjLayer{},
+		NextLayerID:      len(m.Layers) + 1,
+		NextObjectID:     1,
+		Orientation:      "orthogonal",
+		RenderOrder:      "right-down",
+		TiledVersion:     TiledVersion,
+		TileHeight:       m.TileHeight,
+		Tilesets:         []tmjTilesetLink{},
+		TileWidth:        m.TileWidth,
+		Type:             "map",
+		Version:          tiledFormat,
+		Width:            m.Width,
+	}
+
+	for i, ts := range m.Tilesets {
+		data.Tilesets = append(data.Tilesets, tmjTilesetLink{FirstGID: m.FirstGID(i), Source: ts.Filename + ".tsx"})
+	}
+
+	for i, layer := range m.Layers {
+		data.Layers = append(data.Layers, tmjLayer{
+			Data:    layer.GIDs,
+			Height:  m.Height,
+			ID:      i + 1,
+			Name:    layer.Name,
+			OffsetX: layer.OffsetX,
+			OffsetY: layer.OffsetY,
+			Opacity: layer.Opacity,
+			Type:    "tilelayer",
+			Visible: layer.Visible,
+			Width:   m.Width,
+		})
+	}
+
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// floorDiv divides rounding toward negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
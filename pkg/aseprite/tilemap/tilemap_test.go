@@ -0,0 +1,215 @@
+package tilemap_test
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/tilemap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	asepriteFlipX uint32 = 0x20000000
+	asepriteFlipY uint32 = 0x40000000
+	asepriteFlipD uint32 = 0x80000000
+)
+
+// testSprite returns 20x8 sprite with 4x4 tileset of three tiles (empty one and two colored)
+// and one row tilemap layer placed at (2, 0)
+func testSprite(tiles []uint32) *asefile.Sprite {
+	pixels := make([]byte, 4*4*4*3)
+	for i := 4 * 4 * 4; i < len(pixels); i += 4 {
+		pixels[i] = uint8(i / (4 * 4 * 4) * 100)
+		pixels[i+3] = 255
+	}
+
+	layer := &asefile.Layer{Index: 0, Name: "ground", Type: asefile.LayerTypeTilemap, Flags: asefile.LayerFlagVisible, Opacity: 255}
+	return &asefile.Sprite{
+		Width:      20,
+		Height:     8,
+		ColorDepth: asefile.ColorDepthRGBA,
+		Layers:     []*asefile.Layer{layer},
+		Tilesets: []*asefile.Tileset{{
+			Flags: asefile.TilesetFlagEmbedded | asefile.TilesetFlagEmptyTileID, NumTiles: 3,
+			TileWidth: 4, TileHeight: 4, Name: "ground tiles", Pixels: pixels,
+		}},
+		Frames: []*asefile.Frame{{Cels: []*asefile.Cel{{
+			LayerIndex: 0, X: 2, Opacity: 255, Type: asefile.CelTypeCompressedTilemap,
+			Tilemap: &asefile.Tilemap{
+				Width: len(tiles), Height: 1, BitsPerTile: 32, TileIDMask: 0x1fffffff,
+				XFlipMask: asepriteFlipX, YFlipMask: asepriteFlipY, DiagonalFlipMask: asepriteFlipD,
+				Tiles: tiles,
+			},
+		}}}},
+	}
+}
+
+func testMap(t *testing.T, tiles ...uint32) *tilemap.Map {
+	sprite := testSprite(tiles)
+	tilesets, err := tilemap.SpriteTilesets(sprite, "level")
+	require.NoError(t, err)
+
+	m, err := tilemap.NewSpriteMap(sprite, "level", tilesets, sprite.Layers, 0)
+	require.NoError(t, err)
+	return m
+}
+
+func TestSpriteTileset(t *testing.T) {
+	sprite := testSprite(nil)
+	tilesets, err := tilemap.SpriteTilesets(sprite, "level")
+	require.NoError(t, err)
+	require.Len(t, tilesets, 1)
+
+	tileset := tilesets[0]
+	assert.Equal(t, "level_ground_tiles", tileset.Filename)
+	// empty tile is not stored
+	require.Len(t, tileset.Tiles, 2)
+	assert.Equal(t, image.Rect(0, 0, 8, 4), tileset.Image().Rect)
+	assert.Equal(t, uint8(200), tileset.Image().NRGBAAt(4, 0).R)
+
+	tsx, err := tileset.TSX("level_ground_tiles.png")
+	require.NoError(t, err)
+	assert.Contains(t, string(tsx), `name="ground tiles" tilewidth="4" tileheight="4" tilecount="2" columns="2"`)
+	assert.Contains(t, string(tsx), `<image source="level_ground_tiles.png" width="8" height="4"></image>`)
+
+	sprite.Tilesets[0].Pixels = nil
+	_, err = tilemap.SpriteTilesets(sprite, "level")
+	assert.Error(t, err, "external tileset")
+}
+
+func TestTiledMap(t *testing.T) {
+	m := testMap(t, 1, 2|asepriteFlipX|asepriteFlipD, 1|asepriteFlipY)
+
+	// 20x8 sprite is covered by 5x2 map, cel at x=2 is shifted by 0 tiles with 2 pixels layer offset
+	assert.Equal(t, 5, m.Width)
+	assert.Equal(t, 2, m.Height)
+	layer := m.Layers[0]
+	assert.Equal(t, 2, layer.OffsetX)
+	assert.Equal(t, []uint32{
+		1, 2 | tilemap.FlipHorizontal | tilemap.FlipDiagonal, 1 | tilemap.FlipVertical, 0, 0,
+		0, 0, 0, 0, 0,
+	}, layer.GIDs)
+
+	tmx, err := m.TMX()
+	require.NoError(t, err)
+	assert.Contains(t, string(tmx), `<tileset firstgid="1" source="level_ground_tiles.tsx"></tileset>`)
+	assert.Contains(t, string(tmx), `<layer id="1" name="ground" width="5" height="2" offsetx="2">`)
+	assert.Contains(t, string(tmx), "<data encoding=\"csv\">\n1,2684354562,1073741825,0,0,\n0,0,0,0,0\n</data>")
+
+	tmj, err := m.TMJ()
+	require.NoError(t, err)
+
+	var data struct {
+		Layers []struct {
+			Data    []uint32 `json:"data"`
+			OffsetX int      `json:"offsetx"`
+		} `json:"layers"`
+		Tilesets []struct {
+			FirstGID int    `json:"firstgid"`
+			Source   string `json:"source"`
+		} `json:"tilesets"`
+	}
+	require.NoError(t, json.Unmarshal(tmj, &data))
+	assert.Equal(t, layer.GIDs, data.Layers[0].Data)
+	assert.Equal(t, 2, data.Layers[0].OffsetX)
+	assert.Equal(t, "level_ground_tiles.tsx", data.Tilesets[0].Source)
+}
+
+func TestLDtk(t *testing.T) {
+	m := testMap(t, 1, 0, 2|asepriteFlipX|asepriteFlipY)
+
+	project, err := m.LDtk()
+	require.NoError(t, err)
+
+	var data struct {
+		Defs struct {
+			Tilesets []struct {
+				Identifier string `json:"identifier"`
+				RelPath    string `json:"relPath"`
+			} `json:"tilesets"`
+		} `json:"defs"`
+		Levels []struct {
+			LayerInstances []struct {
+				Identifier string `json:"__identifier"`
+				GridTiles  []struct {
+					Px  []int `json:"px"`
+					Src []int `json:"src"`
+					F   int   `json:"f"`
+					T   int   `json:"t"`
+				} `json:"gridTiles"`
+			} `json:"layerInstances"`
+		} `json:"levels"`
+	}
+	require.NoError(t, json.Unmarshal(project, &data))
+
+	assert.Equal(t, "Ground_tiles", data.Defs.Tilesets[0].Identifier)
+	assert.Equal(t, "level_ground_tiles.png", data.Defs.Tilesets[0].RelPath)
+
+	tiles := data.Levels[0].LayerInstances[0].GridTiles
+	require.Len(t, tiles, 2)
+	assert.Equal(t, []int{8, 0}, tiles[1].Px)
+	assert.Equal(t, []int{4, 0}, tiles[1].Src)
+	assert.Equal(t, 3, tiles[1].F)
+	assert.Equal(t, 1, tiles[1].T)
+
+	again, err := m.LDtk()
+	require.NoError(t, err)
+	assert.Equal(t, string(project), string(again), "LDtk output is not deterministic")
+
+	_, err = testMap(t, 2|asepriteFlipD).LDtk()
+	assert.True(t, err != nil && strings.Contains(err.Error(), "rotated"), "diagonal flip is not supported by LDtk")
+}
+
+func TestFromImage(t *testing.T) {
+	// row of 2x2 tiles: tile, its horizontal flip, its 90° clockwise rotation, empty tile and
+	// partial tile padded with transparency
+	r, g, b := color.NRGBA{R: 255, A: 255}, color.NRGBA{G: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	rows := [][]color.NRGBA{
+		{r, g, g, r, b, r, {}, {}, r},
+		{b, {}, {}, b, {}, g, {}, {}, b},
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 9, 2))
+	for y, row := range rows {
+		for x, c := range row {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		opts   tilemap.CutOptions
+		unique int
+		gids   []uint32
+	}{
+		{"exact", tilemap.CutOptions{}, 4, []uint32{1, 2, 3, 0, 4}},
+		{"flips", tilemap.CutOptions{Flips: true}, 3, []uint32{1, 1 | tilemap.FlipHorizontal, 2, 0, 3}},
+		{"rotations", tilemap.CutOptions{Rotations: true}, 3, []uint32{1, 2, 1 | tilemap.FlipDiagonal | tilemap.FlipHorizontal, 0, 3}},
+		{"flips and rotations", tilemap.CutOptions{Flips: true, Rotations: true}, 2, []uint32{1, 1 | tilemap.FlipHorizontal, 1 | tilemap.FlipDiagonal | tilemap.FlipHorizontal, 0, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.TileWidth, tt.opts.TileHeight = 2, 2
+			m, stats, err := tilemap.FromImage(img, "level", tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tilemap.CutStats{Cells: 5, Empty: 1, Unique: tt.unique}, stats)
+			assert.Equal(t, tt.gids, m.Layers[0].GIDs)
+
+			sprite, err := m.Sprite()
+			require.NoError(t, err)
+			rendered := sprite.FrameImage(0, asefile.DefaultLayerFilter)
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 10; x++ {
+					assert.Equal(t, img.NRGBAAt(x, y), rendered.NRGBAAt(x, y), "pixel %d,%d", x, y)
+				}
+			}
+		})
+	}
+
+	_, _, err := tilemap.FromImage(img, "level", tilemap.CutOptions{TileWidth: 2, TileHeight: 1, Rotations: true})
+	assert.Error(t, err)
+}
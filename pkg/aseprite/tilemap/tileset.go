@@ -0,0 +1,146 @@
+package tilemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"regexp"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+const (
+	// TiledVersion is Tiled version written to .tsx, .tmx and .tmj files
+	TiledVersion = "1.10.2"
+	// tiledFormat is Tiled maps and tilesets format version
+	tiledFormat = "1.10"
+)
+
+// Tileset is set of tiles stored in grid image. Tile 0 of Aseprite tileset is always empty tile,
+// it is not stored in image: tile with Aseprite index i is tile i-1 of image (and Tiled tileset)
+type Tileset struct {
+	Name string
+	// Filename is tileset filename without extension, image (.png) and .tsx are named after it
+	Filename   string
+	TileWidth  int
+	TileHeight int
+	// Tiles are tiles images without empty tile
+	Tiles []*image.NRGBA
+	// Columns is number of tiles in image row, square grid if 0
+	Columns int
+}
+
+// NewSpriteTileset reads tiles of sprite tileset, tilesets stored in external files are not supported
+func NewSpriteTileset(sprite *asefile.Sprite, ts *asefile.Tileset, name, filename string) (*Tileset, error) {
+	if ts.Pixels == nil {
+		return nil, fmt.Errorf("tileset %q is stored in external file, only embedded tilesets are supported", name)
+	}
+
+	tileset := &Tileset{Name: name, Filename: filename, TileWidth: ts.TileWidth, TileHeight: ts.TileHeight}
+	for i := 1; i < ts.NumTiles; i++ {
+		tileset.Tiles = append(tileset.Tiles, sprite.TileImage(ts, i))
+	}
+	return tileset, nil
+}
+
+// SpriteTilesets returns every sprite tileset in sprite order, files are named <title>_<tileset name>
+func SpriteTilesets(sprite *asefile.Sprite, title string) ([]*Tileset, error) {
+	var tilesets []*Tileset
+	for i, ts := range sprite.Tilesets {
+		name := ts.Name
+		if name == "" {
+			name = fmt.Sprintf("tileset%d", i)
+		}
+
+		tileset, err := NewSpriteTileset(sprite, ts, name, title+"_"+fileSafeName(name))
+		if err != nil {
+			return nil, err
+		}
+		tilesets = append(tilesets, tileset)
+	}
+	return tilesets, nil
+}
+
+// GridColumns returns number of tiles in image row
+func (t *Tileset) GridColumns() int {
+	if t.Columns > 0 {
+		return min(t.Columns, max(len(t.Tiles), 1))
+	}
+	return max(int(math.Ceil(math.Sqrt(float64(len(t.Tiles))))), 1)
+}
+
+// GridRows returns number of tiles rows in image
+func (t *Tileset) GridRows() int {
+	columns := t.GridColumns()
+	return max((len(t.Tiles)+columns-1)/columns, 1)
+}
+
+// TileRect returns area of tile (index without empty tile) in tileset image
+func (t *Tileset) TileRect(index int) image.Rectangle {
+	columns := t.GridColumns()
+	origin := image.Pt(index%columns*t.TileWidth, index/columns*t.TileHeight)
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(t.TileWidth, t.TileHeight))}
+}
+
+// Image returns tiles placed in grid left to right, top to bottom
+func (t *Tileset) Image() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, t.GridColumns()*t.TileWidth, t.GridRows()*t.TileHeight))
+	for i, tile := range t.Tiles {
+		draw.Draw(img, t.TileRect(i), tile, tile.Rect.Min, draw.Src)
+	}
+	return img
+}
+
+type tsxTileset struct {
+	XMLName      xml.Name `xml:"tileset"`
+	Version      string   `xml:"version,attr"`
+	TiledVersion string   `xml:"tiledversion,attr"`
+	Name         string   `xml:"name,attr"`
+	TileWidth    int      `xml:"tilewidth,attr"`
+	TileHeight   int      `xml:"tileheight,attr"`
+	TileCount    int      `xml:"tilecount,attr"`
+	Columns      int      `xml:"columns,attr"`
+	Image        tsxImage `xml:"image"`
+}
+
+type tsxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+// TSX returns Tiled external tileset referencing tileset image by imageSource (relative to .tsx)
+func (t *Tileset) TSX(imageSource string) ([]byte, error) {
+	columns := t.GridColumns()
+	return encodeXML(tsxTileset{
+		Version:      tiledFormat,
+		TiledVersion: TiledVersion,
+		Name:         t.Name,
+		TileWidth:    t.TileWidth,
+		TileHeight:   t.TileHeight,
+		TileCount:    len(t.Tiles),
+		Columns:      columns,
+		Image: tsxImage{
+			Source: imageSource,
+			Width:  columns * t.TileWidth,
+			Height: t.GridRows() * t.TileHeight,
+		},
+	})
+}
+
+func encodeXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// fileSafeName replaces characters unsafe for filenames with underscores
+func fileSafeName(name string) string {
+	return unsafeNameChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,246 @@
+package upscale
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// source reads image pixels by coordinates relative to image origin, coordinates outside image are clamped
+// to edge pixels and fully transparent pixels are normalized, so they compare equal whatever their color
+type source struct {
+	img *image.NRGBA
+	w   int
+	h   int
+}
+
+func newSource(img *image.NRGBA) source {
+	return source{img: img, w: img.Rect.Dx(), h: img.Rect.Dy()}
+}
+
+func (s source) at(x, y int) color.NRGBA {
+	x = min(max(x, 0), s.w-1)
+	y = min(max(y, 0), s.h-1)
+	c := s.img.NRGBAAt(s.img.Rect.Min.X+x, s.img.Rect.Min.Y+y)
+	if c.A == 0 {
+		return color.NRGBA{}
+	}
+	return c
+}
+
+// scale2x is EPX/AdvMAME2x: output pixel takes color of two equal neighbours meeting at its corner
+func scale2x(img *image.NRGBA) *image.NRGBA {
+	src := newSource(img)
+	result := image.NewNRGBA(image.Rect(0, 0, src.w*2, src.h*2))
+
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			//   B
+			// D E F
+			//   H
+			b, d, e, f, h := src.at(x, y-1), src.at(x-1, y), src.at(x, y), src.at(x+1, y), src.at(x, y+1)
+			e0, e1, e2, e3 := e, e, e, e
+			if b != h && d != f {
+				if d == b {
+					e0 = d
+				}
+				if b == f {
+					e1 = f
+				}
+				if d == h {
+					e2 = d
+				}
+				if h == f {
+					e3 = f
+				}
+			}
+
+			result.SetNRGBA(x*2, y*2, e0)
+			result.SetNRGBA(x*2+1, y*2, e1)
+			result.SetNRGBA(x*2, y*2+1, e2)
+			result.SetNRGBA(x*2+1, y*2+1, e3)
+		}
+	}
+	return result
+}
+
+// scale3x is AdvMAME3x, 3x variant of scale2x also filling edge centers
+func scale3x(img *image.NRGBA) *image.NRGBA {
+	src := newSource(img)
+	result := image.NewNRGBA(image.Rect(0, 0, src.w*3, src.h*3))
+
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			// A B C
+			// D E F
+			// G H I
+			a, b, c := src.at(x-1, y-1), src.at(x, y-1), src.at(x+1, y-1)
+			d, e, f := src.at(x-1, y), src.at(x, y), src.at(x+1, y)
+			g, h, i := src.at(x-1, y+1), src.at(x, y+1), src.at(x+1, y+1)
+
+			out := [9]color.NRGBA{e, e, e, e, e, e, e, e, e}
+			if b != h && d != f {
+				if d == b {
+					out[0] = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					out[1] = b
+				}
+				if b == f {
+					out[2] = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					out[3] = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					out[5] = f
+				}
+				if d == h {
+					out[6] = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					out[7] = h
+				}
+				if h == f {
+					out[8] = f
+				}
+			}
+
+			for j, c := range out {
+				result.SetNRGBA(x*3+j%3, y*3+j/3, c)
+			}
+		}
+	}
+	return result
+}
+
+// hq2x is hqx style 2x magnification: neighbours are compared by YUV thresholds and output pixels
+// scale2x would replace on diagonal edges are interpolated instead, straight edges are kept sharp
+func hq2x(img *image.NRGBA) *image.NRGBA {
+	src := newSource(img)
+	result := image.NewNRGBA(image.Rect(0, 0, src.w*2, src.h*2))
+
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			for _, corner := range corners {
+				// neighbourhood is mirrored, so corner is always bottom right
+				at := func(dx, dy int) color.NRGBA { return src.at(x+dx*corner.X, y+dy*corner.Y) }
+				result.SetNRGBA(x*2+(corner.X+1)/2, y*2+(corner.Y+1)/2, hqCorner(at))
+			}
+		}
+	}
+	return result
+}
+
+func hqCorner(at func(dx, dy int) color.NRGBA) color.NRGBA {
+	//   B
+	// D E F
+	//   H I
+	b, d, e, f, h, i := at(0, -1), at(-1, 0), at(0, 0), at(1, 0), at(0, 1), at(1, 1)
+	if yuvDiffer(e, f) && yuvDiffer(e, h) && !yuvDiffer(f, h) && yuvDiffer(b, h) && yuvDiffer(d, f) {
+		if yuvDiffer(e, i) {
+			// edge runs across corner
+			return mix(weighted{e, 2}, weighted{f, 1}, weighted{h, 1})
+		}
+		// diagonal of pixels similar to E runs through corner
+		return mix(weighted{e, 6}, weighted{f, 1}, weighted{h, 1})
+	}
+	return e
+}
+
+// xbr is 2xBR: edge direction at every output pixel corner is detected by comparing weighted color
+// distances along both diagonals of 5x5 neighbourhood, pixels on the other side of edge are blended
+func xbr(img *image.NRGBA) *image.NRGBA {
+	src := newSource(img)
+	result := image.NewNRGBA(image.Rect(0, 0, src.w*2, src.h*2))
+
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			for _, corner := range corners {
+				// neighbourhood is mirrored, so corner is always bottom right
+				at := func(dx, dy int) color.NRGBA { return src.at(x+dx*corner.X, y+dy*corner.Y) }
+				result.SetNRGBA(x*2+(corner.X+1)/2, y*2+(corner.Y+1)/2, xbrCorner(at))
+			}
+		}
+	}
+	return result
+}
+
+func xbrCorner(at func(dx, dy int) color.NRGBA) color.NRGBA {
+	//    A1 B1 C1
+	// A0 A  B  C  C4
+	// D0 D  E  F  F4
+	// G0 G  H  I  I4
+	//    G5 H5 I5
+	b, c := at(0, -1), at(1, -1)
+	d, e, f := at(-1, 0), at(0, 0), at(1, 0)
+	g, h, i := at(-1, 1), at(0, 1), at(1, 1)
+	f4, i4 := at(2, 0), at(2, 1)
+	h5, i5 := at(0, 2), at(1, 2)
+
+	across := distance(e, c) + distance(e, g) + distance(i, f4) + distance(i, h5) + 4*distance(h, f)
+	along := distance(h, d) + distance(h, i5) + distance(f, i4) + distance(f, b) + 4*distance(e, i)
+	if across >= along {
+		return e
+	}
+
+	nearest := f
+	if distance(e, h) < distance(e, f) {
+		nearest = h
+	}
+	return mix(weighted{e, 1}, weighted{nearest, 1})
+}
+
+// corners are directions of output pixels of 2x magnification
+var corners = []image.Point{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}}
+
+func yuv(c color.NRGBA) (float64, float64, float64) {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	return 0.299*r + 0.587*g + 0.114*b, -0.169*r - 0.331*g + 0.5*b, 0.5*r - 0.419*g - 0.081*b
+}
+
+// yuvDiffer compares colors by hqx thresholds, alpha difference is compared with luma threshold
+func yuvDiffer(a, b color.NRGBA) bool {
+	if a == b {
+		return false
+	}
+	ya, ua, va := yuv(a)
+	yb, ub, vb := yuv(b)
+	return math.Abs(ya-yb) > 48 || math.Abs(ua-ub) > 7 || math.Abs(va-vb) > 6 ||
+		math.Abs(float64(a.A)-float64(b.A)) > 48
+}
+
+// distance is xBR weighted YUV distance with alpha
+func distance(a, b color.NRGBA) float64 {
+	ya, ua, va := yuv(a)
+	yb, ub, vb := yuv(b)
+	return 48*math.Abs(ya-yb) + 7*math.Abs(ua-ub) + 6*math.Abs(va-vb) + 48*math.Abs(float64(a.A)-float64(b.A))
+}
+
+type weighted struct {
+	c      color.NRGBA
+	weight int
+}
+
+// mix blends colors by weights in straight alpha, color channels are weighted by alpha,
+// so transparent pixels do not darken neighbours
+func mix(colors ...weighted) color.NRGBA {
+	var r, g, b, a, total int
+	for _, w := range colors {
+		alpha := int(w.c.A) * w.weight
+		r += int(w.c.R) * alpha
+		g += int(w.c.G) * alpha
+		b += int(w.c.B) * alpha
+		a += alpha
+		total += w.weight
+	}
+	if a == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8((r + a/2) / a),
+		G: uint8((g + a/2) / a),
+		B: uint8((b + a/2) / a),
+		A: uint8((a + total/2) / total),
+	}
+}
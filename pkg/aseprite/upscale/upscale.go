@@ -0,0 +1,172 @@
+package upscale
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+)
+
+// Upscaler is pixel art scaling algorithm applied to exported frames
+type Upscaler string
+
+const (
+	Nearest Upscaler = "nearest"
+	Scale2x Upscaler = "scale2x"
+	Scale3x Upscaler = "scale3x"
+	HQ2x    Upscaler = "hq2x"
+	XBR     Upscaler = "xbr"
+)
+
+// Upscalers returns names of supported upscalers
+func Upscalers() []string {
+	return []string{string(Nearest), string(Scale2x), string(Scale3x), string(HQ2x), string(XBR)}
+}
+
+// Parse parses upscaler name, empty name is nearest neighbour
+func Parse(name string) (Upscaler, error) {
+	if name == "" {
+		return Nearest, nil
+	}
+
+	u := Upscaler(strings.ToLower(strings.TrimSpace(name)))
+	switch u {
+	case Nearest, Scale2x, Scale3x, HQ2x, XBR:
+		return u, nil
+	}
+	return "", fmt.Errorf("unknown upscaler %q, available upscalers: %s", name, strings.Join(Upscalers(), ", "))
+}
+
+// IsNearest reports whether upscaler keeps aseprite nearest neighbour scaling as is
+func (u Upscaler) IsNearest() bool {
+	return u == "" || u == Nearest
+}
+
+// factor returns scale of one algorithm pass, larger scales are reached by repeated passes
+func (u Upscaler) factor() int {
+	switch u {
+	case Scale2x, HQ2x, XBR:
+		return 2
+	case Scale3x:
+		return 3
+	}
+	return 1
+}
+
+// passes returns number of algorithm passes giving scale
+func (u Upscaler) passes(scale int) (int, error) {
+	if scale < 1 {
+		return 0, fmt.Errorf("invalid scale %d", scale)
+	}
+
+	factor := u.factor()
+	if factor == 1 {
+		return 0, nil
+	}
+
+	passes := 0
+	for s := scale; s > 1; s /= factor {
+		if s%factor != 0 {
+			return 0, fmt.Errorf("%s upscaler supports scales that are powers of %d, got %d", u, factor, scale)
+		}
+		passes++
+	}
+	return passes, nil
+}
+
+// ValidateScale checks that upscaler can produce scale
+func (u Upscaler) ValidateScale(scale int) error {
+	_, err := u.passes(scale)
+	return err
+}
+
+// Upscale scales image by scale, scales of pass based algorithms must be powers of their factor
+func (u Upscaler) Upscale(img *image.NRGBA, scale int) (*image.NRGBA, error) {
+	passes, err := u.passes(scale)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.IsNearest() {
+		return nearest(img, scale), nil
+	}
+
+	pass := map[Upscaler]func(*image.NRGBA) *image.NRGBA{
+		Scale2x: scale2x,
+		Scale3x: scale3x,
+		HQ2x:    hq2x,
+		XBR:     xbr,
+	}[u]
+
+	for range passes {
+		img = pass(img)
+	}
+	return img, nil
+}
+
+// Downsample reverts nearest neighbour scaling by taking top left pixel of every factor x factor block
+func Downsample(img *image.NRGBA, factor int) (*image.NRGBA, error) {
+	bounds := img.Bounds()
+	if factor < 1 || bounds.Dx()%factor != 0 || bounds.Dy()%factor != 0 {
+		return nil, fmt.Errorf("image %dx%d is not scaled by %d", bounds.Dx(), bounds.Dy(), factor)
+	}
+	if factor == 1 {
+		return img, nil
+	}
+
+	result := image.NewNRGBA(image.Rect(0, 0, bounds.Dx()/factor, bounds.Dy()/factor))
+	for y := 0; y < result.Rect.Dy(); y++ {
+		for x := 0; x < result.Rect.Dx(); x++ {
+			result.SetNRGBA(x, y, img.NRGBAAt(bounds.Min.X+x*factor, bounds.Min.Y+y*factor))
+		}
+	}
+	return result, nil
+}
+
+// RescaleFile replaces nearest neighbour scaled png file with the same scale made by upscaler
+func (u Upscaler) RescaleFile(filename string, scale int) error {
+	if u.IsNearest() || scale == 1 {
+		return nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filename, err)
+	}
+
+	source, err := Downsample(postprocess.ToNRGBA(img), scale)
+	if err != nil {
+		return fmt.Errorf("failed to upscale %s: %w", filename, err)
+	}
+
+	result, err := u.Upscale(source, scale)
+	if err != nil {
+		return fmt.Errorf("failed to upscale %s: %w", filename, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, result); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filename, err)
+	}
+	return os.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+func nearest(img *image.NRGBA, scale int) *image.NRGBA {
+	bounds := img.Bounds()
+	result := image.NewNRGBA(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale))
+	for y := 0; y < result.Rect.Dy(); y++ {
+		for x := 0; x < result.Rect.Dx(); x++ {
+			result.SetNRGBA(x, y, img.NRGBAAt(bounds.Min.X+x/scale, bounds.Min.Y+y/scale))
+		}
+	}
+	return result
+}
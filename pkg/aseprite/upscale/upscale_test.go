@@ -0,0 +1,99 @@
+package upscale_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/upscale"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var red = color.NRGBA{R: 255, A: 255}
+
+// diagonal returns 5x5 image with red diagonal from (1, 1) to (3, 3) on transparent background
+func diagonal() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 5, 5))
+	for i := 1; i <= 3; i++ {
+		img.SetNRGBA(i, i, red)
+	}
+	return img
+}
+
+func TestParse(t *testing.T) {
+	u, err := upscale.Parse("")
+	require.NoError(t, err)
+	assert.True(t, u.IsNearest())
+
+	u, err = upscale.Parse("HQ2x")
+	require.NoError(t, err)
+	assert.Equal(t, upscale.HQ2x, u)
+
+	_, err = upscale.Parse("bicubic")
+	assert.Error(t, err)
+}
+
+func TestValidateScale(t *testing.T) {
+	assert.NoError(t, upscale.Nearest.ValidateScale(5))
+	assert.NoError(t, upscale.Scale2x.ValidateScale(1))
+	assert.NoError(t, upscale.Scale2x.ValidateScale(8))
+	assert.Error(t, upscale.Scale2x.ValidateScale(3))
+	assert.Error(t, upscale.XBR.ValidateScale(6))
+	assert.NoError(t, upscale.Scale3x.ValidateScale(9))
+	assert.Error(t, upscale.Scale3x.ValidateScale(2))
+}
+
+func TestUpscale(t *testing.T) {
+	for _, tc := range []struct {
+		upscaler upscale.Upscaler
+		scale    int
+	}{
+		{upscale.Nearest, 3},
+		{upscale.Scale2x, 4},
+		{upscale.Scale3x, 3},
+		{upscale.HQ2x, 2},
+		{upscale.XBR, 2},
+	} {
+		t.Run(string(tc.upscaler), func(t *testing.T) {
+			img, err := tc.upscaler.Upscale(diagonal(), tc.scale)
+			require.NoError(t, err)
+			require.Equal(t, image.Rect(0, 0, 5*tc.scale, 5*tc.scale), img.Rect)
+
+			// middle of diagonal stays red, pixels away from diagonal stay transparent
+			assert.Equal(t, red, img.NRGBAAt(2*tc.scale+tc.scale/2, 2*tc.scale+tc.scale/2))
+			assert.Equal(t, color.NRGBA{}, img.NRGBAAt(5*tc.scale-1, 0))
+			assert.Equal(t, color.NRGBA{}, img.NRGBAAt(0, 5*tc.scale-1))
+
+			// blended pixels keep red color, only alpha is interpolated
+			for i := 0; i < len(img.Pix); i += 4 {
+				if img.Pix[i+3] != 0 {
+					assert.Equal(t, []uint8{255, 0, 0}, img.Pix[i:i+3])
+				}
+			}
+		})
+	}
+}
+
+func TestScale2xSmoothsDiagonal(t *testing.T) {
+	img, err := upscale.Scale2x.Upscale(diagonal(), 2)
+	require.NoError(t, err)
+	// step between diagonal pixels is filled, nearest neighbour leaves it transparent
+	assert.Equal(t, red, img.NRGBAAt(4, 3))
+	assert.Equal(t, red, img.NRGBAAt(3, 4))
+	// pixels next to diagonal stay transparent
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(2, 4))
+	assert.Equal(t, color.NRGBA{}, img.NRGBAAt(4, 2))
+}
+
+func TestDownsample(t *testing.T) {
+	img, err := upscale.Nearest.Upscale(diagonal(), 4)
+	require.NoError(t, err)
+
+	source, err := upscale.Downsample(img, 4)
+	require.NoError(t, err)
+	assert.Equal(t, diagonal().Pix, source.Pix)
+
+	_, err = upscale.Downsample(img, 3)
+	assert.Error(t, err)
+}
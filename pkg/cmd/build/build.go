@@ -0,0 +1,352 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/engine"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/export"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/project"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type buildOptions struct {
+	ManifestPath string
+	DryRun       bool
+	Force        bool
+	Check        bool
+	NoCache      bool
+	Watch        bool
+	Jobs         int
+}
+
+func NewBuildCmd(env *environment.Environment) *cobra.Command {
+	opts := &buildOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "build [TARGET...]",
+		Short: "Export sprites with pipelines declared in project manifest",
+		Long: heredoc.Docf(`
+Export sprites with pipelines declared in project manifest (%s searched from working directory up to root).
+Without targets all manifest targets are built.
+
+Manifest example:
+
+	targets:
+	  - name: characters
+	    sources: [sprites/characters, "sprites/npc/*.aseprite"]
+	    out_dir: build/characters
+	    pipelines:
+	      - formats: [png, gif]
+	        scales: [1, 2]
+	        all_tags: true
+	        exclude_layers: [guides]
+	  - name: ui
+	    sources: [sprites/ui]
+	    pipelines:
+	      - output_template: "build/ui/{name}_{frame:02}.png"
+	        split_frames: true`, project.ManifestFilename),
+		Example: heredoc.Doc(`
+	# Build every target of manifest
+	aseprite-assets build
+
+	# Build only "ui" target
+	aseprite-assets build ui
+
+	# List outputs planned by manifest without exporting
+	aseprite-assets build --dry-run
+
+	# Build every target and rebuild sprites on every save
+	aseprite-assets build --watch`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := env.Config()
+			if err != nil {
+				return err
+			}
+
+			manifest, err := opts.loadManifest()
+			if err != nil {
+				return err
+			}
+
+			targets, err := manifest.SelectTargets(args)
+			if err != nil {
+				return err
+			}
+
+			if opts.DryRun {
+				jobs, engines, failed := planTargets(manifest, targets)
+				printPlan(jobs, engines)
+				if failed > 0 {
+					return fmt.Errorf("%d exports failed to be planned", failed)
+				}
+				return nil
+			}
+
+			cacheFile := manifest.CachePath(exporter.DefaultCacheFilename)
+			if opts.NoCache {
+				cacheFile = ""
+			}
+
+			cli := aseprite.NewCLI(cfg.AsepritePath, cfg.ScriptDirPath, cfg.FromSteam)
+			e, cache, err := export.NewCachedExporter(exporter.NewExporter(cli), cacheFile, opts.Force)
+			if err != nil {
+				return err
+			}
+
+			workers := opts.Jobs
+			if !cmd.Flags().Changed("jobs") && manifest.Jobs > 0 {
+				workers = manifest.Jobs
+			}
+
+			if opts.Watch {
+				return export.Watch(e, cache, watchGroups(manifest, targets), workers)
+			}
+
+			jobs, engines, failed := planTargets(manifest, targets)
+
+			if opts.Check {
+				if failed > 0 {
+					return fmt.Errorf("%d exports failed to be planned", failed)
+				}
+				return export.CheckJobs(e, jobs)
+			}
+
+			var jobsErr error
+			if len(jobs) > 0 || len(engines) == 0 {
+				jobsErr = export.RunJobs(e, cache, jobs, workers, failed)
+			} else if failed > 0 {
+				jobsErr = fmt.Errorf("%d exports failed to be planned", failed)
+			}
+			if enginesFailed := export.RunEngines(engines); enginesFailed > 0 {
+				return errors.Join(jobsErr, fmt.Errorf("%d of %d engines exports failed", enginesFailed, len(engines)))
+			}
+			return jobsErr
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			manifest, err := opts.loadManifest()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			var suggestions []string
+			for _, name := range manifest.TargetsNames() {
+				if strings.HasPrefix(name, toComplete) {
+					suggestions = append(suggestions, name)
+				}
+			}
+			return suggestions, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.ManifestPath, "manifest", "m", "", "project manifest path (found from working directory by default)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "list planned outputs without exporting")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "export even if sprites, options and outputs did not change since last build")
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "do not export, exit with error if any output is stale")
+	cmd.Flags().BoolVar(&opts.NoCache, "no-cache", false, "do not use build cache")
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", false, "keep running and rebuild sprites when they change on disk")
+	cmd.Flags().IntVarP(&opts.Jobs, "jobs", "j", runtime.NumCPU(), "number of concurrent aseprite processes (overrides manifest jobs)")
+
+	cmd.MarkFlagsMutuallyExclusive("force", "check")
+	cmd.MarkFlagsMutuallyExclusive("no-cache", "check")
+	cmd.MarkFlagsMutuallyExclusive("watch", "check", "dry-run")
+
+	return cmd
+}
+
+func (o *buildOptions) loadManifest() (*project.Manifest, error) {
+	path := o.ManifestPath
+	if path == "" {
+		var err error
+		if path, err = project.FindManifest("."); err != nil {
+			return nil, err
+		}
+	}
+
+	return project.LoadManifest(path)
+}
+
+// planTargets plans export jobs and engines exports of every target pipeline, failed plans are printed and counted
+func planTargets(manifest *project.Manifest, targets []*project.Target) ([]*exporter.Job, []engine.Params, int) {
+	var (
+		jobs    []*exporter.Job
+		engines []engine.Params
+		failed  int
+	)
+
+	for _, target := range targets {
+		sources, err := files.CollectSources(targetSources(manifest, target), aseprite.SpritesExtensions()...)
+		if err != nil {
+			utils.PrintError(fmt.Sprintf("✘ target %s: %v", target.Name, err))
+			failed++
+			continue
+		}
+
+		for _, source := range sources {
+			sourceJobs, sourceFailed := planSource(manifest, target, source)
+			jobs = append(jobs, sourceJobs...)
+			engines = append(engines, sourceEngines(manifest, target, source)...)
+			failed += sourceFailed
+		}
+	}
+
+	return jobs, engines, failed
+}
+
+// planSource plans export jobs of one target source for every pipeline and format
+func planSource(manifest *project.Manifest, target *project.Target, source files.Source) ([]*exporter.Job, int) {
+	var (
+		jobs   []*exporter.Job
+		failed int
+	)
+
+	for _, pipeline := range target.Pipelines {
+		for _, params := range pipelineParams(manifest, target, pipeline, source) {
+			job, err := exporter.Plan(params)
+			if err != nil {
+				failed++
+				utils.PrintError(fmt.Sprintf("✘ %s (target %s): %v", source.Path, target.Name, err))
+				continue
+			}
+
+			export.PrintDuplicates(job)
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, failed
+}
+
+// sourceEngines returns engines exports of target source for pipelines with engines
+func sourceEngines(manifest *project.Manifest, target *project.Target, source files.Source) []engine.Params {
+	var params []engine.Params
+	for _, pipeline := range target.Pipelines {
+		if len(pipeline.Engines) == 0 {
+			continue
+		}
+
+		imageFilename := files.ChangeFilenameExtension(source.Path, ".png")
+		if target.OutDir != "" {
+			imageFilename = filepath.Join(manifest.Path(target.OutDir), source.RelDir(), filepath.Base(imageFilename))
+		}
+
+		params = append(params, engine.Params{
+			SpriteFilename: source.Path,
+			ImageFilename:  imageFilename,
+			Engines:        pipeline.Engines,
+			Tags:           pipeline.Tags,
+			IncludeLayers:  pipeline.IncludeLayers,
+			ExcludeLayers:  pipeline.ExcludeLayers,
+			IncludeHidden:  pipeline.IncludeHidden,
+		})
+	}
+	return params
+}
+
+// watchGroups returns watched sources of every target planned by target pipelines
+func watchGroups(manifest *project.Manifest, targets []*project.Target) []export.WatchGroup {
+	var groups []export.WatchGroup
+	for _, target := range targets {
+		groups = append(groups, export.WatchGroup{
+			Sources: targetSources(manifest, target),
+			Plan: func(source files.Source) ([]*exporter.Job, int) {
+				return planSource(manifest, target, source)
+			},
+			Native: func(source files.Source) int {
+				return export.RunEngines(sourceEngines(manifest, target, source))
+			},
+		})
+	}
+	return groups
+}
+
+func targetSources(manifest *project.Manifest, target *project.Target) []string {
+	var sources []string
+	for _, source := range target.Sources {
+		sources = append(sources, manifest.Path(source))
+	}
+	return sources
+}
+
+// pipelineParams returns export params of source for every pipeline format
+func pipelineParams(manifest *project.Manifest, target *project.Target, pipeline *project.Pipeline, source files.Source) []exporter.Params {
+	base := exporter.Params{
+		SpriteFilename: source.Path,
+		FramesIncluded: pipeline.Frames,
+		IncludeLayers:  pipeline.IncludeLayers,
+		ExcludeLayers:  pipeline.ExcludeLayers,
+		IncludeHidden:  pipeline.IncludeHidden,
+		Tags:           pipeline.Tags,
+		AllTags:        pipeline.AllTags,
+		Scales:         strings.Join(pipeline.Scales, ","),
+		Sizes:          strings.Join(pipeline.Sizes, ","),
+		SplitLayers:    pipeline.SplitLayers,
+		SplitFrames:    pipeline.SplitFrames,
+		Upscaler:       pipeline.Upscaler,
+		Post:           pipeline.Post,
+		Optimize:       pipeline.Optimize,
+		LoopCount:      exporter.LoopCountTag,
+	}
+	if pipeline.LoopCount != nil {
+		base.LoopCount = *pipeline.LoopCount
+	}
+	for _, filename := range pipeline.PaletteLUT {
+		base.PaletteLUT = append(base.PaletteLUT, manifest.Path(filename))
+	}
+
+	if target.OutDir != "" {
+		base.OutputDir = filepath.Join(manifest.Path(target.OutDir), source.RelDir())
+	}
+
+	if pipeline.OutputTemplate != "" {
+		base.OutputTemplate = manifest.Template(pipeline.OutputTemplate)
+	}
+
+	if len(pipeline.Formats) == 0 {
+		if pipeline.OutputTemplate == "" {
+			// pipeline exports only engines sprite sheets
+			return nil
+		}
+		return []exporter.Params{base}
+	}
+
+	var params []exporter.Params
+	for _, format := range pipeline.Formats {
+		p := base
+		p.Format = format
+		if p.OutputTemplate != "" {
+			// format replaces template extension, so one template serves every format
+			p.OutputTemplate = files.ChangeFilenameExtension(p.OutputTemplate, format)
+		}
+		params = append(params, p)
+	}
+
+	return params
+}
+
+func printPlan(jobs []*exporter.Job, engines []engine.Params) {
+	var outputs int
+	for _, job := range jobs {
+		utils.PrintlnBold(job.Params.SpriteFilename)
+		for _, output := range job.Outputs {
+			fmt.Printf("  → %s\n", output)
+		}
+		outputs += len(job.Outputs)
+	}
+
+	for _, params := range engines {
+		utils.PrintlnBold(params.SpriteFilename)
+		fmt.Printf("  → %s (%s)\n", params.ImageFilename, strings.Join(params.Engines, ", "))
+	}
+
+	fmt.Printf("\n%d exports, %d outputs, %d engines sprite sheets planned\n", len(jobs), outputs, len(engines))
+}
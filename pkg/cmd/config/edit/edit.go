@@ -3,38 +3,50 @@ package edit
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/config"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/tui"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
 )
 
 func NewConfigEditCmd(env *environment.Environment) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "edit",
 		Short: "Modify configuration settings",
-		Long:  `Configure settings through flags or interactive TUI. Without flags, launches interactive configuration interface.`,
+		Long: heredoc.Doc(`
+Configure settings through flags or interactive TUI. Without flags, launches interactive configuration interface.
+
+Export presets used by "export --preset" are set with --preset <name>=<targets>, where targets are space
+separated <format>[@<scales or sizes>] (e.g. "png@1,2 webp@1" or "png@64x64,128x128"), and removed with
+--delete-preset <name>.`),
 		Example: heredoc.Doc(`aseprite-assets config edit --scripts-dir ./scripts
+aseprite-assets config edit --preset "web=png@1,2 webp@1" --preset "icons=png@16x16,32x32"
+aseprite-assets config edit --delete-preset web
 aseprite-assets config edit`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if scriptsDir, _ := cmd.Flags().GetString("scripts-dir"); scriptsDir != "" {
-				if scriptsDir == "default" {
-					err := config.SetDefaultScriptDirPath()
-					if err != nil {
-						return err
-					}
-					return nil
-				}
+			presets, _ := cmd.Flags().GetStringArray("preset")
+			deletedPresets, _ := cmd.Flags().GetStringArray("delete-preset")
+			scriptsDir, _ := cmd.Flags().GetString("scripts-dir")
 
-				if info, err := os.Stat(scriptsDir); err != nil || !info.IsDir() {
-					return fmt.Errorf("invalid scripts directory: %w", err)
+			if scriptsDir != "" {
+				if err := setScriptsDir(scriptsDir); err != nil {
+					return err
 				}
-
-				if err := config.SetScriptDirPath(scriptsDir); err != nil {
-					return fmt.Errorf("failed to set scripts directory: %w", err)
+			}
+			if len(presets) > 0 || len(deletedPresets) > 0 {
+				if err := editExportPresets(presets, deletedPresets); err != nil {
+					return err
 				}
+			}
+			if scriptsDir != "" || len(presets) > 0 || len(deletedPresets) > 0 {
 				return nil
 			}
 
@@ -53,6 +65,85 @@ aseprite-assets config edit`),
 
 	cmd.Flags().StringP("scripts-dir", "s", "",
 		"Set custom scripts directory path")
+	cmd.Flags().StringArray("preset", nil,
+		"Add or replace export preset as <name>=<targets> (e.g. \"web=png@1,2 webp@1\"), repeatable")
+	cmd.Flags().StringArray("delete-preset", nil,
+		"Remove export preset by name, repeatable")
 
 	return cmd
 }
+
+// setScriptsDir saves scripts directory, "default" restores the default one
+func setScriptsDir(scriptsDir string) error {
+	if scriptsDir == "default" {
+		return config.SetDefaultScriptDirPath()
+	}
+
+	if info, err := os.Stat(scriptsDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("invalid scripts directory: %w", err)
+	}
+
+	if err := config.SetScriptDirPath(scriptsDir); err != nil {
+		return fmt.Errorf("failed to set scripts directory: %w", err)
+	}
+	return nil
+}
+
+// editExportPresets validates and saves presets given as <name>=<targets>, then removes deleted ones
+func editExportPresets(presets, deleted []string) error {
+	for _, preset := range presets {
+		name, spec, found := strings.Cut(preset, "=")
+		if !found {
+			return fmt.Errorf("invalid preset %q, expected <name>=<targets>", preset)
+		}
+
+		targets, err := config.ParseExportPreset(spec)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			if err := validatePresetTarget(target); err != nil {
+				return fmt.Errorf("preset %q: %w", name, err)
+			}
+		}
+
+		if err := config.SetExportPreset(strings.TrimSpace(name), targets); err != nil {
+			return fmt.Errorf("failed to save export preset: %w", err)
+		}
+		utils.PrintlnSuccess(fmt.Sprintf("Export preset %s saved: %s", strings.ToLower(strings.TrimSpace(name)), joinTargets(targets)))
+	}
+
+	for _, name := range deleted {
+		if err := config.DeleteExportPreset(name); err != nil {
+			return err
+		}
+		utils.PrintlnSuccess(fmt.Sprintf("Export preset %s removed", name))
+	}
+
+	return nil
+}
+
+func validatePresetTarget(target config.ExportPresetTarget) error {
+	if !slices.Contains(aseprite.AvailableExportExtensions(), files.PrefExtension(target.Format)) {
+		return fmt.Errorf("invalid format %q, allowed: %v", target.Format, aseprite.AvailableExportExtensions())
+	}
+	if target.Scales != "" {
+		if err := exporter.ValidateScalesInput(target.Scales); err != nil {
+			return fmt.Errorf("invalid scales of %s: %w", target.Format, err)
+		}
+	}
+	if target.Sizes != "" {
+		if err := exporter.ValidateSizesInput(target.Sizes); err != nil {
+			return fmt.Errorf("invalid sizes of %s: %w", target.Format, err)
+		}
+	}
+	return nil
+}
+
+func joinTargets(targets []config.ExportPresetTarget) string {
+	var specs []string
+	for _, target := range targets {
+		specs = append(specs, target.String())
+	}
+	return strings.Join(specs, " ")
+}
@@ -0,0 +1,103 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// isBatch reports whether sprites sources need batch export instead of single sprite export
+func (o *exportOptions) isBatch(sources []string) bool {
+	if o.OutDir != "" || len(sources) > 1 {
+		return true
+	}
+
+	return len(sources) == 1 && (files.IsGlobPattern(sources[0]) || files.CheckFileExists(sources[0], true))
+}
+
+// exportBatch exports every sprite found in sources (files, directories or globs) on pool of aseprite processes
+func (h *exportHandler) exportBatch(sources []string) error {
+	opts := h.options
+
+	if opts.OutputFilename != "" {
+		return errors.New("cannot use --output-filename with several sprites, use --output-template or --out-dir")
+	}
+
+	if opts.OutputTemplate == "" && !opts.isMatrix() && !opts.IsFormatValid() {
+		return errors.New("format (or output template) required to export several sprites")
+	}
+
+	if len(sources) == 0 {
+		sources = h.config.SpritesFoldersPaths
+	}
+
+	sprites, err := files.CollectSources(sources, aseprite.SpritesExtensions()...)
+	if err != nil {
+		return err
+	}
+
+	e, cache, err := h.newExporter()
+	if err != nil {
+		return err
+	}
+
+	if opts.Watch {
+		return Watch(e, cache, []WatchGroup{{Sources: sources, Plan: h.planSprite}}, opts.Jobs)
+	}
+
+	var (
+		jobs   []*exporter.Job
+		failed int
+	)
+
+	for _, sprite := range sprites {
+		spriteJobs, spriteFailed := h.planSprite(sprite)
+		jobs = append(jobs, spriteJobs...)
+		failed += spriteFailed
+	}
+
+	if opts.Check {
+		if failed > 0 {
+			return fmt.Errorf("%d of %d sprites failed to plan export", failed, len(sprites))
+		}
+		return CheckJobs(e, jobs)
+	}
+
+	return RunJobs(e, cache, jobs, opts.Jobs, failed)
+}
+
+// planSprite plans export of sprite found in sources, with --out-dir its output directory mirrors sources tree
+func (h *exportHandler) planSprite(sprite files.Source) ([]*exporter.Job, int) {
+	var outputDir string
+	if h.options.OutDir != "" {
+		outputDir = filepath.Join(h.options.OutDir, sprite.RelDir())
+	}
+
+	var (
+		jobs   []*exporter.Job
+		failed int
+	)
+	for _, params := range h.options.matrixParams(sprite.Path, outputDir) {
+		job, err := exporter.Plan(params)
+		if err != nil {
+			label := sprite.Path
+			if h.options.isMatrix() {
+				label = fmt.Sprintf("%s (%s)", sprite.Path, params.Format)
+			}
+			utils.PrintError(fmt.Sprintf("✘ %s: %v", label, err))
+			failed++
+			continue
+		}
+
+		PrintDuplicates(job)
+		jobs = append(jobs, job)
+	}
+
+	printMatrixDuplicates(jobs)
+	return jobs, failed
+}
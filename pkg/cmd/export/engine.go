@@ -0,0 +1,88 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/engine"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// exportEngines writes sprite sheets with engines metadata of every sprite found in sources (natively, without aseprite)
+func (h *exportHandler) exportEngines(sources []string) error {
+	opts := h.options
+
+	if err := engine.ValidateEngines(opts.Engines); err != nil {
+		return err
+	}
+
+	if len(sources) == 0 {
+		sources = h.config.SpritesFoldersPaths
+	}
+
+	sprites, err := files.CollectSources(sources, aseprite.SpritesExtensions()...)
+	if err != nil {
+		return err
+	}
+
+	if opts.OutputFilename != "" && len(sprites) > 1 {
+		return errors.New("cannot use --output-filename with several sprites, use --out-dir")
+	}
+
+	var params []engine.Params
+	for _, sprite := range sprites {
+		params = append(params, opts.engineParams(sprite))
+	}
+
+	if failed := RunEngines(params); failed > 0 {
+		return fmt.Errorf("%d of %d sprites failed to export for engines", failed, len(params))
+	}
+	return nil
+}
+
+// engineParams returns engine export of sprite, sheet is written to output filename or next to sprite (mirrored in --out-dir)
+func (o *exportOptions) engineParams(sprite files.Source) engine.Params {
+	imageFilename := o.OutputFilename
+	if imageFilename == "" {
+		imageFilename = files.ChangeFilenameExtension(sprite.Path, ".png")
+		if o.OutDir != "" {
+			imageFilename = filepath.Join(o.OutDir, sprite.RelDir(), filepath.Base(imageFilename))
+		}
+	}
+
+	includeLayers := o.IncludeLayers
+	if o.SelectedLayer != "" {
+		includeLayers = append(includeLayers, o.SelectedLayer)
+	}
+
+	return engine.Params{
+		SpriteFilename: sprite.Path,
+		ImageFilename:  imageFilename,
+		Engines:        o.Engines,
+		Tags:           o.Tags,
+		IncludeLayers:  includeLayers,
+		ExcludeLayers:  o.ExcludeLayers,
+		IncludeHidden:  o.IncludeHidden,
+	}
+}
+
+// RunEngines writes sprite sheets and engines metadata, prints written files and returns number of failed sprites
+func RunEngines(params []engine.Params) int {
+	failed := 0
+	for _, p := range params {
+		written, err := engine.Export(p)
+		if err != nil {
+			failed++
+			utils.PrintError(fmt.Sprintf("✘ %s: %v", p.SpriteFilename, err))
+			continue
+		}
+
+		for _, filename := range written {
+			utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+		}
+	}
+	return failed
+}
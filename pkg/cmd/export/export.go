@@ -3,16 +3,22 @@ package export
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"slices"
-	"strconv"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
-	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/commands"
-	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/commands/helpers"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/engine"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/upscale"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/export/icons"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/config"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
@@ -36,18 +42,47 @@ type exportHandler struct {
 type exportOptions struct {
 	SpriteFilename string `survey:"sprite-filename"`
 	OutputFilename string `survey:"output-filename"`
+	OutputTemplate string
 	FramesIncluded string `survey:"frames-included"`
 	SelectedLayer  string
 	Format         string
+	Formats        []string
+	Preset         string
 	Sizes          string
 	Scales         string
+	Tags           []string
+	AllTags        bool
+	SplitLayers    bool
+	SplitFrames    bool
+	IncludeLayers  []string
+	ExcludeLayers  []string
+	IncludeHidden  bool
+	OutDir         string
+	Jobs           int
+	Force          bool
+	Check          bool
+	CacheFile      string
+	NoCache        bool
+	Watch          bool
+	Engines        []string
+	Slices         bool
+	SliceNames     []string
+	NinePatch      bool
+	Upscaler       string
+	Post           []string
+	Optimize       bool
+	LoopCount      int
+	PaletteLUT     []string
+
+	// variants are formats with scales or sizes of every sprite export, set when exporting several formats
+	variants []config.ExportPresetTarget
 }
 
 func NewExportCmd(env *environment.Environment) *cobra.Command {
 	options := &exportOptions{}
 
 	cmd := &cobra.Command{
-		Use:     "export [ARG]",
+		Use:     "export [SPRITES...]",
 		Aliases: []string{"exp", "e"},
 		Example: heredoc.Doc(`
 	# Export aseprite asset to png format
@@ -60,19 +95,112 @@ func NewExportCmd(env *environment.Environment) *cobra.Command {
 	aseprite-assets export <asset-filename> --format png --scales 1,2,3
 	
 	# Export aseprite asset to png format in sizes 64x64,128x128
-	aseprite-assets export <asset-filename> --format png --sizes 64x64,128x128`),
+	aseprite-assets export <asset-filename> --format png --sizes 64x64,128x128
+
+	# Export aseprite asset to png and webp formats in scales 1,2
+	aseprite-assets export <asset-filename> --format png --format webp --scales 1,2
+
+	# Export sprites with "web" preset of config (e.g. png in scales 1,2 and webp in scale 1)
+	aseprite-assets export ./sprites --preset web --out-dir ./out
+
+	# Export only frames of "walk" and "idle" tags (one output per tag)
+	aseprite-assets export <asset-filename> --format gif --tag walk --tag idle
+
+	# Export every tag of sprite to its own output
+	aseprite-assets export <asset-filename> --format gif --all-tags
+
+	# Export every frame of every tag to its own file using output template
+	aseprite-assets export <asset-filename> --all-tags --output-template "./out/{name}/{tag}_{frame:03}.png"
+
+	# Export every layer and every frame to its own file in scales 1,2
+	aseprite-assets export <asset-filename> --format png --frames '*' --split-layers --split-frames --scales 1,2
+
+	# Export layers of "body" group except "body/shadow" including hidden ones
+	aseprite-assets export <asset-filename> --format png --include-layer 'body/*' --exclude-layer body/shadow --include-hidden
+
+	# Export all sprites of configured sprites folders mirroring their tree in ./out
+	aseprite-assets export --format png --out-dir ./out
+
+	# Export sprites matched by glob and directory on 8 aseprite processes
+	aseprite-assets export "./sprites/ui/*.aseprite" ./sprites/characters --format png --scales 1,2 --out-dir ./out --jobs 8
+
+	# Check (e.g. on CI) that exported files match their sprites
+	aseprite-assets export ./sprites --format png --out-dir ./out --check
+
+	# Export sprite sheet with Godot SpriteFrames and Phaser atlas and animations of sprite tags
+	aseprite-assets export <asset-filename> --engine godot --engine phaser
+
+	# Export every slice to its own image (nine-patch slices as .9.png) with JSON sidecar of bounds, centers and pivots
+	aseprite-assets export <asset-filename> --slices --nine-patch --out-dir ./out/ui
+
+	# Export sprite scaled by 2 and 4 with scale2x pixel art upscaler instead of nearest neighbour
+	aseprite-assets export <asset-filename> --format png --scales 2,4 --upscaler scale2x
+
+	# Export trimmed sprite with 1px black outline padded to multiple of 8 pixels
+	aseprite-assets export <asset-filename> --format png --post trim --post outline:#000 --post pad:8
+
+	# Export sprites to losslessly optimized png files (indexed when sprite has at most 256 colors)
+	aseprite-assets export ./sprites --format png --out-dir ./out --optimize
+
+	# Export every tag to animated png with frame durations and tag direction, played 3 times
+	aseprite-assets export <asset-filename> --format apng --all-tags --scales 1,2 --loop-count 3
+
+	# Export index texture of indexed sprite with palette LUT of base palette and its red and blue variants
+	aseprite-assets export <asset-filename> --format png --palette-lut base --palette-lut red.gpl --palette-lut blue.gpl
+
+	# Export configured sprites folders and re-export sprites on every save
+	aseprite-assets export --format png --out-dir ./out --watch`),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := env.Config()
 			if err != nil {
 				return err
 			}
 
+			if cmd.Flags().Changed("frames") && options.usesTags() {
+				return errors.New("cannot combine --frames with --tag or --all-tags, tags define exported frames")
+			}
+
+			if err := options.resolveVariants(cfg); err != nil {
+				return err
+			}
+
+			for i, name := range options.PaletteLUT {
+				if options.PaletteLUT[i], err = palette.Find(name, cfg.PalettesFoldersPaths); err != nil {
+					return err
+				}
+			}
+
 			h := &exportHandler{
 				config:      cfg,
 				options:     options,
 				asepriteCli: aseprite.NewCLI(cfg.AsepritePath, cfg.ScriptDirPath, cfg.FromSteam),
 			}
 
+			sources := args
+			if options.SpriteFilename != "" {
+				sources = append(sources, options.SpriteFilename)
+			}
+
+			if options.Slices {
+				return h.exportSlices(sources)
+			}
+
+			if len(options.Engines) > 0 {
+				return h.exportEngines(sources)
+			}
+
+			if options.isBatch(sources) {
+				return h.exportBatch(sources)
+			}
+
+			if len(sources) == 1 {
+				options.SpriteFilename = sources[0]
+			}
+
+			if options.isMatrix() {
+				return h.exportMatrix(options.SpriteFilename)
+			}
+
 			if h.options.needsSurvey() {
 				utils.PrintlnBold("Do not have enough data to export sprite\n")
 				if err := h.collect(); err != nil {
@@ -86,63 +214,111 @@ func NewExportCmd(env *environment.Environment) *cobra.Command {
 			}
 			return nil
 		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, aseprite.SpritesExtensions())(c, args, toComplete)
+		},
 	}
 
 	cmd.Flags().StringVarP(&options.SpriteFilename, "sprite-filename", "s", "", "aseprite asset filename")
 	cmd.Flags().StringVarP(&options.OutputFilename, "output-filename", "o", "", "output filename")
-	cmd.Flags().StringVarP(&options.Format, "format", "f", "", "output format")
-	cmd.Flags().StringVarP(&options.SelectedLayer, "layer", "l", "", "separate layer name to export")
+	cmd.Flags().StringVar(&options.OutputTemplate, "output-template", "", "output filename template with placeholders {name}, {dir}, {layer}, {tag}, {frame}, {frame:03}, {scale}, {size}")
+	cmd.Flags().StringSliceVarP(&options.Formats, "format", "f", nil, "output format, repeatable (each format is exported to its own output)")
+	cmd.Flags().StringVar(&options.Preset, "preset", "", "export preset of config (formats with scales or sizes, see \"config edit --preset\")")
+	cmd.Flags().StringVarP(&options.SelectedLayer, "layer", "l", "", "separate layer name or group path (e.g. \"body/arm\") to export")
 	cmd.Flags().StringVar(&options.Sizes, "sizes", "", "comma separated list of sizes (e.g., \"64x64,128x128\")")
 	cmd.Flags().StringVar(&options.Scales, "scales", "", "comma separated list of scales (e.g., \"1,2,3\")")
 	cmd.Flags().StringVar(&options.FramesIncluded, "frames", "0", "frames included template - zero based (e.g. '0:2', '0', '*'")
 
-	_ = cmd.RegisterFlagCompletionFunc("layer", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		writtenFilename := options.SpriteFilename
-		if writtenFilename == "" {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
+	cmd.Flags().StringSliceVarP(&options.Tags, "tag", "t", nil, "tag name to export, repeatable (each tag is exported to its own output)")
+	cmd.Flags().BoolVar(&options.AllTags, "all-tags", false, "export every sprite tag to its own output")
 
-		if !files.CheckFileExists(writtenFilename, false) || !files.CheckFileExtension(writtenFilename, aseprite.SpritesExtensions()...) {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
+	cmd.Flags().BoolVar(&options.SplitLayers, "split-layers", false, "export every visible layer to its own file (layers of groups are named by path, e.g. body-arm)")
+	cmd.Flags().BoolVar(&options.SplitFrames, "split-frames", false, "export every frame to its own file")
+
+	cmd.Flags().StringArrayVar(&options.IncludeLayers, "include-layer", nil, "glob of layer names or group paths to export (e.g. \"body/*\"), repeatable")
+	cmd.Flags().StringArrayVar(&options.ExcludeLayers, "exclude-layer", nil, "glob of layer names or group paths to skip, repeatable")
+	cmd.Flags().BoolVar(&options.IncludeHidden, "include-hidden", false, "export hidden layers too")
+
+	cmd.Flags().StringVar(&options.OutDir, "out-dir", "", "output directory mirroring sprites sources tree (exports several sprites)")
+	cmd.Flags().IntVarP(&options.Jobs, "jobs", "j", runtime.NumCPU(), "number of concurrent aseprite processes for several sprites export")
+
+	cmd.Flags().BoolVar(&options.Force, "force", false, "export even if sprite, options and outputs did not change since last export")
+	cmd.Flags().BoolVar(&options.Check, "check", false, "do not export, exit with error if any output is stale")
+	cmd.Flags().StringVar(&options.CacheFile, "cache-file", exporter.DefaultCacheFilename, "build cache file with hashes of exported outputs")
+	cmd.Flags().BoolVar(&options.NoCache, "no-cache", false, "do not use build cache")
 
+	cmd.Flags().BoolVarP(&options.Watch, "watch", "w", false, "keep running and re-export sprites when they change on disk")
+
+	cmd.Flags().StringSliceVar(&options.Engines, "engine", nil, fmt.Sprintf("write sprite sheet with animations metadata of engine (%s), repeatable", strings.Join(engine.Engines(), ", ")))
+
+	cmd.Flags().BoolVar(&options.Slices, "slices", false, "export every slice to its own png with <sprite>.slices.json sidecar (bounds, centers, pivots per key)")
+	cmd.Flags().StringSliceVar(&options.SliceNames, "slice", nil, "slice name to export with --slices, repeatable (all slices by default)")
+	cmd.Flags().BoolVar(&options.NinePatch, "nine-patch", false, "write slices with 9-slice center as Android nine-patch .9.png")
+
+	cmd.Flags().StringVar(&options.Upscaler, "upscaler", string(upscale.Nearest), fmt.Sprintf("algorithm of scaled png outputs (%s), scale2x, hq2x and xbr need powers of 2 scales, scale3x powers of 3", strings.Join(upscale.Upscalers(), ", ")))
+	cmd.Flags().StringArrayVar(&options.Post, "post", nil, fmt.Sprintf("post-processing operation applied to every png output in order, repeatable: %s", strings.Join(postprocess.Operations(), ", ")))
+
+	cmd.Flags().BoolVar(&options.Optimize, "optimize", false, "losslessly recompress png outputs (indexed colors when possible, stripped metadata, best filter per row)")
+
+	cmd.Flags().IntVar(&options.LoopCount, "loop-count", exporter.LoopCountTag, "number of apng plays, 0 loops forever (tag repeat count by default)")
+
+	cmd.Flags().StringSliceVar(&options.PaletteLUT, "palette-lut", nil, "palette of configured palettes folders (name or path), repeatable: write index textures of the first (base) palette with <sprite>_lut.png of every palette row")
+
+	cmd.MarkFlagsMutuallyExclusive("output-filename", "output-template")
+	cmd.MarkFlagsMutuallyExclusive("force", "check")
+	cmd.MarkFlagsMutuallyExclusive("no-cache", "check")
+	cmd.MarkFlagsMutuallyExclusive("layer", "split-layers")
+	cmd.MarkFlagsMutuallyExclusive("watch", "check")
+	for _, flag := range []string{"format", "scales", "sizes", "engine", "slices"} {
+		cmd.MarkFlagsMutuallyExclusive("preset", flag)
+	}
+	for _, flag := range []string{"output-template", "split-layers", "split-frames", "scales", "sizes", "check", "watch", "upscaler", "post", "optimize", "loop-count", "palette-lut"} {
+		cmd.MarkFlagsMutuallyExclusive("engine", flag)
+		cmd.MarkFlagsMutuallyExclusive("slices", flag)
+	}
+	for _, flag := range []string{"engine", "output-filename", "format", "tag", "all-tags"} {
+		cmd.MarkFlagsMutuallyExclusive("slices", flag)
+	}
+
+	_ = cmd.RegisterFlagCompletionFunc("layer", options.spriteNamesCompletion(exporter.LayersPaths))
+	_ = cmd.RegisterFlagCompletionFunc("include-layer", options.spriteNamesCompletion(exporter.LayersPaths))
+	_ = cmd.RegisterFlagCompletionFunc("exclude-layer", options.spriteNamesCompletion(exporter.LayersPaths))
+	_ = cmd.RegisterFlagCompletionFunc("slice", options.spriteNamesCompletion(exporter.SlicesNames))
+	_ = cmd.RegisterFlagCompletionFunc("engine", cobra.FixedCompletions(engine.Engines(), cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("upscaler", cobra.FixedCompletions(upscale.Upscalers(), cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("tag", options.spriteNamesCompletion(exporter.TagsNames))
+	_ = cmd.RegisterFlagCompletionFunc("palette-lut", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		cfg, err := env.Config()
 		if err != nil {
-			return nil, cobra.ShellCompDirectiveNoFileComp
+			return nil, cobra.ShellCompDirectiveError
 		}
-
-		aseCli := aseprite.NewCLI(cfg.AsepritePath, cfg.ScriptDirPath, cfg.FromSteam)
-		cmdLayers := &helpers.SpriteLayersNames{SpriteFilename: writtenFilename}
-		output, err := aseCli.ExecuteCommandOutput(cmdLayers)
+		return autocomp.GenerateFilesAutoCompletions(cfg.PalettesFoldersPaths, palette.Extensions())(c, args, toComplete)
+	})
+	_ = cmd.RegisterFlagCompletionFunc("preset", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := env.Config()
 		if err != nil {
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		}
-
-		layers := strings.Split(strings.TrimSpace(output), "\n")
-		var suggestions []string
-		for _, layer := range layers {
-			if layer != "" && strings.HasPrefix(layer, toComplete) {
-				suggestions = append(suggestions, layer)
-			}
+			return nil, cobra.ShellCompDirectiveError
 		}
-
-		return suggestions, cobra.ShellCompDirectiveNoFileComp
+		return cfg.ExportPresetsNames(), cobra.ShellCompDirectiveNoFileComp
 	})
 
+	cmd.AddCommand(icons.NewExportIconsCmd(env))
+
 	return cmd
 }
 
 func (h *exportHandler) collectSpriteLayers() error {
-	spriteLayersCmd := &helpers.SpriteLayersNames{
-		SpriteFilename: h.options.SpriteFilename,
-	}
-
-	output, err := h.asepriteCli.ExecuteCommandOutput(spriteLayersCmd)
+	sprite, err := asefile.ReadFile(h.options.SpriteFilename)
 	if err != nil {
-		return errors.New("Failed to export sprite layers: " + err.Error())
+		return errors.New("Failed to read sprite layers: " + err.Error())
 	}
 
-	h.spriteLayers = strings.Split(output, "\n")
+	h.spriteLayers = exporter.LayersPaths(sprite)
 
 	if len(h.spriteLayers) == 0 {
 		return errors.New("failed to export sprite layers")
@@ -153,70 +329,98 @@ func (h *exportHandler) collectSpriteLayers() error {
 
 func (h *exportHandler) export() error {
 	opts := h.options
+	opts.SelectedLayer = strings.TrimSuffix(opts.SelectedLayer, "\r")
 
-	if opts.Scales != "" && opts.Sizes != "" {
-		return fmt.Errorf("cannot specify both scales and sizes, choose one")
+	if opts.Watch {
+		e, cache, err := h.newExporter()
+		if err != nil {
+			return err
+		}
+		return Watch(e, cache, []WatchGroup{{Sources: []string{opts.SpriteFilename}, Plan: h.planSprite}}, opts.Jobs)
 	}
 
-	if !opts.IsSpriteFilenameValid() {
-		return fmt.Errorf("invalid sprite filename: %q", opts.SpriteFilename)
+	job, err := exporter.Plan(opts.params(opts.SpriteFilename, ""))
+	if err != nil {
+		return err
 	}
 
-	outputPath := opts.OutputFilename
-	if outputPath == "" {
-		if !opts.IsFormatValid() {
-			return errors.New("format required when output filename is not specified")
-		}
+	PrintDuplicates(job)
 
-		outputPath = files.ChangeFilenameExtension(opts.SpriteFilename, opts.Format)
+	e, cache, err := h.newExporter()
+	if err != nil {
+		return err
 	}
 
-	if err := files.EnsureDirExists(outputPath); err != nil {
-		return fmt.Errorf("failed to sprite output directory: %w", err)
+	if opts.Check {
+		return CheckJobs(e, []*exporter.Job{job})
 	}
 
-	fmt.Printf("Exporting sprite: %s to output: %s\n", opts.SpriteFilename, outputPath)
-
-	exportCmd := &commands.ExportSprite{
-		SpriteFilename: opts.SpriteFilename,
-		OutputFilename: outputPath,
-		Format:         opts.Format,
-		FramesIncluded: opts.FramesIncluded,
+	fmt.Printf("Exporting sprite: %s to output: %s\n", opts.SpriteFilename, job.Template)
+	if len(job.Tags) > 0 {
+		fmt.Printf("Exporting tags: %s\n", strings.Join(job.Tags, ", "))
 	}
 
-	if len(opts.SelectedLayer) != 0 {
-		if strings.HasSuffix(opts.SelectedLayer, "\r") {
-			opts.SelectedLayer = opts.SelectedLayer[:len(opts.SelectedLayer)-1]
-		}
-
-		exportCmd.SelectedLayerName = opts.SelectedLayer
+	result := e.Run(job)
+	if err := saveCache(cache); err != nil {
+		return err
 	}
 
-	switch {
-	case opts.Scales != "":
-		if err := ValidateScalesInput(opts.Scales); err != nil {
-			return fmt.Errorf("invalid scales input: %w", err)
-		}
-		exportCmd.Scales = opts.Scales
-
-	case opts.Sizes != "":
-		if err := ValidateSizesInput(opts.Sizes); err != nil {
-			return fmt.Errorf("invalid sizes input: %w", err)
-		}
-		exportCmd.Sizes = opts.Sizes
+	if result.Err != nil {
+		return result.Err
+	}
 
+	if result.Skipped {
+		utils.PrintlnSuccess("Outputs are up to date, nothing to export (use --force to export anyway)")
+		return nil
 	}
 
-	output, err := h.asepriteCli.ExecuteCommandOutput(exportCmd)
+	if result.Output != "" {
+		fmt.Printf("Export result:\n%s", result.Output)
+	}
+	for _, r := range result.Optimized {
+		fmt.Println(r)
+	}
+	if len(result.Optimized) > 0 {
+		PrintOptimizedTotal(result.Optimized)
+	}
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to export sprite: %w", err)
+// newExporter creates exporter using build cache unless it is disabled
+func (h *exportHandler) newExporter() (*exporter.Exporter, *exporter.Cache, error) {
+	cacheFile := h.options.CacheFile
+	if h.options.NoCache {
+		cacheFile = ""
 	}
 
-	if output != "" {
-		fmt.Printf("Export result:\n%s", output)
+	return NewCachedExporter(exporter.NewExporter(h.asepriteCli), cacheFile, h.options.Force)
+}
+
+// params converts options to export params of sprite, outputDir replaces sprite directory in outputs
+func (o *exportOptions) params(spriteFilename, outputDir string) exporter.Params {
+	return exporter.Params{
+		SpriteFilename: spriteFilename,
+		OutputFilename: o.OutputFilename,
+		OutputTemplate: o.OutputTemplate,
+		OutputDir:      outputDir,
+		Format:         o.Format,
+		FramesIncluded: o.FramesIncluded,
+		SelectedLayer:  o.SelectedLayer,
+		IncludeLayers:  o.IncludeLayers,
+		ExcludeLayers:  o.ExcludeLayers,
+		IncludeHidden:  o.IncludeHidden,
+		Tags:           o.Tags,
+		AllTags:        o.AllTags,
+		Scales:         o.Scales,
+		Sizes:          o.Sizes,
+		SplitLayers:    o.SplitLayers,
+		SplitFrames:    o.SplitFrames,
+		Upscaler:       o.Upscaler,
+		Post:           o.Post,
+		Optimize:       o.Optimize,
+		LoopCount:      o.LoopCount,
+		PaletteLUT:     o.PaletteLUT,
 	}
-	return nil
 }
 
 func (h *exportHandler) collect() error {
@@ -229,10 +433,16 @@ func (h *exportHandler) collect() error {
 		return err
 	}
 
-	if err := o.collectFramesInfo(); err != nil {
+	if err := o.collectTagsInfo(); err != nil {
 		return err
 	}
 
+	if !o.usesTags() {
+		if err := o.collectFramesInfo(); err != nil {
+			return err
+		}
+	}
+
 	err := h.collectSpriteLayers()
 	if err != nil {
 		return err
@@ -264,6 +474,10 @@ func (o *exportOptions) collectSourceInfo(cfg *config.Config) error {
 }
 
 func (o *exportOptions) collectOutputInfo() error {
+	if o.OutputTemplate != "" {
+		return nil
+	}
+
 	if o.IsOutputFilenameValid() {
 		o.Format = files.GetFileExtension(o.OutputFilename)
 		return nil
@@ -289,7 +503,7 @@ func (o *exportOptions) collectFramesInfo() error {
 		&o.FramesIncluded,
 		survey.WithValidator(func(ans interface{}) error {
 			input := ans.(string)
-			return ValidateFramesInput(input)
+			return exporter.ValidateFramesInput(input)
 		}),
 	); err != nil {
 		return err
@@ -509,7 +723,7 @@ func (o *exportOptions) sizeSuggestions() func(string) []string {
 }
 
 func (o *exportOptions) IsOutputInfoValid() bool {
-	return o.IsOutputFilenameValid() || o.IsFormatValid()
+	return o.OutputTemplate != "" || o.IsOutputFilenameValid() || o.IsFormatValid()
 }
 
 func (o *exportOptions) IsSpriteFilenameValid() bool {
@@ -534,7 +748,7 @@ func ValidateScalesInputValidator(ans interface{}) error {
 		return errors.New("invalid scales input type")
 	}
 
-	return ValidateScalesInput(input)
+	return exporter.ValidateScalesInput(input)
 }
 
 func ValidateSizesInputValidator(ans interface{}) error {
@@ -543,101 +757,7 @@ func ValidateSizesInputValidator(ans interface{}) error {
 		return errors.New("invalid sizes input type")
 	}
 
-	return ValidateSizesInput(input)
-}
-
-func ValidateScalesInput(input string) error {
-	if input == "" {
-		return errors.New("scales cannot be empty")
-	}
-
-	elements := strings.Split(input, ",")
-
-	if err := ValidateNumberList(elements); err == nil {
-		return nil
-	}
-
-	return errors.New("invalid format: scales must be a comma-separated list of numbers (e.g., \"1,2,3\")")
-}
-
-func ValidateSizesInput(input string) error {
-	if input == "" {
-		return errors.New("sizes cannot be empty")
-	}
-
-	elements := strings.Split(input, ",")
-
-	if err := ValidatePairList(elements); err == nil {
-		return nil
-	}
-
-	return errors.New("invalid format: sizes must be a comma-separated list of pairs (e.g., \"64x64,128x128\")")
-}
-
-func ValidateNumberList(elements []string) error {
-	for _, elem := range elements {
-		elem = strings.TrimSpace(elem)
-		if _, err := strconv.Atoi(elem); err != nil {
-			return fmt.Errorf("invalid number: %q", elem)
-		}
-	}
-	return nil
-}
-
-func ValidatePairList(elements []string) error {
-	for _, elem := range elements {
-		elem = strings.TrimSpace(elem)
-		parts := strings.Split(elem, "x")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid pair format: %q", elem)
-		}
-
-		// Validate width
-		if _, err := strconv.Atoi(strings.TrimSpace(parts[0])); err != nil {
-			return fmt.Errorf("invalid number in pair: %q", parts[0])
-		}
-
-		// Validate height
-		if _, err := strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
-			return fmt.Errorf("invalid number in pair: %q", parts[1])
-		}
-	}
-	return nil
-}
-
-func ValidateFramesInput(input string) error {
-	if input == "" {
-		return errors.New("frames cannot be empty")
-	}
-
-	if input == "*" {
-		return nil
-	}
-
-	if _, err := strconv.Atoi(input); err == nil {
-		return nil
-	}
-
-	parts := strings.Split(input, ":")
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid frames format: %s", input)
-	}
-
-	start, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return fmt.Errorf("invalid start frame: %s", parts[0])
-	}
-
-	end, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return fmt.Errorf("invalid end frame: %s", parts[1])
-	}
-
-	if start > end {
-		return fmt.Errorf("start frame %d > end frame %d", start, end)
-	}
-
-	return nil
+	return exporter.ValidateSizesInput(input)
 }
 
 func filterSuggestions(input string, options []string) []string {
@@ -653,7 +773,7 @@ func filterSuggestions(input string, options []string) []string {
 func (o *exportOptions) needsSurvey() bool {
 	return !o.IsSpriteFilenameValid() ||
 		!o.IsOutputInfoValid() ||
-		ValidateFramesInput(o.FramesIncluded) != nil ||
-		(o.Scales != "" && ValidateScalesInput(o.Scales) != nil) ||
-		(o.Sizes != "" && ValidateSizesInput(o.Sizes) != nil)
+		exporter.ValidateFramesInput(o.FramesIncluded) != nil ||
+		(o.Scales != "" && exporter.ValidateScalesInput(o.Scales) != nil) ||
+		(o.Sizes != "" && exporter.ValidateSizesInput(o.Sizes) != nil)
 }
@@ -0,0 +1,133 @@
+package icons
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/icons"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+)
+
+type iconsOptions struct {
+	OutDir        string
+	Frame         int
+	Layer         string
+	IncludeHidden bool
+	Targets       []string
+	Sizes         string
+	Trim          bool
+	Background    string
+}
+
+func NewExportIconsCmd(env *environment.Environment) *cobra.Command {
+	opts := &iconsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "icons <SPRITE>",
+		Short: "Export sprite frame to .ico, favicons and Android/iOS icon sets",
+		Long: heredoc.Doc(`
+Render sprite frame (without Aseprite) and write it to icon sets of every target to <sprite>_icons directory:
+  ico      <sprite>.ico with --sizes images (16 to 256 pixels)
+  favicon  favicon/ with favicon.ico, png favicons, apple-touch-icon, site.webmanifest and head.html snippet
+  android  android/mipmap-<density>/ic_launcher.png of mdpi to xxxhdpi densities and play store icon
+  ios      ios/AppIcon.appiconset with iPhone, iPad and App Store icons and Contents.json
+
+Frame is scaled by the largest integer factor fitting every icon and centered on --background padding, so pixel
+art stays sharp. Icons smaller than frame are downscaled with nearest neighbour and reported. iOS icons cannot
+be transparent, they are flattened onto --background (white when it is not set).`),
+		Example: heredoc.Doc(`
+	# Write every icon set of 32x32 sprite next to it
+	aseprite-assets export icons ./sprites/logo.aseprite
+
+	# Write only windows icon of trimmed second frame with custom sizes
+	aseprite-assets export icons logo.aseprite --target ico --sizes 16x16,32x32,48x48 --frame 1 --trim
+
+	# Write mobile icon sets of "icon" layer on dark background
+	aseprite-assets export icons logo.aseprite --target android --target ios --layer icon --background "#1d2b53" --out-dir ./build/icons`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := opts.params(args[0])
+			if err != nil {
+				return err
+			}
+
+			result, err := exporter.ExportIcons(params)
+			for _, filename := range result.Written {
+				utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(result.Downscaled) > 0 {
+				sizes := make([]string, len(result.Downscaled))
+				for i, size := range result.Downscaled {
+					sizes[i] = fmt.Sprintf("%dx%d", size, size)
+				}
+				utils.PrintlnWarning(fmt.Sprintf("Icons %s are smaller than sprite frame and were downscaled", strings.Join(sizes, ", ")))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory of icon sets (<sprite>_icons next to sprite by default)")
+	cmd.Flags().IntVar(&opts.Frame, "frame", 0, "zero based frame rendered to icons")
+	cmd.Flags().StringVarP(&opts.Layer, "layer", "l", "", "layer name or group path (e.g. \"body/*\") rendered to icons, every visible layer by default")
+	cmd.Flags().BoolVar(&opts.IncludeHidden, "include-hidden", false, "render hidden layers too")
+	cmd.Flags().StringSliceVarP(&opts.Targets, "target", "t", nil, fmt.Sprintf("icon set to write (%s), repeatable, every set by default", strings.Join(icons.Targets(), ", ")))
+	cmd.Flags().StringVar(&opts.Sizes, "sizes", "", "comma separated list of square .ico sizes up to 256x256 (e.g., \"16x16,32x32,256x256\")")
+	cmd.Flags().BoolVar(&opts.Trim, "trim", false, "remove transparent margins of frame before scaling")
+	cmd.Flags().StringVar(&opts.Background, "background", "", "padding color as #rrggbb or #rrggbbaa, transparent by default")
+
+	_ = cmd.RegisterFlagCompletionFunc("target", cobra.FixedCompletions(icons.Targets(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// params validates options and converts them to icons export params of sprite
+func (o *iconsOptions) params(spriteFilename string) (exporter.IconParams, error) {
+	targets, err := icons.ParseTargets(o.Targets)
+	if err != nil {
+		return exporter.IconParams{}, err
+	}
+
+	var sizes []string
+	if o.Sizes != "" {
+		if err := exporter.ValidateSizesInput(o.Sizes); err != nil {
+			return exporter.IconParams{}, err
+		}
+		sizes = strings.Split(o.Sizes, ",")
+	}
+	icoSizes, err := icons.ParseSizes(sizes)
+	if err != nil {
+		return exporter.IconParams{}, err
+	}
+
+	background, err := postprocess.ParseColor(o.Background, color.NRGBA{})
+	if err != nil {
+		return exporter.IconParams{}, err
+	}
+
+	var includeLayers []string
+	if o.Layer != "" {
+		includeLayers = []string{o.Layer}
+	}
+
+	return exporter.IconParams{
+		SpriteFilename: spriteFilename,
+		OutputDir:      o.OutDir,
+		Frame:          o.Frame,
+		Targets:        targets,
+		Sizes:          icoSizes,
+		Trim:           o.Trim,
+		Background:     background,
+		IncludeLayers:  includeLayers,
+		IncludeHidden:  o.IncludeHidden,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/config"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// resolveVariants expands --preset targets or several --format values (with --scales or --sizes) to export
+// variants. Single variant is kept in Format, Scales and Sizes options, so single format export stays interactive
+func (o *exportOptions) resolveVariants(cfg *config.Config) error {
+	var variants []config.ExportPresetTarget
+	if o.Preset != "" {
+		// --format, --scales and --sizes are mutually exclusive with --preset, so targets lose none of them
+		targets, err := cfg.ExportPreset(o.Preset)
+		if err != nil {
+			return err
+		}
+		variants = targets
+	} else {
+		for _, format := range o.Formats {
+			variants = append(variants, config.ExportPresetTarget{Format: format, Scales: o.Scales, Sizes: o.Sizes})
+		}
+	}
+
+	if len(variants) == 1 {
+		o.Format, o.Scales, o.Sizes = variants[0].Format, variants[0].Scales, variants[0].Sizes
+		return nil
+	}
+
+	for _, variant := range variants {
+		if !slices.Contains(aseprite.AvailableExportExtensions(), files.PrefExtension(variant.Format)) {
+			return fmt.Errorf("invalid format %q, allowed: %v", variant.Format, aseprite.AvailableExportExtensions())
+		}
+	}
+	o.variants = variants
+	return nil
+}
+
+// isMatrix reports whether every sprite is exported in several variants
+func (o *exportOptions) isMatrix() bool {
+	return len(o.variants) > 1
+}
+
+// matrixParams returns export params of sprite for every variant, format replaces extension of
+// output filename and template, so one output serves every format
+func (o *exportOptions) matrixParams(spriteFilename, outputDir string) []exporter.Params {
+	base := o.params(spriteFilename, outputDir)
+	if !o.isMatrix() {
+		return []exporter.Params{base}
+	}
+
+	var params []exporter.Params
+	for _, variant := range o.variants {
+		p := base
+		p.Format, p.Scales, p.Sizes = variant.Format, variant.Scales, variant.Sizes
+		if p.OutputTemplate != "" {
+			p.OutputTemplate = files.ChangeFilenameExtension(p.OutputTemplate, variant.Format)
+		}
+		if p.OutputFilename != "" {
+			p.OutputFilename = files.ChangeFilenameExtension(p.OutputFilename, variant.Format)
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// exportMatrix exports one sprite in every variant on pool of aseprite processes
+func (h *exportHandler) exportMatrix(spriteFilename string) error {
+	opts := h.options
+	if spriteFilename == "" {
+		return errors.New("sprite filename required to export several formats or preset")
+	}
+
+	e, cache, err := h.newExporter()
+	if err != nil {
+		return err
+	}
+
+	if opts.Watch {
+		return Watch(e, cache, []WatchGroup{{Sources: []string{spriteFilename}, Plan: h.planSprite}}, opts.Jobs)
+	}
+
+	jobs, failed := h.planSprite(files.Source{Path: spriteFilename})
+	if opts.Check {
+		if failed > 0 {
+			return fmt.Errorf("%d of %d exports failed to plan", failed, len(opts.variants))
+		}
+		return CheckJobs(e, jobs)
+	}
+
+	return RunJobs(e, cache, jobs, opts.Jobs, failed)
+}
+
+// printMatrixDuplicates warns about outputs written by several variants of sprite
+func printMatrixDuplicates(jobs []*exporter.Job) {
+	for _, duplicate := range exporter.DuplicateOutputs(jobs) {
+		utils.PrintlnWarning(fmt.Sprintf("Warning: %s is written by several formats, scales or sizes, only last export is kept", duplicate))
+	}
+}
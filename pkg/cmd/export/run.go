@@ -0,0 +1,129 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/optimize"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+)
+
+// RunJobs runs export jobs on pool of aseprite processes, prints result of every job and summary.
+// planFailed is number of sprites which jobs failed to be planned, they are counted as failed
+func RunJobs(e *exporter.Exporter, cache *exporter.Cache, jobs []*exporter.Job, workers int, planFailed int) error {
+	// jobs are counted as exports when several formats or preset targets of one sprite are exported
+	unit := "sprites"
+	if sprites := jobsSprites(jobs); sprites != len(jobs) {
+		unit = "exports"
+		utils.PrintlnBold(fmt.Sprintf("Exporting %d sprites in %d exports (%d workers)", sprites, len(jobs), workers))
+	} else {
+		utils.PrintlnBold(fmt.Sprintf("Exporting %d sprites (%d workers)", len(jobs), workers))
+	}
+
+	failed, skipped := planFailed, 0
+	var optimized []optimize.Result
+	e.RunAll(jobs, workers, func(result exporter.Result) {
+		filename := result.Job.Params.SpriteFilename
+		switch {
+		case result.Err != nil:
+			failed++
+			utils.PrintError(fmt.Sprintf("✘ %s: %v", filename, result.Err))
+		case result.Skipped:
+			skipped++
+			fmt.Printf("· %s (up to date)\n", filename)
+		default:
+			utils.PrintlnSuccess(fmt.Sprintf("✔ %s (%d files)", filename, len(result.Job.Outputs)))
+			for _, r := range result.Optimized {
+				fmt.Printf("  %s\n", r)
+			}
+			optimized = append(optimized, result.Optimized...)
+		}
+	})
+
+	if err := saveCache(cache); err != nil {
+		return err
+	}
+
+	total := len(jobs) + planFailed
+	fmt.Printf("\nExported %d, up to date %d of %d %s\n", total-failed-skipped, skipped, total, unit)
+	if len(optimized) > 0 {
+		PrintOptimizedTotal(optimized)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s failed to export", failed, total, unit)
+	}
+
+	return nil
+}
+
+// jobsSprites returns number of distinct sprites exported by jobs
+func jobsSprites(jobs []*exporter.Job) int {
+	sprites := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		sprites[job.Params.SpriteFilename] = true
+	}
+	return len(sprites)
+}
+
+// CheckJobs prints stale outputs of jobs and fails if any
+func CheckJobs(e *exporter.Exporter, jobs []*exporter.Job) error {
+	var outputs, stale int
+	for _, job := range jobs {
+		outputs += len(job.Outputs)
+
+		jobStale, err := e.Stale(job)
+		if err != nil {
+			return err
+		}
+
+		for _, output := range jobStale {
+			utils.PrintError(fmt.Sprintf("✘ %s is stale (sprite %s)", output, job.Params.SpriteFilename))
+		}
+		stale += len(jobStale)
+	}
+
+	if stale > 0 {
+		return fmt.Errorf("%d of %d outputs are stale, export sprites again", stale, outputs)
+	}
+
+	utils.PrintlnSuccess(fmt.Sprintf("All %d outputs are up to date", outputs))
+	return nil
+}
+
+// NewCachedExporter creates exporter using build cache file unless cacheFile is empty
+func NewCachedExporter(e *exporter.Exporter, cacheFile string, force bool) (*exporter.Exporter, *exporter.Cache, error) {
+	if cacheFile == "" {
+		return e, nil, nil
+	}
+
+	cache, err := exporter.LoadCache(cacheFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return e.WithCache(cache, force), cache, nil
+}
+
+// PrintOptimizedTotal prints bytes saved by optimization of png files
+func PrintOptimizedTotal(results []optimize.Result) {
+	total := optimize.Total(results)
+	utils.PrintlnSuccess(fmt.Sprintf("Optimized %d png files: %s → %s, saved %s",
+		len(results), optimize.FormatBytes(total.Before), optimize.FormatBytes(total.After), optimize.FormatBytes(total.Saved())))
+}
+
+func PrintDuplicates(job *exporter.Job) {
+	for _, duplicate := range job.Duplicates {
+		utils.PrintlnWarning(fmt.Sprintf("Warning: %s is written several times, add placeholders to output template to keep every output", duplicate))
+	}
+}
+
+func saveCache(cache *exporter.Cache) error {
+	if cache == nil {
+		return nil
+	}
+
+	if err := cache.Save(); err != nil {
+		return fmt.Errorf("failed to save build cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobsSprites(t *testing.T) {
+	job := func(sprite, format string) *exporter.Job {
+		return &exporter.Job{Params: exporter.Params{SpriteFilename: sprite, Format: format}}
+	}
+
+	assert.Equal(t, 2, jobsSprites([]*exporter.Job{job("hero.aseprite", "png"), job("enemy.aseprite", "png")}))
+	// formats of one sprite are one sprite
+	assert.Equal(t, 1, jobsSprites([]*exporter.Job{job("hero.aseprite", "png"), job("hero.aseprite", "webp")}))
+	assert.Zero(t, jobsSprites(nil))
+}
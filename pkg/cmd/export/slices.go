@@ -0,0 +1,63 @@
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// exportSlices writes slices images and sidecars of every sprite found in sources (natively, without aseprite)
+func (h *exportHandler) exportSlices(sources []string) error {
+	opts := h.options
+
+	if len(sources) == 0 {
+		sources = h.config.SpritesFoldersPaths
+	}
+
+	sprites, err := files.CollectSources(sources, aseprite.SpritesExtensions()...)
+	if err != nil {
+		return err
+	}
+
+	includeLayers := opts.IncludeLayers
+	if opts.SelectedLayer != "" {
+		includeLayers = append(includeLayers, opts.SelectedLayer)
+	}
+
+	failed := 0
+	for _, sprite := range sprites {
+		var outputDir string
+		if opts.OutDir != "" {
+			outputDir = filepath.Join(opts.OutDir, sprite.RelDir())
+		}
+
+		written, err := exporter.ExportSlices(exporter.SliceParams{
+			SpriteFilename: sprite.Path,
+			OutputDir:      outputDir,
+			FramesIncluded: opts.FramesIncluded,
+			Names:          opts.SliceNames,
+			NinePatch:      opts.NinePatch,
+			IncludeLayers:  includeLayers,
+			ExcludeLayers:  opts.ExcludeLayers,
+			IncludeHidden:  opts.IncludeHidden,
+		})
+		if err != nil {
+			failed++
+			utils.PrintError(fmt.Sprintf("✘ %s: %v", sprite.Path, err))
+			continue
+		}
+
+		for _, filename := range written {
+			utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sprites failed to export slices", failed, len(sprites))
+	}
+	return nil
+}
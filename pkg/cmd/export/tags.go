@@ -0,0 +1,78 @@
+package export
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+func (o *exportOptions) usesTags() bool {
+	return o.AllTags || len(o.Tags) > 0
+}
+
+func (o *exportOptions) collectTagsInfo() error {
+	sprite, err := asefile.ReadFile(o.SpriteFilename)
+	if err != nil {
+		return errors.New("Failed to read sprite tags: " + err.Error())
+	}
+
+	available := exporter.TagsNames(sprite)
+	if len(available) == 0 || o.usesTags() {
+		return nil
+	}
+
+	var exportByTags bool
+	if err := survey.AskOne(&survey.Confirm{
+		Message: "Do you want to export by tags? (each tag to its own output)",
+		Default: false,
+	}, &exportByTags); err != nil {
+		return err
+	}
+
+	if !exportByTags {
+		return nil
+	}
+
+	return survey.AskOne(
+		&survey.MultiSelect{
+			Message: "Choose tags to export:",
+			Options: available,
+		},
+		&o.Tags,
+		survey.WithValidator(survey.MinItems(1)),
+	)
+}
+
+// spriteNamesCompletion completes flag with names taken from sprite given in --sprite-filename
+func (o *exportOptions) spriteNamesCompletion(names func(sprite *asefile.Sprite) []string) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		writtenFilename := o.SpriteFilename
+		if writtenFilename == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		if !files.CheckFileExists(writtenFilename, false) || !files.CheckFileExtension(writtenFilename, aseprite.SpritesExtensions()...) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		sprite, err := asefile.ReadFile(writtenFilename)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var suggestions []string
+		for _, name := range names(sprite) {
+			if name != "" && strings.HasPrefix(name, toComplete) {
+				suggestions = append(suggestions, name)
+			}
+		}
+
+		return suggestions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// WatchDelay is time without new file events after which changed sprites are exported
+const WatchDelay = 300 * time.Millisecond
+
+// WatchGroup is set of sources exported with the same options.
+// Plan returns export jobs of source and prints reasons of failed plans with their count,
+// optional Native writes outputs of source without aseprite (e.g. engines sprite sheets) and returns failures count
+type WatchGroup struct {
+	Sources []string
+	Plan    func(source files.Source) ([]*exporter.Job, int)
+	Native  func(source files.Source) int
+}
+
+// Watch exports every sprite of groups and then re-exports sprites changed on disk until interrupted.
+// New sprites matching groups sources are exported too, export failures do not stop watching
+func Watch(e *exporter.Exporter, cache *exporter.Cache, groups []WatchGroup, workers int) error {
+	var sources []string
+	for _, group := range groups {
+		sources = append(sources, group.Sources...)
+	}
+
+	watcher, err := files.NewWatcher(sources, WatchDelay, aseprite.SpritesExtensions()...)
+	if err != nil {
+		return fmt.Errorf("failed to watch sprites: %w", err)
+	}
+	defer watcher.Close()
+
+	var (
+		jobs    []*exporter.Job
+		natives []func()
+		failed  int
+	)
+	for _, group := range groups {
+		groupJobs, groupNatives, groupFailed := planGroup(group, nil)
+		jobs = append(jobs, groupJobs...)
+		natives = append(natives, groupNatives...)
+		failed += groupFailed
+	}
+
+	if err := RunJobs(e, cache, jobs, workers, failed); err != nil {
+		utils.PrintError(err.Error())
+	}
+	for _, native := range natives {
+		native()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	utils.PrintlnBold("\nWatching sprites for changes (press Ctrl+C to stop)")
+
+	watcher.Run(ctx, func(paths []string) {
+		rebuildChanged(e, cache, groups, workers, paths)
+	}, func(err error) {
+		utils.PrintError(fmt.Sprintf("✘ watching sprites: %v", err))
+	})
+	return nil
+}
+
+// planGroup plans jobs and native exports of group sources, only of changed ones (absolute paths) unless changed is nil
+func planGroup(group WatchGroup, changed map[string]bool) ([]*exporter.Job, []func(), int) {
+	var sources []files.Source
+	if changed != nil {
+		sources = watchedSources(group.Sources)
+	} else {
+		var err error
+		sources, err = files.CollectSources(group.Sources, aseprite.SpritesExtensions()...)
+		if err != nil {
+			utils.PrintError(fmt.Sprintf("✘ %v", err))
+			return nil, nil, 1
+		}
+	}
+
+	var (
+		jobs    []*exporter.Job
+		natives []func()
+		failed  int
+	)
+	for _, source := range sources {
+		if changed != nil {
+			abs, err := filepath.Abs(source.Path)
+			if err != nil || !changed[abs] {
+				continue
+			}
+			delete(changed, abs)
+		}
+
+		sourceJobs, sourceFailed := group.Plan(source)
+		jobs = append(jobs, sourceJobs...)
+		failed += sourceFailed
+
+		if group.Native != nil {
+			natives = append(natives, func() { group.Native(source) })
+		}
+	}
+
+	return jobs, natives, failed
+}
+
+// watchedSources collects sources of every argument separately, so argument which sprites were removed
+// while watching is skipped with warning and does not stop rebuilds of other arguments
+func watchedSources(args []string) []files.Source {
+	var sources []files.Source
+	for _, arg := range args {
+		argSources, err := files.CollectSources([]string{arg}, aseprite.SpritesExtensions()...)
+		if err != nil {
+			utils.PrintlnWarning(fmt.Sprintf("Warning: %v, skipped", err))
+			continue
+		}
+		sources = append(sources, argSources...)
+	}
+	return sources
+}
+
+// rebuildChanged exports jobs of changed sprites and prints one line per written output
+func rebuildChanged(e *exporter.Exporter, cache *exporter.Cache, groups []WatchGroup, workers int, paths []string) {
+	changed := make(map[string]bool, len(paths))
+	planned := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		changed[path] = true
+	}
+
+	var (
+		jobs    []*exporter.Job
+		natives []func()
+	)
+	for _, group := range groups {
+		// sprite could belong to several groups, so every group gets all changed paths
+		groupChanged := make(map[string]bool, len(changed))
+		for path := range changed {
+			groupChanged[path] = true
+		}
+
+		groupJobs, groupNatives, _ := planGroup(group, groupChanged)
+		jobs = append(jobs, groupJobs...)
+		natives = append(natives, groupNatives...)
+
+		for path := range changed {
+			if !groupChanged[path] {
+				planned[path] = true
+			}
+		}
+	}
+
+	for _, path := range paths {
+		if !planned[path] && !files.CheckFileExists(path, false) {
+			fmt.Printf("%s − %s removed\n", timestamp(), path)
+		}
+	}
+
+	e.RunAll(jobs, workers, func(result exporter.Result) {
+		filename := result.Job.Params.SpriteFilename
+		switch {
+		case result.Err != nil:
+			utils.PrintError(fmt.Sprintf("%s ✘ %s: %v", timestamp(), filename, result.Err))
+		case result.Skipped:
+			fmt.Printf("%s · %s (up to date)\n", timestamp(), filename)
+		default:
+			for _, output := range result.Job.Outputs {
+				utils.PrintlnSuccess(fmt.Sprintf("%s ✔ %s", timestamp(), output))
+			}
+		}
+	})
+
+	for _, native := range natives {
+		native()
+	}
+
+	if err := saveCache(cache); err != nil {
+		utils.PrintError(err.Error())
+	}
+}
+
+func timestamp() string {
+	return time.Now().Format("15:04:05")
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchGroup returns group of sprites files in dir which plan is one job per source
+func watchGroup(t *testing.T, dir string, names ...string) WatchGroup {
+	group := WatchGroup{Plan: func(source files.Source) ([]*exporter.Job, int) {
+		return []*exporter.Job{{Params: exporter.Params{SpriteFilename: source.Path}}}, 0
+	}}
+	for _, name := range names {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte("sprite"), 0644))
+		group.Sources = append(group.Sources, filename)
+	}
+	return group
+}
+
+func plannedSprites(jobs []*exporter.Job) []string {
+	var sprites []string
+	for _, job := range jobs {
+		sprites = append(sprites, filepath.Base(job.Params.SpriteFilename))
+	}
+	return sprites
+}
+
+func TestPlanGroup(t *testing.T) {
+	dir := t.TempDir()
+	group := watchGroup(t, dir, "hero.aseprite", "enemy.aseprite")
+	abs := func(name string) string {
+		path, err := filepath.Abs(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return path
+	}
+
+	jobs, _, failed := planGroup(group, nil)
+	assert.Equal(t, []string{"hero.aseprite", "enemy.aseprite"}, plannedSprites(jobs))
+	assert.Zero(t, failed)
+
+	changed := map[string]bool{abs("enemy.aseprite"): true, abs("other.aseprite"): true}
+	jobs, _, failed = planGroup(group, changed)
+	assert.Equal(t, []string{"enemy.aseprite"}, plannedSprites(jobs))
+	assert.Zero(t, failed)
+	assert.Equal(t, map[string]bool{abs("other.aseprite"): true}, changed, "planned paths are removed from changed")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "hero.aseprite")))
+
+	// removed sprite argument is skipped while watching, so other sprites are still rebuilt
+	jobs, _, failed = planGroup(group, map[string]bool{abs("hero.aseprite"): true, abs("enemy.aseprite"): true})
+	assert.Equal(t, []string{"enemy.aseprite"}, plannedSprites(jobs))
+	assert.Zero(t, failed)
+
+	jobs, _, failed = planGroup(group, nil)
+	assert.Empty(t, jobs)
+	assert.Equal(t, 1, failed)
+}
+
+func TestPlanGroupNative(t *testing.T) {
+	group := watchGroup(t, t.TempDir(), "hero.aseprite")
+	var native []string
+	group.Native = func(source files.Source) int {
+		native = append(native, filepath.Base(source.Path))
+		return 0
+	}
+
+	_, natives, _ := planGroup(group, nil)
+	require.Len(t, natives, 1)
+	assert.Empty(t, native, "native exports run after jobs")
+
+	natives[0]()
+	assert.Equal(t, []string{"hero.aseprite"}, native)
+}
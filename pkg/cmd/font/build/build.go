@@ -0,0 +1,170 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/font"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+)
+
+type buildOptions struct {
+	OutDir        string
+	Name          string
+	Chars         string
+	CharsFile     string
+	Cell          string
+	Frame         int
+	Layer         string
+	IncludeHidden bool
+	Format        string
+	Kerning       string
+	Spacing       int
+	SpaceWidth    int
+	LineHeight    int
+	Base          int
+	Padding       int
+	PowerOfTwo    bool
+}
+
+func NewFontBuildCmd(env *environment.Environment) *cobra.Command {
+	opts := &buildOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "build <SPRITE>",
+		Aliases: []string{"b"},
+		Short:   "Build BMFont bitmap font from sprite with glyphs sheet",
+		Long: heredoc.Doc(`
+Render sprite frame (without Aseprite) as glyphs sheet and write BMFont descriptor <sprite>.fnt (text or XML)
+with page image <sprite>_0.png. Glyphs are cells of --cell grid read row by row, or sprite slices in sprite
+order. Characters of glyphs are given by --chars or --chars-file in the same order (line breaks are skipped),
+slices named by their characters (e.g. "A" or "U+0041") need no charmap.
+
+Glyph advance is width of its opaque columns plus --spacing, empty glyphs (e.g. space) advance by --space-width.
+Glyphs are trimmed and packed into page, identical glyphs are stored once. Kerning pairs are read from
+--kerning sidecar with lines like "AV -1" or "A V -1" (# starts comment).`),
+		Example: heredoc.Doc(`
+	# Build font of 8x8 glyph grid with characters of file
+	aseprite-assets font build ./fonts/pixel.aseprite --cell 8 --chars-file ./fonts/pixel.txt
+
+	# Build XML font of 6x10 grid with kerning pairs and 1px spacing
+	aseprite-assets font build pixel.aseprite --cell 6x10 --chars "ABCDEFGHIJKLMNOPQRSTUVWXYZ .,!?" --kerning pixel.kern --format xml --spacing 1
+
+	# Build font of sprite slices named by their characters to out directory
+	aseprite-assets font build pixel.aseprite --base 7 --out-dir ./assets/fonts`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := opts.params(args[0])
+			if err != nil {
+				return err
+			}
+
+			written, f, err := font.Export(params)
+			for _, filename := range written {
+				utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+			}
+			if err != nil {
+				return err
+			}
+
+			utils.PrintlnBold(fmt.Sprintf("Built font %q with %d glyphs and %d kerning pairs on %dx%d page",
+				f.Face, len(f.Chars), len(f.Kernings), f.Page.Rect.Dx(), f.Page.Rect.Dy()))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory (sprite directory by default)")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "font face and output files name (sprite name by default)")
+	cmd.Flags().StringVarP(&opts.Chars, "chars", "c", "", "characters of glyphs in sheet order")
+	cmd.Flags().StringVar(&opts.CharsFile, "chars-file", "", "UTF-8 file with characters of glyphs in sheet order (line breaks are skipped)")
+	cmd.Flags().StringVar(&opts.Cell, "cell", "", "glyph cell size as N or WxH (e.g. 8, 6x10), sprite slices are glyphs without it")
+	cmd.Flags().IntVar(&opts.Frame, "frame", 0, "zero based frame of glyphs sheet")
+	cmd.Flags().StringVarP(&opts.Layer, "layer", "l", "", "layer name or group path (e.g. \"glyphs/*\") of glyphs, every visible layer by default")
+	cmd.Flags().BoolVar(&opts.IncludeHidden, "include-hidden", false, "render hidden layers too")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", string(font.FormatText), fmt.Sprintf("descriptor format (%s)", strings.Join(font.Formats(), ", ")))
+	cmd.Flags().StringVarP(&opts.Kerning, "kerning", "k", "", "kerning pairs sidecar file")
+	cmd.Flags().IntVar(&opts.Spacing, "spacing", 1, "pixels added to advance of every glyph")
+	cmd.Flags().IntVar(&opts.SpaceWidth, "space-width", 0, "advance of empty glyphs (half of line height by default)")
+	cmd.Flags().IntVar(&opts.LineHeight, "line-height", 0, "distance between lines (the tallest glyph cell by default)")
+	cmd.Flags().IntVar(&opts.Base, "base", 0, "distance from line top to baseline (line height by default)")
+	cmd.Flags().IntVar(&opts.Padding, "padding", 1, "transparent pixels between glyphs in page image")
+	cmd.Flags().BoolVar(&opts.PowerOfTwo, "pot", false, "round page size up to power of two")
+
+	cmd.MarkFlagsMutuallyExclusive("chars", "chars-file")
+
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(font.Formats(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// params reads charmap and converts options to font build params of sprite
+func (o *buildOptions) params(spriteFilename string) (font.Params, error) {
+	chars := o.Chars
+	if o.CharsFile != "" {
+		data, err := os.ReadFile(o.CharsFile)
+		if err != nil {
+			return font.Params{}, fmt.Errorf("failed to read chars file: %w", err)
+		}
+		chars = strings.TrimPrefix(string(data), "\ufeff")
+	}
+
+	var cell image.Point
+	if o.Cell != "" {
+		var err error
+		if cell, err = parseCellSize(o.Cell); err != nil {
+			return font.Params{}, err
+		}
+		if chars == "" {
+			return font.Params{}, errors.New("--chars or --chars-file is required with --cell")
+		}
+	}
+
+	var includeLayers []string
+	if o.Layer != "" {
+		includeLayers = []string{o.Layer}
+	}
+
+	return font.Params{
+		SpriteFilename:  spriteFilename,
+		OutputDir:       o.OutDir,
+		Name:            o.Name,
+		Charmap:         font.ParseCharmap(chars),
+		Cell:            cell,
+		Frame:           o.Frame,
+		Format:          o.Format,
+		KerningFilename: o.Kerning,
+		Options: font.Options{
+			Spacing:    o.Spacing,
+			SpaceWidth: o.SpaceWidth,
+			LineHeight: o.LineHeight,
+			Base:       o.Base,
+			Padding:    o.Padding,
+			PowerOfTwo: o.PowerOfTwo,
+		},
+		IncludeLayers: includeLayers,
+		IncludeHidden: o.IncludeHidden,
+	}, nil
+}
+
+// parseCellSize parses "N" or "WxH" glyph cell size
+func parseCellSize(value string) (image.Point, error) {
+	width, height, found := strings.Cut(strings.ToLower(value), "x")
+	if !found {
+		height = width
+	}
+
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return image.Point{}, fmt.Errorf("invalid cell size %q, expected N or WxH", value)
+	}
+
+	return image.Pt(w, h), nil
+}
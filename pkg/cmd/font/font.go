@@ -0,0 +1,22 @@
+package font
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/font/build"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+)
+
+func NewFontCmd(env *environment.Environment) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "font [command]",
+		Aliases: []string{"f"},
+		Short:   "Bitmap font commands",
+		Long: `
+Subcommands allow you to:
+- Build BMFont (.fnt) bitmap font from sprite with glyphs sheet (build)`,
+	}
+
+	cmd.AddCommand(build.NewFontBuildCmd(env))
+
+	return cmd
+}
@@ -0,0 +1,115 @@
+package optimize
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/optimize"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/export"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type optimizeOptions struct {
+	DryRun bool
+	Jobs   int
+}
+
+func NewOptimizeCmd(env *environment.Environment) *cobra.Command {
+	opts := &optimizeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "optimize [PATHS...]",
+		Aliases: []string{"opt"},
+		Short:   "Losslessly optimize PNG files",
+		Long: heredoc.Doc(`
+Losslessly recompress PNG files in place: images with at most 256 colors are stored with indexed palette,
+opaque and grayscale images drop unused channels, metadata chunks are stripped and every row gets the filter
+giving the smallest file. Files are kept when they cannot be made smaller, animated and 16-bit PNGs are skipped.
+Paths are files, directories (searched recursively) and globs.`),
+		Example: heredoc.Doc(`
+	# Optimize every png of exports directory
+	aseprite-assets optimize ./out
+
+	# Report bytes that would be saved without changing files
+	aseprite-assets optimize "./out/**/*.png" --dry-run`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("at least one png file, directory or glob required")
+			}
+
+			sources, err := files.CollectSources(args, ".png")
+			if err != nil {
+				return err
+			}
+
+			return opts.run(sources)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "report savings without writing files")
+	cmd.Flags().IntVarP(&opts.Jobs, "jobs", "j", runtime.NumCPU(), "number of files optimized concurrently")
+
+	return cmd
+}
+
+// run optimizes files on pool of workers, prints result of every file and total
+func (o *optimizeOptions) run(sources []files.Source) error {
+	utils.PrintlnBold(fmt.Sprintf("Optimizing %d png files", len(sources)))
+
+	queue := make(chan string)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []optimize.Result
+		failed  int
+	)
+
+	for range max(1, min(o.Jobs, len(sources))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filename := range queue {
+				result, err := optimize.File(filename, o.DryRun)
+
+				mu.Lock()
+				switch {
+				case errors.Is(err, optimize.ErrAnimated) || errors.Is(err, optimize.ErrLossy):
+					fmt.Printf("· %s (skipped: %v)\n", filename, errors.Unwrap(err))
+				case err != nil:
+					failed++
+					utils.PrintError(fmt.Sprintf("✘ %v", err))
+				case result.Saved() == 0:
+					fmt.Printf("· %s (already optimal)\n", filename)
+					results = append(results, result)
+				default:
+					utils.PrintlnSuccess(fmt.Sprintf("✔ %s", result))
+					results = append(results, result)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, source := range sources {
+		queue <- source.Path
+	}
+	close(queue)
+	wg.Wait()
+
+	fmt.Println()
+	export.PrintOptimizedTotal(results)
+	if o.DryRun {
+		fmt.Println("Dry run, files are not changed")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed to optimize", failed, len(sources))
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/packer"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type packOptions struct {
+	Output          string
+	DataFilename    string
+	DataFormat      string
+	Layout          string
+	Count           int
+	Padding         int
+	Extrude         int
+	Trim            bool
+	MergeDuplicates bool
+	PowerOfTwo      bool
+	MaxSize         string
+	Tags            []string
+}
+
+func NewPackCmd(env *environment.Environment) *cobra.Command {
+	opts := &packOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "pack [SOURCES...]",
+		Short: "Pack sprites frames or exported images into atlas PNG with Aseprite JSON metadata",
+		Long: heredoc.Doc(`
+Pack frames of sprites (.aseprite/.ase, rendered natively from visible layers) and images (e.g. exported .png frames)
+into one atlas image with metadata in Aseprite JSON (hash or array) schema. Sources are files, directories (searched recursively) and globs.`),
+		Example: heredoc.Doc(`
+	# Pack every frame of sprites folder into smallest atlas
+	aseprite-assets pack ./sprites/characters -o ./out/characters.png
+
+	# Pack "walk" and "idle" tags trimming frames and merging identical ones into power of two atlas
+	aseprite-assets pack hero.aseprite --tag walk --tag idle --trim --merge-duplicates --pot -o hero.png
+
+	# Pack exported frames in rows of 8 with padding and extrusion, metadata in array format
+	aseprite-assets pack "./out/frames/*.png" --layout rows --count 8 --padding 2 --extrude 1 --data-format array -o sheet.png`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("at least one sprite, image, directory or glob required")
+			}
+
+			packOpts, err := opts.packerOptions()
+			if err != nil {
+				return err
+			}
+
+			sources, err := files.CollectSources(args, append(aseprite.SpritesExtensions(), ".png")...)
+			if err != nil {
+				return err
+			}
+
+			frames, tags, err := opts.collectFrames(sources)
+			if err != nil {
+				return err
+			}
+
+			atlas, err := packer.Pack(frames, tags, packOpts)
+			if err != nil {
+				return err
+			}
+
+			return opts.write(atlas)
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, append(aseprite.SpritesExtensions(), ".png"))(c, args, toComplete)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "atlas PNG filename")
+	cmd.Flags().StringVar(&opts.DataFilename, "data", "", "metadata JSON filename (atlas filename with .json extension by default)")
+	cmd.Flags().StringVar(&opts.DataFormat, "data-format", string(packer.DataHash), fmt.Sprintf("metadata frames format (%s)", strings.Join(packer.DataFormats(), ", ")))
+	cmd.Flags().StringVar(&opts.Layout, "layout", string(packer.LayoutPacked), fmt.Sprintf("frames placement (%s)", strings.Join(packer.Layouts(), ", ")))
+	cmd.Flags().IntVar(&opts.Count, "count", 0, "frames per row (rows layout) or column (columns layout), square grid by default")
+	cmd.Flags().IntVar(&opts.Padding, "padding", 0, "transparent pixels between frames")
+	cmd.Flags().IntVar(&opts.Extrude, "extrude", 0, "pixels of frame border repeated around it")
+	cmd.Flags().BoolVar(&opts.Trim, "trim", false, "trim transparent frame borders (offsets are kept in spriteSourceSize)")
+	cmd.Flags().BoolVar(&opts.MergeDuplicates, "merge-duplicates", false, "store identical frames once")
+	cmd.Flags().BoolVar(&opts.PowerOfTwo, "pot", false, "round atlas size up to power of two")
+	cmd.Flags().StringVar(&opts.MaxSize, "max-size", "", "max atlas size as N or WxH (e.g. 2048, 2048x1024)")
+	cmd.Flags().StringSliceVarP(&opts.Tags, "tag", "t", nil, "pack only frames of sprite tag, repeatable")
+
+	_ = cmd.MarkFlagRequired("output")
+	_ = cmd.RegisterFlagCompletionFunc("layout", cobra.FixedCompletions(packer.Layouts(), cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("data-format", cobra.FixedCompletions(packer.DataFormats(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func (o *packOptions) packerOptions() (packer.Options, error) {
+	if !files.CheckFileExtension(o.Output, ".png") {
+		return packer.Options{}, fmt.Errorf("atlas output must be .png file: %s", o.Output)
+	}
+	if !slices.Contains(packer.DataFormats(), o.DataFormat) {
+		return packer.Options{}, fmt.Errorf("invalid data format %q, available formats: %s", o.DataFormat, strings.Join(packer.DataFormats(), ", "))
+	}
+
+	maxWidth, maxHeight, err := parseMaxSize(o.MaxSize)
+	if err != nil {
+		return packer.Options{}, err
+	}
+
+	return packer.Options{
+		Layout:          packer.Layout(o.Layout),
+		Count:           o.Count,
+		Padding:         o.Padding,
+		Extrude:         o.Extrude,
+		Trim:            o.Trim,
+		MergeDuplicates: o.MergeDuplicates,
+		PowerOfTwo:      o.PowerOfTwo,
+		MaxWidth:        maxWidth,
+		MaxHeight:       maxHeight,
+	}, nil
+}
+
+// collectFrames loads frames of every source, sprites tags ranges are shifted to atlas frames indices
+func (o *packOptions) collectFrames(sources []files.Source) ([]packer.Frame, []packer.FrameTag, error) {
+	var (
+		frames []packer.Frame
+		tags   []packer.FrameTag
+	)
+
+	for _, source := range sources {
+		if !files.CheckFileExtension(source.Path, aseprite.SpritesExtensions()...) {
+			frame, err := packer.ImageFrame(source.Path)
+			if err != nil {
+				return nil, nil, err
+			}
+			frames = append(frames, frame)
+			continue
+		}
+
+		sprite, err := asefile.ReadFile(source.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read sprite %s: %w", source.Path, err)
+		}
+
+		spriteFrames, spriteTags, err := packer.SpriteFrames(sprite, source.Path, o.Tags, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, tag := range spriteTags {
+			tag.From += len(frames)
+			tag.To += len(frames)
+			tags = append(tags, tag)
+		}
+		frames = append(frames, spriteFrames...)
+	}
+
+	return frames, tags, nil
+}
+
+func (o *packOptions) write(atlas *packer.Atlas) error {
+	dataFilename := o.DataFilename
+	if dataFilename == "" {
+		dataFilename = files.ChangeFilenameExtension(o.Output, ".json")
+	}
+
+	// metadata refers to atlas image relatively to its own directory
+	imageName, err := filepath.Rel(filepath.Dir(dataFilename), o.Output)
+	if err != nil {
+		imageName = filepath.Base(o.Output)
+	}
+
+	data, err := atlas.Metadata(packer.DataFormat(o.DataFormat), filepath.ToSlash(imageName))
+	if err != nil {
+		return err
+	}
+
+	for _, filename := range []string{o.Output, dataFilename} {
+		if err := files.EnsureDirExists(filename); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	file, err := os.Create(o.Output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, atlas.Image); err != nil {
+		return fmt.Errorf("failed to write atlas image: %w", err)
+	}
+
+	if err := os.WriteFile(dataFilename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write atlas metadata: %w", err)
+	}
+
+	size := atlas.Image.Rect.Size()
+	utils.PrintlnSuccess(fmt.Sprintf("Packed %d frames (%d unique) into %s (%dx%d), metadata: %s",
+		len(atlas.Placements), atlas.Unique(), o.Output, size.X, size.Y, dataFilename))
+	return nil
+}
+
+// parseMaxSize parses "N" or "WxH", empty value is unlimited size
+func parseMaxSize(value string) (int, int, error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	width, height, found := strings.Cut(strings.ToLower(value), "x")
+	if !found {
+		height = width
+	}
+
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid max size %q, expected N or WxH", value)
+	}
+
+	return w, h, nil
+}
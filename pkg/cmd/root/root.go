@@ -3,14 +3,19 @@ package root
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/build"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/config"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/config/open"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/export"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/font"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/list"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/optimize"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/pack"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/palette"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/scripts"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/show"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/tilemap"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
 )
 
@@ -45,9 +50,14 @@ CLI interface for aseprite assets interaction. For in-terminal opening of asepri
 		config.NewConfigCmd(env),
 		sprite.NewSpriteCmd(env),
 		export.NewExportCmd(env),
+		build.NewBuildCmd(env),
+		pack.NewPackCmd(env),
+		optimize.NewOptimizeCmd(env),
 		list.NewListCmd(env),
 		open.NewConfigOpenCmd(env),
 		palette.NewPaletteCmd(env),
+		tilemap.NewTilemapCmd(env),
+		font.NewFontCmd(env),
 		scripts.NewScriptsCmd(env),
 		show.NewShowCmd(env),
 	)
@@ -0,0 +1,210 @@
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type InspectOptions struct {
+	JSON bool
+}
+
+func NewSpriteInspectCmd(env *environment.Environment) *cobra.Command {
+	opts := &InspectOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "inspect [ARG]",
+		Aliases: []string{"i", "info"},
+		Short:   "Print sprite structure (frames, layers, tags, slices and user data)",
+		Long: heredoc.Doc(`
+Print sprite structure read natively from .aseprite/.ase file (Aseprite installation is not required).`),
+		Example: heredoc.Doc(`
+	# Print human readable sprite structure
+	aseprite-assets sprite inspect ./sprites/hero.aseprite
+
+	# Print sprite structure as JSON
+	aseprite-assets sprite inspect ./sprites/hero.aseprite --json`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			filename := args[0]
+			if !files.CheckFileExists(filename, false) || !files.CheckFileExtension(filename, aseprite.SpritesExtensions()...) {
+				return fmt.Errorf("invalid sprite file: %s", filename)
+			}
+
+			sprite, err := asefile.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+
+			report := NewSpriteReport(filename, sprite)
+			if opts.JSON {
+				return printJSON(c.OutOrStdout(), report)
+			}
+
+			printHuman(c.OutOrStdout(), report)
+			return nil
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, aseprite.SpritesExtensions())(c, args, toComplete)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "print sprite structure as JSON")
+
+	return cmd
+}
+
+func printJSON(w io.Writer, report *SpriteReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func printHuman(w io.Writer, r *SpriteReport) {
+	utils.FprintlnBold(w, fmt.Sprintf("🖌️ %s", r.Filename))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Canvas:\t%dx%d\n", r.Width, r.Height)
+	fmt.Fprintf(tw, "Color mode:\t%s\n", r.ColorMode)
+	if r.TransparentIndex != nil {
+		fmt.Fprintf(tw, "Transparent index:\t%d\n", *r.TransparentIndex)
+	}
+	fmt.Fprintf(tw, "Pixel ratio:\t%s\n", r.PixelRatio)
+	fmt.Fprintf(tw, "Grid:\t%d,%d %dx%d\n", r.Grid.X, r.Grid.Y, r.Grid.Width, r.Grid.Height)
+	fmt.Fprintf(tw, "Palette:\t%d colors\n", r.PaletteSize)
+	fmt.Fprintf(tw, "Frames:\t%d\n", r.FramesCount)
+	if r.UserData != nil {
+		fmt.Fprintf(tw, "User data:\t%s\n", formatUserData(r.UserData))
+	}
+	_ = tw.Flush()
+
+	section(w, "Frames")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tDURATION")
+	for _, f := range r.Frames {
+		fmt.Fprintf(tw, "%d\t%dms\n", f.Index, f.DurationMs)
+	}
+	_ = tw.Flush()
+
+	section(w, "Layers")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tVISIBLE\tBLEND\tOPACITY\tFLAGS\tUSER DATA")
+	// sprite layers are stored bottom to top, print them as Aseprite does (top first)
+	for i := len(r.Layers) - 1; i >= 0; i-- {
+		printLayer(tw, r.Layers[i], 0)
+	}
+	_ = tw.Flush()
+
+	if len(r.Tags) > 0 {
+		section(w, "Tags")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tFRAMES\tDIRECTION\tREPEAT\tCOLOR\tUSER DATA")
+		for _, t := range r.Tags {
+			repeat := "∞"
+			if t.Repeat > 0 {
+				repeat = strconv.Itoa(t.Repeat)
+			}
+			fmt.Fprintf(tw, "%s\t%d-%d\t%s\t%s\t%s\t%s\n", t.Name, t.From, t.To, t.Direction, repeat, t.Color, formatUserData(t.UserData))
+		}
+		_ = tw.Flush()
+	}
+
+	if len(r.Slices) > 0 {
+		section(w, "Slices")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tFRAME\tBOUNDS\tCENTER\tPIVOT\tUSER DATA")
+		for _, s := range r.Slices {
+			for i, k := range s.Keys {
+				name, ud := s.Name, formatUserData(s.UserData)
+				if i > 0 {
+					name, ud = "", ""
+				}
+				center, pivot := "-", "-"
+				if k.Center != nil {
+					center = formatRect(*k.Center)
+				}
+				if k.Pivot != nil {
+					pivot = fmt.Sprintf("%d,%d", k.Pivot.X, k.Pivot.Y)
+				}
+				fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", name, k.Frame, formatRect(k.Bounds), center, pivot, ud)
+			}
+		}
+		_ = tw.Flush()
+	}
+
+	if len(r.Tilesets) > 0 {
+		section(w, "Tilesets")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tTILES\tTILE SIZE")
+		for _, ts := range r.Tilesets {
+			fmt.Fprintf(tw, "%s\t%d\t%dx%d\n", ts.Name, ts.Tiles, ts.TileWidth, ts.TileHeight)
+		}
+		_ = tw.Flush()
+	}
+}
+
+func printLayer(w io.Writer, l *LayerReport, depth int) {
+	var flags []string
+	if l.Background {
+		flags = append(flags, "background")
+	}
+	if l.Reference {
+		flags = append(flags, "reference")
+	}
+	flagsText := "-"
+	if len(flags) > 0 {
+		flagsText = strings.Join(flags, ",")
+	}
+
+	fmt.Fprintf(w, "%s%s\t%s\t%t\t%s\t%d\t%s\t%s\n",
+		strings.Repeat("  ", depth), l.Name, l.Type, l.Visible, l.BlendMode, l.Opacity, flagsText, formatUserData(l.UserData))
+
+	for i := len(l.Children) - 1; i >= 0; i-- {
+		printLayer(w, l.Children[i], depth+1)
+	}
+}
+
+func section(w io.Writer, title string) {
+	fmt.Fprintf(w, "\n%s:\n", title)
+}
+
+func formatRect(r Rect) string {
+	return fmt.Sprintf("%d,%d %dx%d", r.X, r.Y, r.Width, r.Height)
+}
+
+func formatUserData(ud *UserDataReport) string {
+	if ud == nil {
+		return "-"
+	}
+
+	var parts []string
+	if ud.Text != "" {
+		parts = append(parts, strconv.Quote(ud.Text))
+	}
+	if ud.Color != "" {
+		parts = append(parts, ud.Color)
+	}
+	if len(ud.Properties) > 0 {
+		parts = append(parts, fmt.Sprintf("%d properties", len(ud.Properties)))
+	}
+
+	return strings.Join(parts, " ")
+}
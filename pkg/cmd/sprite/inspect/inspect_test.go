@@ -0,0 +1,32 @@
+package inspect_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/inspect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectWritesToCommandOutput(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hero.aseprite")
+	require.NoError(t, asefile.WriteFile(filename, &asefile.Sprite{
+		Width: 16, Height: 8, ColorDepth: asefile.ColorDepthRGBA,
+		Frames: []*asefile.Frame{{}},
+		Layers: []*asefile.Layer{{Name: "body", Flags: asefile.LayerFlagVisible, Opacity: 255}},
+	}))
+
+	var out bytes.Buffer
+	cmd := inspect.NewSpriteInspectCmd(nil)
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{filename})
+	require.NoError(t, cmd.Execute())
+
+	// the whole human report, header included, goes to command output
+	assert.Contains(t, out.String(), "🖌️ "+filename+"\n")
+	assert.Contains(t, out.String(), "Canvas:")
+	assert.Contains(t, out.String(), "body")
+}
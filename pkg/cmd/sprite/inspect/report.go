@@ -0,0 +1,215 @@
+package inspect
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+)
+
+type SpriteReport struct {
+	Filename         string          `json:"filename"`
+	Width            int             `json:"width"`
+	Height           int             `json:"height"`
+	ColorMode        string          `json:"color_mode"`
+	TransparentIndex *int            `json:"transparent_index,omitempty"`
+	PixelRatio       string          `json:"pixel_ratio"`
+	Grid             Rect            `json:"grid"`
+	PaletteSize      int             `json:"palette_size"`
+	FramesCount      int             `json:"frames_count"`
+	Frames           []FrameReport   `json:"frames"`
+	Layers           []*LayerReport  `json:"layers"`
+	Tags             []TagReport     `json:"tags"`
+	Slices           []SliceReport   `json:"slices"`
+	Tilesets         []TilesetReport `json:"tilesets,omitempty"`
+	UserData         *UserDataReport `json:"user_data,omitempty"`
+}
+
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type Point struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type FrameReport struct {
+	Index      int   `json:"index"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+type LayerReport struct {
+	Name         string          `json:"name"`
+	Path         string          `json:"path"`
+	Type         string          `json:"type"`
+	Visible      bool            `json:"visible"`
+	Background   bool            `json:"background"`
+	Reference    bool            `json:"reference"`
+	BlendMode    string          `json:"blend_mode"`
+	Opacity      int             `json:"opacity"`
+	TilesetIndex *int            `json:"tileset_index,omitempty"`
+	UserData     *UserDataReport `json:"user_data,omitempty"`
+	Children     []*LayerReport  `json:"children,omitempty"`
+}
+
+type TagReport struct {
+	Name      string          `json:"name"`
+	From      int             `json:"from"`
+	To        int             `json:"to"`
+	Direction string          `json:"direction"`
+	Repeat    int             `json:"repeat"`
+	Color     string          `json:"color"`
+	UserData  *UserDataReport `json:"user_data,omitempty"`
+}
+
+type SliceReport struct {
+	Name     string           `json:"name"`
+	Keys     []SliceKeyReport `json:"keys"`
+	UserData *UserDataReport  `json:"user_data,omitempty"`
+}
+
+type SliceKeyReport struct {
+	Frame  int    `json:"frame"`
+	Bounds Rect   `json:"bounds"`
+	Center *Rect  `json:"center,omitempty"`
+	Pivot  *Point `json:"pivot,omitempty"`
+}
+
+type TilesetReport struct {
+	Name       string `json:"name"`
+	Tiles      int    `json:"tiles"`
+	TileWidth  int    `json:"tile_width"`
+	TileHeight int    `json:"tile_height"`
+}
+
+type UserDataReport struct {
+	Text       string         `json:"text,omitempty"`
+	Color      string         `json:"color,omitempty"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+// NewSpriteReport collects sprite structure into serializable report
+func NewSpriteReport(filename string, sprite *asefile.Sprite) *SpriteReport {
+	report := &SpriteReport{
+		Filename:    filepath.ToSlash(filename),
+		Width:       sprite.Width,
+		Height:      sprite.Height,
+		ColorMode:   sprite.ColorDepth.String(),
+		PixelRatio:  fmt.Sprintf("%d:%d", sprite.PixelWidth, sprite.PixelHeight),
+		Grid:        newRect(sprite.Grid),
+		PaletteSize: len(sprite.Palette.Entries),
+		FramesCount: len(sprite.Frames),
+		Frames:      []FrameReport{},
+		Layers:      []*LayerReport{},
+		Tags:        []TagReport{},
+		Slices:      []SliceReport{},
+		UserData:    newUserDataReport(sprite.UserData),
+	}
+
+	if sprite.ColorDepth == asefile.ColorDepthIndexed {
+		index := int(sprite.TransparentIndex)
+		report.TransparentIndex = &index
+	}
+
+	for _, f := range sprite.Frames {
+		report.Frames = append(report.Frames, FrameReport{Index: f.Index, DurationMs: f.Duration.Milliseconds()})
+	}
+
+	for _, l := range sprite.TopLevelLayers() {
+		report.Layers = append(report.Layers, newLayerReport(l))
+	}
+
+	for _, t := range sprite.Tags {
+		report.Tags = append(report.Tags, TagReport{
+			Name:      t.Name,
+			From:      t.From,
+			To:        t.To,
+			Direction: t.Direction.String(),
+			Repeat:    t.Repeat,
+			Color:     hexColor(t.Color),
+			UserData:  newUserDataReport(t.UserData),
+		})
+	}
+
+	for _, s := range sprite.Slices {
+		sr := SliceReport{Name: s.Name, UserData: newUserDataReport(s.UserData)}
+		for _, k := range s.Keys {
+			kr := SliceKeyReport{Frame: k.Frame, Bounds: newRect(k.Bounds)}
+			if k.Center != nil {
+				center := newRect(*k.Center)
+				kr.Center = &center
+			}
+			if k.Pivot != nil {
+				kr.Pivot = &Point{X: k.Pivot.X, Y: k.Pivot.Y}
+			}
+			sr.Keys = append(sr.Keys, kr)
+		}
+		report.Slices = append(report.Slices, sr)
+	}
+
+	for _, ts := range sprite.Tilesets {
+		report.Tilesets = append(report.Tilesets, TilesetReport{
+			Name:       ts.Name,
+			Tiles:      ts.NumTiles,
+			TileWidth:  ts.TileWidth,
+			TileHeight: ts.TileHeight,
+		})
+	}
+
+	return report
+}
+
+func newLayerReport(l *asefile.Layer) *LayerReport {
+	lr := &LayerReport{
+		Name:       l.Name,
+		Path:       l.Path(),
+		Type:       l.Type.String(),
+		Visible:    l.Visible(),
+		Background: l.IsBackground(),
+		Reference:  l.IsReference(),
+		BlendMode:  l.BlendMode.String(),
+		Opacity:    int(l.Opacity),
+		UserData:   newUserDataReport(l.UserData),
+	}
+
+	if l.Type == asefile.LayerTypeTilemap {
+		index := l.TilesetIndex
+		lr.TilesetIndex = &index
+	}
+
+	for _, child := range l.Children {
+		lr.Children = append(lr.Children, newLayerReport(child))
+	}
+
+	return lr
+}
+
+func newUserDataReport(ud asefile.UserData) *UserDataReport {
+	if ud.IsEmpty() {
+		return nil
+	}
+
+	report := &UserDataReport{Text: ud.Text, Properties: ud.Properties}
+	if ud.Color != nil {
+		report.Color = hexColor(*ud.Color)
+	}
+
+	return report
+}
+
+func newRect(r image.Rectangle) Rect {
+	return Rect{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()}
+}
+
+func hexColor(c color.NRGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
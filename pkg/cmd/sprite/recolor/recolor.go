@@ -0,0 +1,282 @@
+package recolor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/recolor"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/config"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+// unmatchedColorsLimit is number of colors missing in source palette listed per sprite
+const unmatchedColorsLimit = 8
+
+type recolorOptions struct {
+	From   string
+	To     []string
+	OutDir string
+	Format string
+	Frames string
+	Jobs   int
+}
+
+func NewSpriteRecolorCmd(env *environment.Environment) *cobra.Command {
+	opts := &recolorOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "recolor [SPRITES...] --from <PALETTE> --to <PALETTES>",
+		Aliases: []string{"rc"},
+		Short:   "Write palette swap variants of sprites, one per target palette",
+		Long: heredoc.Doc(`
+Recolor sprites (read natively, without Aseprite) by mapping every color of --from palette to color of the same
+index in every --to palette, each target palette writes its own variant <sprite>_<palette>. Indexed sprites keep
+their pixels and get palette entries mapped (by index when entry matches source color of the same index, by color
+otherwise), RGB sprites get pixels mapped by color, alpha is kept. Pixels with colors missing in source palette
+are left as is and reported.
+
+Palettes are searched by name (with or without extension) in configured palettes folders or given by path.
+Variants are written as .aseprite files by default, other --format exports them directly with Aseprite.
+Sprites are files, directories (searched recursively) and globs.`),
+		Example: heredoc.Doc(`
+	# Write hero_red.aseprite and hero_blue.aseprite variants of base palette sprite
+	aseprite-assets sprite recolor ./sprites/hero.aseprite --from base.gpl --to red.gpl,blue.gpl
+
+	# Export team colors of every enemy as gif animations mirroring sprites tree in ./out
+	aseprite-assets sprite recolor ./sprites/enemies --from base --to red,blue,green --format gif --frames '*' --out-dir ./out`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := env.Config()
+			if err != nil {
+				return err
+			}
+
+			mappings, err := opts.mappings(cfg)
+			if err != nil {
+				return err
+			}
+
+			if err := opts.validateFormat(); err != nil {
+				return err
+			}
+
+			sources, err := files.CollectSources(args, aseprite.SpritesExtensions()...)
+			if err != nil {
+				return err
+			}
+
+			return opts.run(cfg, sources, mappings)
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, aseprite.SpritesExtensions())(c, args, toComplete)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.From, "from", "", "source palette of sprites colors (name of configured palettes folders or path)")
+	cmd.Flags().StringSliceVar(&opts.To, "to", nil, "target palettes with as many colors as source one, comma separated or repeatable")
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory mirroring sprites sources tree (sprite directory by default)")
+	cmd.Flags().StringVarP(&opts.Format, "format", "f", strings.TrimPrefix(aseprite.Aseprite.String(), "."), "variants format, aseprite writes sprite files and other formats are exported with Aseprite")
+	cmd.Flags().StringVar(&opts.Frames, "frames", "0", "frames of exported variants - zero based (e.g. '0:2', '0', '*')")
+	cmd.Flags().IntVarP(&opts.Jobs, "jobs", "j", runtime.NumCPU(), "number of concurrent aseprite processes of exported variants")
+
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+
+	palettesCompletion := func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := env.Config()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return autocomp.GenerateFilesAutoCompletions(cfg.PalettesFoldersPaths, palette.Extensions())(c, args, toComplete)
+	}
+	_ = cmd.RegisterFlagCompletionFunc("from", palettesCompletion)
+	_ = cmd.RegisterFlagCompletionFunc("to", palettesCompletion)
+
+	return cmd
+}
+
+// mappings loads source and target palettes of configured palettes folders
+func (o *recolorOptions) mappings(cfg *config.Config) ([]*recolor.Mapping, error) {
+	source, err := palette.LoadAll([]string{o.From}, cfg.PalettesFoldersPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := palette.LoadAll(o.To, cfg.PalettesFoldersPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []*recolor.Mapping
+	names := make(map[string]bool)
+	for _, target := range targets {
+		if names[target.Name] {
+			return nil, fmt.Errorf("target palettes have the same name %q, variants would overwrite each other", target.Name)
+		}
+		names[target.Name] = true
+
+		mapping, err := recolor.NewMapping(source[0], target)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+func (o *recolorOptions) validateFormat() error {
+	if o.writesSprites() {
+		return nil
+	}
+	if !slices.Contains(aseprite.AvailableExportExtensions(), files.PrefExtension(o.Format)) {
+		return fmt.Errorf("invalid format %q, allowed: %s", o.Format, strings.Join(aseprite.AvailableExportExtensions(), ", "))
+	}
+	return exporter.ValidateFramesInput(o.Frames)
+}
+
+// writesSprites reports whether variants are written as sprite files instead of exports
+func (o *recolorOptions) writesSprites() bool {
+	return files.CheckFileExtension("variant"+files.PrefExtension(o.Format), aseprite.SpritesExtensions()...)
+}
+
+// run writes variants of every sprite, exported variants are written to temporary sprites first
+func (o *recolorOptions) run(cfg *config.Config, sources []files.Source, mappings []*recolor.Mapping) error {
+	utils.PrintlnBold(fmt.Sprintf("Recoloring %d sprites from palette %s to %d palettes", len(sources), mappings[0].Source.Name, len(mappings)))
+
+	var tempDir string
+	if !o.writesSprites() {
+		var err error
+		if tempDir, err = os.MkdirTemp("", "aseprite-assets-recolor-*"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+	}
+
+	var (
+		jobs    []*exporter.Job
+		written int
+		failed  int
+	)
+	for i, source := range sources {
+		for j, mapping := range mappings {
+			sprite, err := asefile.ReadFile(source.Path)
+			if err != nil {
+				failed += len(mappings) - j
+				utils.PrintError(fmt.Sprintf("✘ %s: %v", source.Path, err))
+				break
+			}
+
+			report, err := recolor.Sprite(sprite, mapping)
+			if err != nil {
+				failed += len(mappings) - j
+				utils.PrintError(fmt.Sprintf("✘ %s: %v", source.Path, err))
+				break
+			}
+			if j == 0 {
+				printReport(source.Path, mapping.Source, report)
+			}
+
+			output := o.outputFilename(source, mapping.Target.Name)
+			if tempDir == "" {
+				if err := writeSprite(output, sprite); err != nil {
+					failed++
+					utils.PrintError(fmt.Sprintf("✘ %s: %v", output, err))
+					continue
+				}
+				written++
+				utils.PrintlnSuccess(fmt.Sprintf("✔ %s", output))
+				continue
+			}
+
+			spriteFilename := filepath.Join(tempDir, strconv.Itoa(i), files.ChangeFilenameExtension(filepath.Base(output), aseprite.Aseprite.String()))
+			job, err := planExport(spriteFilename, sprite, output, o.Frames)
+			if err != nil {
+				failed++
+				utils.PrintError(fmt.Sprintf("✘ %s: %v", output, err))
+				continue
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	if len(jobs) > 0 {
+		e := exporter.NewExporter(aseprite.NewCLI(cfg.AsepritePath, cfg.ScriptDirPath, cfg.FromSteam))
+		e.RunAll(jobs, o.Jobs, func(result exporter.Result) {
+			output := strings.Join(result.Job.Outputs, ", ")
+			if result.Err != nil {
+				failed++
+				utils.PrintError(fmt.Sprintf("✘ %s: %v", output, result.Err))
+				return
+			}
+			written++
+			utils.PrintlnSuccess(fmt.Sprintf("✔ %s", output))
+		})
+	}
+
+	fmt.Printf("\nWritten %d of %d variants\n", written, len(sources)*len(mappings))
+	if failed > 0 {
+		return fmt.Errorf("%d variants failed to be written", failed)
+	}
+	return nil
+}
+
+// outputFilename returns <sprite>_<palette> variant filename of format in sprite or mirrored output directory
+func (o *recolorOptions) outputFilename(source files.Source, paletteName string) string {
+	dir := filepath.Dir(source.Path)
+	if o.OutDir != "" {
+		dir = filepath.Join(o.OutDir, source.RelDir())
+	}
+
+	name := strings.TrimSuffix(filepath.Base(source.Path), filepath.Ext(source.Path))
+	return filepath.Join(dir, fmt.Sprintf("%s_%s%s", name, paletteName, files.PrefExtension(o.Format)))
+}
+
+func writeSprite(filename string, sprite *asefile.Sprite) error {
+	if err := files.EnsureDirExists(filename); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return asefile.WriteFile(filename, sprite)
+}
+
+// planExport writes recolored sprite to temporary file and plans its export to output
+func planExport(spriteFilename string, sprite *asefile.Sprite, output, frames string) (*exporter.Job, error) {
+	if err := writeSprite(spriteFilename, sprite); err != nil {
+		return nil, err
+	}
+
+	return exporter.Plan(exporter.Params{
+		SpriteFilename: spriteFilename,
+		OutputFilename: output,
+		FramesIncluded: frames,
+	})
+}
+
+func printReport(filename string, source *palette.Palette, report recolor.Report) {
+	if len(report.Unmatched) == 0 {
+		fmt.Printf("%s: %d pixels recolored\n", filename, report.Recolored)
+		return
+	}
+
+	utils.PrintlnWarning(fmt.Sprintf("%s: %d pixels recolored, %d pixels of %d colors missing in palette %s are kept: %s",
+		filename, report.Recolored, report.UnmatchedPixels(), len(report.Unmatched), source.Name,
+		palette.FormatCounts(report.Unmatched, unmatchedColorsLimit)))
+}
@@ -0,0 +1,242 @@
+package remap
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/recolor"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type remapOptions struct {
+	Palette      string
+	Metric       string
+	Indexed      bool
+	OutDir       string
+	DryRun       bool
+	SummaryLimit int
+}
+
+func NewSpriteRemapCmd(env *environment.Environment) *cobra.Command {
+	opts := &remapOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "remap [SPRITES...] --palette <PALETTE>",
+		Aliases: []string{"rp"},
+		Short:   "Snap sprites colors to the nearest colors of palette",
+		Long: heredoc.Doc(`
+Snap every pixel of sprites (read natively, without Aseprite) to the nearest opaque color of --palette by --metric
+distance, alpha is kept. RGB sprites get pixels snapped and indexed sprites get palette entries snapped. With
+--indexed sprites are converted to indexed mode with palette colors (pixels with alpha below half become
+transparent, the rest opaque).
+
+Every sprite prints summary of changed pixels per original and palette color. Sprites are overwritten unless
+--out-dir is set (sprites already using palette colors are copied there as is), --dry-run only prints summaries. Palette is searched by name (with or without extension)
+in configured palettes folders or given by path. Sprites are files, directories (searched recursively) and globs.`),
+		Example: heredoc.Doc(`
+	# Snap colors of every sprite of freelance directory to project palette in place
+	aseprite-assets sprite remap ./sprites/freelance --palette project.gpl
+
+	# Print pixels which would be changed by plain RGB distance without writing sprites
+	aseprite-assets sprite remap ./sprites/hero.aseprite --palette project --metric rgb --dry-run
+
+	# Convert sprites to indexed mode with palette colors mirroring sprites tree in ./remapped
+	aseprite-assets sprite remap "./sprites/**/*.aseprite" --palette project --metric oklab --indexed --out-dir ./remapped`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := env.Config()
+			if err != nil {
+				return err
+			}
+
+			metric, err := palette.ParseMetric(opts.Metric)
+			if err != nil {
+				return err
+			}
+
+			palettes, err := palette.LoadAll([]string{opts.Palette}, cfg.PalettesFoldersPaths)
+			if err != nil {
+				return err
+			}
+
+			sources, err := files.CollectSources(args, aseprite.SpritesExtensions()...)
+			if err != nil {
+				return err
+			}
+
+			return opts.run(sources, palettes[0], metric)
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, aseprite.SpritesExtensions())(c, args, toComplete)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Palette, "palette", "p", "", "palette of snapped colors (name of configured palettes folders or path)")
+	cmd.Flags().StringVarP(&opts.Metric, "metric", "m", string(palette.MetricCIEDE2000), fmt.Sprintf("color distance (%s)", strings.Join(palette.Metrics(), ", ")))
+	cmd.Flags().BoolVar(&opts.Indexed, "indexed", false, "convert sprites to indexed mode with palette colors")
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory mirroring sprites sources tree (sprites are overwritten by default)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "print summaries without writing sprites")
+	cmd.Flags().IntVar(&opts.SummaryLimit, "summary-limit", 10, "number of color changes listed per sprite, 0 lists every change")
+
+	_ = cmd.MarkFlagRequired("palette")
+	cmd.MarkFlagsMutuallyExclusive("dry-run", "out-dir")
+
+	_ = cmd.RegisterFlagCompletionFunc("metric", cobra.FixedCompletions(palette.Metrics(), cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("palette", func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := env.Config()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return autocomp.GenerateFilesAutoCompletions(cfg.PalettesFoldersPaths, palette.Extensions())(c, args, toComplete)
+	})
+
+	return cmd
+}
+
+// run remaps every sprite, prints its summary and writes it unless nothing changed (unchanged sprites
+// are copied to output directory, so it mirrors sources)
+func (o *remapOptions) run(sources []files.Source, p *palette.Palette, metric palette.Metric) error {
+	utils.PrintlnBold(fmt.Sprintf("Remapping %d sprites to palette %s (%d colors) by %s distance", len(sources), p.Name, len(p.Colors), metric))
+
+	var (
+		total            recolor.RemapReport
+		written, failed  int
+		unchangedSprites int
+	)
+	total.Changes = make(map[recolor.Change]int)
+
+	for _, source := range sources {
+		sprite, err := asefile.ReadFile(source.Path)
+		if err != nil {
+			failed++
+			utils.PrintError(fmt.Sprintf("✘ %s: %v", source.Path, err))
+			continue
+		}
+
+		report, err := recolor.Remap(sprite, p, metric, o.Indexed)
+		if err != nil {
+			failed++
+			utils.PrintError(fmt.Sprintf("✘ %s: %v", source.Path, err))
+			continue
+		}
+
+		total.Pixels += report.Pixels
+		total.Translucent += report.Translucent
+		for change, count := range report.Changes {
+			total.Changes[change] += count
+		}
+
+		// unchanged sprites are rewritten only to mirror sources in output directory
+		unchanged := len(report.Changes) == 0 && !o.Indexed
+		if unchanged {
+			unchangedSprites++
+			if o.OutDir == "" {
+				fmt.Printf("· %s (already uses palette colors)\n", source.Path)
+				continue
+			}
+		}
+
+		output := source.Path
+		if o.OutDir != "" {
+			output = filepath.Join(o.OutDir, source.RelDir(), filepath.Base(source.Path))
+		}
+
+		if !o.DryRun {
+			if err := files.EnsureDirExists(output); err != nil {
+				failed++
+				utils.PrintError(fmt.Sprintf("✘ %s: failed to create output directory: %v", output, err))
+				continue
+			}
+
+			if unchanged {
+				err = copySprite(source.Path, output)
+			} else {
+				err = asefile.WriteFile(output, sprite)
+			}
+			if err != nil {
+				failed++
+				utils.PrintError(fmt.Sprintf("✘ %s: %v", output, err))
+				continue
+			}
+			written++
+		}
+
+		if unchanged {
+			fmt.Printf("· %s (already uses palette colors, copied)\n", output)
+			continue
+		}
+
+		utils.PrintlnSuccess(fmt.Sprintf("✔ %s: %d of %d pixels changed", output, report.ChangedPixels(), report.Pixels))
+		if report.Translucent > 0 {
+			utils.PrintlnWarning(fmt.Sprintf("  %d translucent pixels lost alpha by conversion to indexed mode", report.Translucent))
+		}
+		printChanges(report.Changes, o.SummaryLimit)
+	}
+
+	if len(sources) > 1 && len(total.Changes) > 0 {
+		utils.PrintlnBold("\nChanges of every sprite:")
+		printChanges(total.Changes, o.SummaryLimit)
+	}
+
+	fmt.Printf("\n%d of %d pixels changed, %d sprites already used palette colors\n", total.ChangedPixels(), total.Pixels, unchangedSprites)
+	if o.DryRun {
+		fmt.Println("Dry run, sprites are not changed")
+	} else {
+		fmt.Printf("Written %d sprites\n", written)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sprites failed to be remapped", failed, len(sources))
+	}
+	return nil
+}
+
+// copySprite writes unchanged sprite file to output as is
+func copySprite(source, output string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, data, 0644)
+}
+
+// printChanges prints changed pixels per original and palette color from the most changed ones,
+// at most limit changes when limit is positive
+func printChanges(changes map[recolor.Change]int, limit int) {
+	sorted := slices.SortedFunc(maps.Keys(changes), func(a, b recolor.Change) int {
+		return cmp.Or(
+			cmp.Compare(changes[b], changes[a]),
+			strings.Compare(palette.Hex(a.From), palette.Hex(b.From)),
+			strings.Compare(palette.Hex(a.To), palette.Hex(b.To)),
+		)
+	})
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, change := range sorted {
+		if limit > 0 && i == limit {
+			fmt.Fprintf(tw, "  … %d more colors\n", len(sorted)-limit)
+			break
+		}
+		fmt.Fprintf(tw, "  %s → %s\t%d px\n", palette.Hex(change.From), palette.Hex(change.To), changes[change])
+	}
+	_ = tw.Flush()
+}
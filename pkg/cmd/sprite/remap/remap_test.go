@@ -0,0 +1,51 @@
+package remap
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/asefile"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/palette"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePixelSprite writes 1x1 RGB sprite of pixel color
+func writePixelSprite(t *testing.T, filename string, c color.NRGBA) {
+	sprite := &asefile.Sprite{
+		Width: 1, Height: 1, ColorDepth: asefile.ColorDepthRGBA,
+		Layers: []*asefile.Layer{{Name: "body", Flags: asefile.LayerFlagVisible, Opacity: 255}},
+		Frames: []*asefile.Frame{{Cels: []*asefile.Cel{
+			{Opacity: 255, Width: 1, Height: 1, Pixels: []byte{c.R, c.G, c.B, c.A}},
+		}}},
+	}
+	require.NoError(t, asefile.WriteFile(filename, sprite))
+}
+
+func TestRemapOutDirMirrorsSources(t *testing.T) {
+	dir := t.TempDir()
+	black, white := color.NRGBA{A: 255}, color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	writePixelSprite(t, filepath.Join(dir, "sprites", "hero.aseprite"), color.NRGBA{R: 10, G: 10, B: 10, A: 255})
+	writePixelSprite(t, filepath.Join(dir, "sprites", "enemies", "slime.aseprite"), white)
+
+	sources, err := files.CollectSources([]string{filepath.Join(dir, "sprites")}, ".aseprite")
+	require.NoError(t, err)
+
+	outDir := filepath.Join(dir, "out")
+	opts := &remapOptions{OutDir: outDir}
+	require.NoError(t, opts.run(sources, &palette.Palette{Name: "mono", Colors: []color.NRGBA{black, white}}, palette.MetricRGB))
+
+	remapped, err := asefile.ReadFile(filepath.Join(outDir, "hero.aseprite"))
+	require.NoError(t, err)
+	assert.Equal(t, black, remapped.FrameImage(0, nil).NRGBAAt(0, 0))
+
+	// sprite already using palette colors is copied as is
+	source, err := os.ReadFile(filepath.Join(dir, "sprites", "enemies", "slime.aseprite"))
+	require.NoError(t, err)
+	copied, err := os.ReadFile(filepath.Join(outDir, "enemies", "slime.aseprite"))
+	require.NoError(t, err)
+	assert.Equal(t, source, copied)
+}
@@ -3,7 +3,10 @@ package sprite
 import (
 	"github.com/spf13/cobra"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/create"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/inspect"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/open"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/recolor"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/remap"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/sprite/remove"
 	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
 )
@@ -16,12 +19,19 @@ func NewSpriteCmd(env *environment.Environment) *cobra.Command {
 		Long: `
 Subcommands allow you to:
 - Create sprite (create)
-- Open sprite (open)`,
+- Open sprite (open)
+- Remove sprite (remove)
+- Inspect sprite structure (inspect)
+- Write palette swap variants of sprites (recolor)
+- Snap sprites colors to palette colors (remap)`,
 	}
 
 	cmd.AddCommand(create.NewSpriteCreateCmd(env))
 	cmd.AddCommand(open.NewSpriteOpenCmd(env))
 	cmd.AddCommand(remove.NewSpriteRemoveCmd(env))
+	cmd.AddCommand(inspect.NewSpriteInspectCmd(env))
+	cmd.AddCommand(recolor.NewSpriteRecolorCmd(env))
+	cmd.AddCommand(remap.NewSpriteRemapCmd(env))
 
 	return cmd
 }
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/tilemap"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type exportOptions struct {
+	OutDir        string
+	Formats       []string
+	Frame         int
+	Columns       int
+	IncludeLayers []string
+	ExcludeLayers []string
+	IncludeHidden bool
+}
+
+func NewTilemapExportCmd(env *environment.Environment) *cobra.Command {
+	opts := &exportOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "export [SOURCES...]",
+		Aliases: []string{"exp", "e"},
+		Short:   "Export tilemap layers to Tiled (.tmx/.tmj) or LDtk (.ldtk) map with tilesets",
+		Long: heredoc.Doc(`
+Export tilemap layers of sprites (.aseprite/.ase, read natively) to map <sprite>.tmx (Tiled XML), <sprite>.tmj
+(Tiled JSON) or <sprite>.ldtk (LDtk project with one level). Sprite tilesets are written next to map as
+with "tilemap tileset". Tile indices and flips are preserved: Tiled GID of tile is its Aseprite index
+with horizontal, vertical and diagonal flip bits. Map covers sprite canvas with tiles of tilemap layers
+(all exported layers must have the same tile size), layer not aligned to grid gets pixel offset.
+
+LDtk layer has one tileset and cannot rotate tiles, so layers mixing tilesets or with diagonally flipped
+tiles cannot be exported to LDtk. Sources are files, directories (searched recursively) and globs,
+sprites without tilemap layers are skipped.`),
+		Example: heredoc.Doc(`
+	# Export tilemap layers to Tiled map next to sprite
+	aseprite-assets tilemap export ./levels/forest.aseprite
+
+	# Export Tiled JSON and LDtk maps of every level to out directory
+	aseprite-assets tilemap export ./levels --format json --format ldtk --out-dir ./out/levels
+
+	# Export only "ground" and "walls" layers of third frame including hidden layers
+	aseprite-assets tilemap export forest.aseprite --include-layer ground --include-layer walls --frame 2 --include-hidden`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := tilemap.ValidateMapFormats(opts.Formats); err != nil {
+				return err
+			}
+
+			sprites, err := files.CollectSources(args, aseprite.SpritesExtensions()...)
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, sprite := range sprites {
+				var outputDir string
+				if opts.OutDir != "" {
+					outputDir = filepath.Join(opts.OutDir, sprite.RelDir())
+				}
+
+				written, err := tilemap.ExportMap(tilemap.Params{
+					SpriteFilename: sprite.Path,
+					OutputDir:      outputDir,
+					Formats:        opts.Formats,
+					Frame:          opts.Frame,
+					Columns:        opts.Columns,
+					IncludeLayers:  opts.IncludeLayers,
+					ExcludeLayers:  opts.ExcludeLayers,
+					IncludeHidden:  opts.IncludeHidden,
+				})
+				noTilemap := errors.Is(err, tilemap.ErrNoTilesets) || errors.Is(err, tilemap.ErrNoTilemapLayers)
+				if noTilemap && len(sprites) > 1 {
+					utils.PrintlnWarning(fmt.Sprintf("− %s: %v, skipped", sprite.Path, err))
+					continue
+				}
+				if err != nil {
+					failed++
+					utils.PrintError(fmt.Sprintf("✘ %s: %v", sprite.Path, err))
+					continue
+				}
+
+				for _, filename := range written {
+					utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d sprites failed to export tilemaps", failed, len(sprites))
+			}
+			return nil
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, aseprite.SpritesExtensions())(c, args, toComplete)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory mirroring sprites sources tree (sprite directory by default)")
+	cmd.Flags().StringSliceVarP(&opts.Formats, "format", "f", []string{string(tilemap.FormatTMX)}, fmt.Sprintf("map format (%s), repeatable", strings.Join(tilemap.MapFormats(), ", ")))
+	cmd.Flags().IntVar(&opts.Frame, "frame", 0, "zero based frame of tilemap layers to export")
+	cmd.Flags().IntVar(&opts.Columns, "columns", 0, "tiles per tileset image row, square grid by default")
+	cmd.Flags().StringArrayVar(&opts.IncludeLayers, "include-layer", nil, "glob of tilemap layer names or group paths to export (e.g. \"level/*\"), repeatable")
+	cmd.Flags().StringArrayVar(&opts.ExcludeLayers, "exclude-layer", nil, "glob of layer names or group paths to skip, repeatable")
+	cmd.Flags().BoolVar(&opts.IncludeHidden, "include-hidden", false, "export hidden tilemap layers too (marked hidden in map)")
+
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(tilemap.MapFormats(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
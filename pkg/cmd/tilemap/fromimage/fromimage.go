@@ -0,0 +1,111 @@
+package fromimage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/tilemap"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+)
+
+type fromImageOptions struct {
+	OutDir    string
+	Tile      string
+	Formats   []string
+	Columns   int
+	Flips     bool
+	Rotations bool
+	Aseprite  bool
+}
+
+func NewTilemapFromImageCmd(env *environment.Environment) *cobra.Command {
+	opts := &fromImageOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "from-image <IMAGE>",
+		Aliases: []string{"fi"},
+		Short:   "Cut flat level image into deduplicated tileset and Tiled/LDtk map",
+		Long: heredoc.Doc(`
+Cut image (e.g. level mockup .png) into tiles of --tile size and store every unique tile once in tileset
+<image>_tiles.png with Tiled tileset <image>_tiles.tsx. Map <image>.tmx (or .tmj, .ldtk with --format) places
+tiles as they are in image, fully transparent tiles are empty cells and partial tiles on right and bottom
+edges are padded with transparency.
+
+With --flips tiles flipped horizontally or vertically are duplicates and with --rotations (square tiles only)
+tiles rotated by 90, 180 or 270 degrees are, such tiles are placed with Tiled flip flags. LDtk cannot rotate
+tiles, so use json or tmx format with --rotations. With --aseprite sprite <image>.aseprite with tileset and
+tilemap layer is written too.`),
+		Example: heredoc.Doc(`
+	# Cut level mockup to 16x16 tiles, write tileset and Tiled map next to it
+	aseprite-assets tilemap from-image ./mockups/forest.png --tile 16
+
+	# Treat flipped tiles as duplicates, write Tiled JSON and LDtk maps with sprite to out directory
+	aseprite-assets tilemap from-image forest.png --tile 16x8 --flips --format json --format ldtk --aseprite --out-dir ./levels
+
+	# Treat flipped and rotated tiles as duplicates
+	aseprite-assets tilemap from-image forest.png --tile 16 --flips --rotations`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			width, height, err := parseTileSize(opts.Tile)
+			if err != nil {
+				return err
+			}
+
+			written, stats, err := tilemap.ExportImage(tilemap.ImageParams{
+				ImageFilename: args[0],
+				OutputDir:     opts.OutDir,
+				Formats:       opts.Formats,
+				Columns:       opts.Columns,
+				Cut: tilemap.CutOptions{
+					TileWidth:  width,
+					TileHeight: height,
+					Flips:      opts.Flips,
+					Rotations:  opts.Rotations,
+				},
+				Aseprite: opts.Aseprite,
+			})
+			for _, filename := range written {
+				utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+			}
+			if err != nil {
+				return err
+			}
+
+			utils.PrintlnBold(fmt.Sprintf("Found %d unique tiles in %d cells (%d empty) of %s",
+				stats.Unique, stats.Cells, stats.Empty, args[0]))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory (image directory by default)")
+	cmd.Flags().StringVarP(&opts.Tile, "tile", "t", "16", "tile size as N or WxH (e.g. 16, 16x8)")
+	cmd.Flags().StringSliceVarP(&opts.Formats, "format", "f", []string{string(tilemap.FormatTMX)}, fmt.Sprintf("map format (%s), repeatable", strings.Join(tilemap.MapFormats(), ", ")))
+	cmd.Flags().IntVar(&opts.Columns, "columns", 0, "tiles per tileset image row, square grid by default")
+	cmd.Flags().BoolVar(&opts.Flips, "flips", false, "treat horizontally and vertically flipped tiles as duplicates")
+	cmd.Flags().BoolVar(&opts.Rotations, "rotations", false, "treat tiles rotated by 90, 180 and 270 degrees as duplicates (square tiles only)")
+	cmd.Flags().BoolVar(&opts.Aseprite, "aseprite", false, "write .aseprite sprite with tileset and tilemap layer too")
+
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(tilemap.MapFormats(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// parseTileSize parses "N" or "WxH" tile size
+func parseTileSize(value string) (int, int, error) {
+	width, height, found := strings.Cut(strings.ToLower(value), "x")
+	if !found {
+		height = width
+	}
+
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("invalid tile size %q, expected N or WxH", value)
+	}
+
+	return w, h, nil
+}
@@ -0,0 +1,28 @@
+package tilemap
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/tilemap/export"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/tilemap/fromimage"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/cmd/tilemap/tileset"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+)
+
+func NewTilemapCmd(env *environment.Environment) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tilemap [command]",
+		Aliases: []string{"t"},
+		Short:   "Tilemap commands to export tilesets and tilemap layers",
+		Long: `
+Subcommands allow you to:
+- Extract sprite tilesets to image and Tiled tileset (tileset)
+- Export tilemap layers to Tiled or LDtk map (export)
+- Cut flat level image into deduplicated tileset and map (from-image)`,
+	}
+
+	cmd.AddCommand(tileset.NewTilemapTilesetCmd(env))
+	cmd.AddCommand(export.NewTilemapExportCmd(env))
+	cmd.AddCommand(fromimage.NewTilemapFromImageCmd(env))
+
+	return cmd
+}
@@ -0,0 +1,94 @@
+package tileset
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+	autocomp "github.com/spinozanilast/aseprite-assets-cli/internal/cmd"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/tilemap"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/environment"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+)
+
+type tilesetOptions struct {
+	OutDir  string
+	Columns int
+}
+
+func NewTilemapTilesetCmd(env *environment.Environment) *cobra.Command {
+	opts := &tilesetOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "tileset [SOURCES...]",
+		Aliases: []string{"ts"},
+		Short:   "Extract sprite tilesets to image and Tiled tileset (.tsx)",
+		Long: heredoc.Doc(`
+Write every tileset of sprites (.aseprite/.ase, read natively) as tiles grid image <sprite>_<tileset>.png
+with Tiled external tileset <sprite>_<tileset>.tsx next to it. Empty tile (index 0) is not stored, so tile
+with Aseprite index N is Tiled tile N-1. Sources are files, directories (searched recursively) and globs,
+sprites without tilesets are skipped.`),
+		Example: heredoc.Doc(`
+	# Extract tilesets next to sprite
+	aseprite-assets tilemap tileset ./levels/forest.aseprite
+
+	# Extract tilesets of every sprite in folder to out directory with 8 tiles per row
+	aseprite-assets tilemap tileset ./levels --out-dir ./out/tilesets --columns 8`),
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sprites, err := files.CollectSources(args, aseprite.SpritesExtensions()...)
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, sprite := range sprites {
+				var outputDir string
+				if opts.OutDir != "" {
+					outputDir = filepath.Join(opts.OutDir, sprite.RelDir())
+				}
+
+				written, err := tilemap.ExportTilesets(tilemap.Params{
+					SpriteFilename: sprite.Path,
+					OutputDir:      outputDir,
+					Columns:        opts.Columns,
+				})
+				if errors.Is(err, tilemap.ErrNoTilesets) && len(sprites) > 1 {
+					utils.PrintlnWarning(fmt.Sprintf("− %s: %v, skipped", sprite.Path, err))
+					continue
+				}
+				if err != nil {
+					failed++
+					utils.PrintError(fmt.Sprintf("✘ %s: %v", sprite.Path, err))
+					continue
+				}
+
+				for _, filename := range written {
+					utils.PrintlnSuccess(fmt.Sprintf("✔ %s", filename))
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d sprites failed to export tilesets", failed, len(sprites))
+			}
+			return nil
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			cfg, err := env.Config()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			return autocomp.GenerateFilesAutoCompletions(cfg.SpritesFoldersPaths, aseprite.SpritesExtensions())(c, args, toComplete)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OutDir, "out-dir", "", "output directory mirroring sprites sources tree (sprite directory by default)")
+	cmd.Flags().IntVar(&opts.Columns, "columns", 0, "tiles per tileset image row, square grid by default")
+
+	return cmd
+}
@@ -21,13 +21,14 @@ const (
 	configName = ".aseprite-assets-cli"
 	configType = "json"
 
-	fromSteamKey    = "from_steam"
-	appIdKey        = "app_id"
-	asepritePathKey = "aseprite_path"
-	scriptDirKey    = "scripts_dir"
-	spriteDirsKey   = "assets_folder_paths"
-	openAiConfigKey = "open_ai_api"
-	palettesDirsKey = "palettes_folder_paths"
+	fromSteamKey     = "from_steam"
+	appIdKey         = "app_id"
+	asepritePathKey  = "aseprite_path"
+	scriptDirKey     = "scripts_dir"
+	spriteDirsKey    = "assets_folder_paths"
+	openAiConfigKey  = "open_ai_api"
+	palettesDirsKey  = "palettes_folder_paths"
+	exportPresetsKey = "export_presets"
 )
 
 type OpenAiConfig struct {
@@ -43,6 +44,8 @@ type Config struct {
 	PalettesFoldersPaths []string     `mapstructure:"palettes_folder_paths"`
 	ScriptDirPath        string       `mapstructure:"scripts_dir"`
 	OpenAiConfig         OpenAiConfig `mapstructure:"open_ai_api"`
+	// ExportPresets are named sets of export targets used by "export --preset"
+	ExportPresets map[string][]ExportPresetTarget `mapstructure:"export_presets"`
 }
 
 // LoadConfig loads the configuration from the file system (use it again if you need config after updating)
@@ -122,6 +122,39 @@ func TestSetOpenAiConfig(t *testing.T) {
 	w.testWrapped(t)
 }
 
+func TestExportPresets(t *testing.T) {
+	w := DefaultWrapper{
+		testFunc: func(t *testing.T, _ *config.Config) {
+			web, err := config.ParseExportPreset("png@1,2 .webp@1")
+			require.NoError(t, err)
+			icons, err := config.ParseExportPreset("png@16x16,32x32 ico")
+			require.NoError(t, err)
+			assert.Equal(t, []config.ExportPresetTarget{{Format: "png", Sizes: "16x16,32x32"}, {Format: "ico"}}, icons)
+
+			require.NoError(t, config.SetExportPreset("Web", web))
+			require.NoError(t, config.SetExportPreset("icons", icons))
+			require.NoError(t, config.DeleteExportPreset("icons"))
+
+			cfg, err := config.LoadConfig()
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"web"}, cfg.ExportPresetsNames())
+			targets, err := cfg.ExportPreset("WEB")
+			require.NoError(t, err)
+			assert.Equal(t, []config.ExportPresetTarget{{Format: "png", Scales: "1,2"}, {Format: "webp", Scales: "1"}}, targets)
+
+			_, err = cfg.ExportPreset("icons")
+			assert.ErrorContains(t, err, "available presets: web")
+			assert.Error(t, config.DeleteExportPreset("icons"))
+
+			_, err = config.ParseExportPreset(" ")
+			assert.Error(t, err)
+			assert.Error(t, config.SetExportPreset("web.v2", web))
+		},
+	}
+	w.testWrapped(t)
+}
+
 func TestConfigValidate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ExportPresetTarget is one export of preset: format with optional scales or sizes (comma separated as in export flags)
+type ExportPresetTarget struct {
+	Format string `mapstructure:"format" json:"format"`
+	Scales string `mapstructure:"scales" json:"scales,omitempty"`
+	Sizes  string `mapstructure:"sizes" json:"sizes,omitempty"`
+}
+
+// String returns target in preset spec form, e.g. "png@1,2" or "png@64x64"
+func (t ExportPresetTarget) String() string {
+	switch {
+	case t.Scales != "":
+		return t.Format + "@" + t.Scales
+	case t.Sizes != "":
+		return t.Format + "@" + t.Sizes
+	default:
+		return t.Format
+	}
+}
+
+// ParseExportPreset parses space separated preset targets "<format>[@<scales or sizes>]",
+// e.g. "png@1,2 webp@1" or "png@64x64,128x128 gif"
+func ParseExportPreset(spec string) ([]ExportPresetTarget, error) {
+	var targets []ExportPresetTarget
+	for _, field := range strings.Fields(spec) {
+		format, resize, _ := strings.Cut(field, "@")
+		target := ExportPresetTarget{Format: strings.TrimPrefix(format, ".")}
+		if target.Format == "" {
+			return nil, fmt.Errorf("preset target %q has no format", field)
+		}
+
+		if strings.ContainsAny(resize, "xX") {
+			target.Sizes = resize
+		} else {
+			target.Scales = resize
+		}
+		targets = append(targets, target)
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("preset %q has no targets", spec)
+	}
+	return targets, nil
+}
+
+// ExportPresetsNames returns sorted names of configured export presets
+func (c *Config) ExportPresetsNames() []string {
+	var names []string
+	for name := range c.ExportPresets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ExportPreset returns targets of export preset, preset names are case-insensitive
+func (c *Config) ExportPreset(name string) ([]ExportPresetTarget, error) {
+	targets, ok := c.ExportPresets[strings.ToLower(name)]
+	if !ok {
+		available := strings.Join(c.ExportPresetsNames(), ", ")
+		if available == "" {
+			available = "none, add one with \"config edit --preset <name>=<targets>\""
+		}
+		return nil, fmt.Errorf("export preset %q not found, available presets: %s", name, available)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("export preset %q has no targets", name)
+	}
+	return targets, nil
+}
+
+// SetExportPreset adds or replaces export preset, names are stored in lower case
+func SetExportPreset(name string, targets []ExportPresetTarget) error {
+	if err := validatePresetName(name); err != nil {
+		return err
+	}
+
+	return updateExportPresets(func(presets map[string]any) error {
+		presets[strings.ToLower(name)] = targets
+		return nil
+	})
+}
+
+// DeleteExportPreset removes export preset from config
+func DeleteExportPreset(name string) error {
+	return updateExportPresets(func(presets map[string]any) error {
+		if _, ok := presets[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("export preset %q not found", name)
+		}
+		delete(presets, strings.ToLower(name))
+		return nil
+	})
+}
+
+// updateExportPresets changes presets of current settings and saves config. Presets are not set with viper.Set,
+// since viper cannot unset keys: config is reloaded from changed settings instead
+func updateExportPresets(update func(presets map[string]any) error) error {
+	settings := viper.AllSettings()
+	presets, _ := settings[exportPresetsKey].(map[string]any)
+	if presets == nil {
+		presets = map[string]any{}
+	}
+	if err := update(presets); err != nil {
+		return err
+	}
+	settings[exportPresetsKey] = presets
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to reload configuration: %w", err)
+	}
+	return saveConfig()
+}
+
+func validatePresetName(name string) error {
+	if name == "" || strings.ContainsAny(name, ". \t") {
+		return fmt.Errorf("invalid export preset name %q, name cannot be empty or contain dots and spaces", name)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/engine"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/exporter"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/postprocess"
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/aseprite/upscale"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFilename is project manifest looked up from working directory to filesystem root
+const ManifestFilename = "aseprite-assets.yaml"
+
+var (
+	ErrManifestNotFound = errors.New("project manifest not found")
+)
+
+// Manifest declares export pipelines of project sprites, relative paths are resolved from manifest directory
+type Manifest struct {
+	// CacheFile is build cache file (.aseprite-assets-cache.json next to manifest by default)
+	CacheFile string    `yaml:"cache_file"`
+	Jobs      int       `yaml:"jobs"`
+	Targets   []*Target `yaml:"targets"`
+
+	// Dir is directory of manifest file
+	Dir string `yaml:"-"`
+}
+
+// Target is named group of sources exported by pipelines
+type Target struct {
+	Name string `yaml:"name"`
+	// Sources are sprite files, directories (recursive) or globs
+	Sources []string `yaml:"sources"`
+	// OutDir mirrors sources tree, outputs are written next to sprites without it
+	OutDir    string      `yaml:"out_dir"`
+	Pipelines []*Pipeline `yaml:"pipelines"`
+}
+
+// Pipeline describes export of every target source, options mirror export command flags
+type Pipeline struct {
+	Formats        []string `yaml:"formats"`
+	OutputTemplate string   `yaml:"output_template"`
+	Frames         string   `yaml:"frames"`
+	Tags           []string `yaml:"tags"`
+	AllTags        bool     `yaml:"all_tags"`
+	Scales         []string `yaml:"scales"`
+	Sizes          []string `yaml:"sizes"`
+	IncludeLayers  []string `yaml:"include_layers"`
+	ExcludeLayers  []string `yaml:"exclude_layers"`
+	IncludeHidden  bool     `yaml:"include_hidden"`
+	SplitLayers    bool     `yaml:"split_layers"`
+	SplitFrames    bool     `yaml:"split_frames"`
+	// Upscaler is algorithm of scaled png outputs, as export --upscaler
+	Upscaler string `yaml:"upscaler"`
+	// Post are post-processing operations of png outputs, as export --post
+	Post []string `yaml:"post"`
+	// Optimize losslessly recompresses png outputs, as export --optimize
+	Optimize bool `yaml:"optimize"`
+	// LoopCount is number of apng plays (0 loops forever), tags repeat count is used when it is not set
+	LoopCount *int `yaml:"loop_count"`
+	// PaletteLUT are palette files (relative to manifest) of index textures and palette LUT, as export --palette-lut
+	PaletteLUT []string `yaml:"palette_lut"`
+	// Engines write sprite sheet with engines animations metadata next to formats outputs
+	Engines []string `yaml:"engines"`
+}
+
+// FindManifest looks for manifest in dir and its parents
+func FindManifest(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ManifestFilename)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%w: %s (searched from working directory to root)", ErrManifestNotFound, ManifestFilename)
+		}
+		dir = parent
+	}
+}
+
+// LoadManifest reads and validates manifest file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project manifest: %w", err)
+	}
+
+	manifest, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project manifest %s: %w", path, err)
+	}
+
+	if manifest.Dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// ParseManifest decodes manifest, unknown fields are reported as errors
+func ParseManifest(data []byte) (*Manifest, error) {
+	manifest := &Manifest{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(manifest); err != nil {
+		return nil, err
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func (m *Manifest) validate() error {
+	if len(m.Targets) == 0 {
+		return errors.New("no targets declared")
+	}
+
+	var names []string
+	for i, target := range m.Targets {
+		if target.Name == "" {
+			return fmt.Errorf("target #%d has no name", i+1)
+		}
+		if slices.Contains(names, target.Name) {
+			return fmt.Errorf("target %q declared several times", target.Name)
+		}
+		names = append(names, target.Name)
+
+		if len(target.Sources) == 0 {
+			return fmt.Errorf("target %q has no sources", target.Name)
+		}
+		if len(target.Pipelines) == 0 {
+			return fmt.Errorf("target %q has no pipelines", target.Name)
+		}
+
+		for j, pipeline := range target.Pipelines {
+			if len(pipeline.Formats) == 0 && pipeline.OutputTemplate == "" && len(pipeline.Engines) == 0 {
+				return fmt.Errorf("pipeline #%d of target %q needs formats, output template or engines", j+1, target.Name)
+			}
+			if err := engine.ValidateEngines(pipeline.Engines); err != nil {
+				return fmt.Errorf("pipeline #%d of target %q: %w", j+1, target.Name, err)
+			}
+			if _, err := upscale.Parse(pipeline.Upscaler); err != nil {
+				return fmt.Errorf("pipeline #%d of target %q: %w", j+1, target.Name, err)
+			}
+			if _, err := postprocess.Parse(pipeline.Post); err != nil {
+				return fmt.Errorf("pipeline #%d of target %q: %w", j+1, target.Name, err)
+			}
+			if pipeline.LoopCount != nil && *pipeline.LoopCount < 0 {
+				return fmt.Errorf("pipeline #%d of target %q has negative loop count", j+1, target.Name)
+			}
+			if len(pipeline.Scales) > 0 && len(pipeline.Sizes) > 0 {
+				return fmt.Errorf("pipeline #%d of target %q cannot have both scales and sizes", j+1, target.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SelectTargets returns targets with given names in manifest order, all targets without names
+func (m *Manifest) SelectTargets(names []string) ([]*Target, error) {
+	if len(names) == 0 {
+		return m.Targets, nil
+	}
+
+	for _, name := range names {
+		if !slices.Contains(m.TargetsNames(), name) {
+			return nil, fmt.Errorf("target %q not found, available targets: %s", name, strings.Join(m.TargetsNames(), ", "))
+		}
+	}
+
+	var targets []*Target
+	for _, target := range m.Targets {
+		if slices.Contains(names, target.Name) {
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+func (m *Manifest) TargetsNames() []string {
+	var names []string
+	for _, target := range m.Targets {
+		names = append(names, target.Name)
+	}
+	return names
+}
+
+// Path resolves path relative to manifest directory
+func (m *Manifest) Path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.Dir, path)
+}
+
+// Template resolves output template relative to manifest directory, templates starting with {dir}
+// placeholder are kept as is, as it is replaced by sprite or output directory
+func (m *Manifest) Template(template string) string {
+	if strings.HasPrefix(template, "{"+exporter.PlaceholderDir+"}") {
+		return template
+	}
+	return m.Path(template)
+}
+
+func (m *Manifest) CachePath(defaultFilename string) string {
+	if m.CacheFile == "" {
+		return m.Path(defaultFilename)
+	}
+	return m.Path(m.CacheFile)
+}
@@ -0,0 +1,98 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/project"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const manifestYAML = `
+jobs: 2
+targets:
+  - name: characters
+    sources: [sprites/characters]
+    out_dir: build/characters
+    pipelines:
+      - formats: [png, gif]
+        scales: [1, 2]
+        all_tags: true
+  - name: ui
+    sources: ["sprites/ui/*.aseprite"]
+    pipelines:
+      - output_template: "{dir}/{name}_{frame:02}.png"
+        split_frames: true
+      - engines: [godot, unity]
+`
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "sprites", "ui")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, project.ManifestFilename), []byte(manifestYAML), 0644))
+
+	path, err := project.FindManifest(nested)
+	require.NoError(t, err)
+
+	manifest, err := project.LoadManifest(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, manifest.Jobs)
+	assert.Equal(t, []string{"characters", "ui"}, manifest.TargetsNames())
+	assert.Equal(t, []string{"png", "gif"}, manifest.Targets[0].Pipelines[0].Formats)
+	assert.Equal(t, filepath.Join(manifest.Dir, "build", "characters"), manifest.Path(manifest.Targets[0].OutDir))
+	assert.Equal(t, "{dir}/{name}_{frame:02}.png", manifest.Template(manifest.Targets[1].Pipelines[0].OutputTemplate))
+	assert.Equal(t, []string{"godot", "unity"}, manifest.Targets[1].Pipelines[1].Engines)
+
+	targets, err := manifest.SelectTargets([]string{"ui"})
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, "ui", targets[0].Name)
+
+	_, err = manifest.SelectTargets([]string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestManifestTemplate(t *testing.T) {
+	manifest := &project.Manifest{Dir: filepath.Join("home", "game")}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		// {dir} is sprite or output directory, which is already resolved
+		{"{dir}/{name}_{frame:02}.png", "{dir}/{name}_{frame:02}.png"},
+		{"{dir}_{name}.png", "{dir}_{name}.png"},
+		{"{name}/out.png", filepath.Join("home", "game", "{name}", "out.png")},
+		{"{tag}_{name}.png", filepath.Join("home", "game", "{tag}_{name}.png")},
+		{"build/{name}.png", filepath.Join("home", "game", "build", "{name}.png")},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, manifest.Template(tt.template), tt.template)
+	}
+
+	abs, err := filepath.Abs(filepath.Join("build", "{name}.png"))
+	require.NoError(t, err)
+	assert.Equal(t, abs, manifest.Template(abs))
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	invalid := map[string]string{
+		"no targets":       `jobs: 1`,
+		"unknown field":    "targets:\n  - name: a\n    source: [x]\n",
+		"no pipelines":     "targets:\n  - name: a\n    sources: [x]\n",
+		"no format":        "targets:\n  - name: a\n    sources: [x]\n    pipelines:\n      - scales: [\"1\"]\n",
+		"unknown engine":   "targets:\n  - name: a\n    sources: [x]\n    pipelines:\n      - engines: [unreal]\n",
+		"scales and sizes": "targets:\n  - name: a\n    sources: [x]\n    pipelines:\n      - formats: [png]\n        scales: [\"1\"]\n        sizes: [8x8]\n",
+		"duplicate target": "targets:\n  - name: a\n    sources: [x]\n    pipelines: [{formats: [png]}]\n  - name: a\n    sources: [y]\n    pipelines: [{formats: [png]}]\n",
+	}
+
+	for name, data := range invalid {
+		_, err := project.ParseManifest([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
@@ -31,7 +31,7 @@ func EnsureDirExists(path string) error {
 	_, err := os.Stat(dir)
 
 	if err != nil {
-		err := os.Mkdir(dir, os.ModePerm)
+		err := os.MkdirAll(dir, os.ModePerm)
 		if err != nil {
 			return err
 		}
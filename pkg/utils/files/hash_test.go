@@ -0,0 +1,23 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sprite.aseprite")
+	require.NoError(t, os.WriteFile(filename, []byte("abc"), 0644))
+
+	hash, err := files.HashFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", hash)
+
+	_, err = files.HashFile(filename + ".missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
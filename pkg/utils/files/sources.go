@@ -0,0 +1,113 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Source is file found by CollectSources with root directory it was found from.
+// Path relative to root is used to mirror source tree in output directory.
+type Source struct {
+	Path string
+	Root string
+}
+
+// RelDir returns source directory relative to its root ("." for files directly in root).
+func (s Source) RelDir() string {
+	rel, err := filepath.Rel(s.Root, filepath.Dir(s.Path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "."
+	}
+	return rel
+}
+
+// CollectSources expands files, directories (recursively) and glob patterns to files with specified extensions.
+// Duplicates are skipped. Returns error if argument matches nothing.
+func CollectSources(args []string, extensions ...string) ([]Source, error) {
+	var sources []Source
+	seen := make(map[string]bool)
+
+	add := func(path, root string) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if seen[abs] {
+			return
+		}
+		seen[abs] = true
+		sources = append(sources, Source{Path: path, Root: root})
+	}
+
+	for _, arg := range args {
+		paths := []string{arg}
+		root := ""
+
+		if IsGlobPattern(arg) {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob pattern %q: %w", arg, err)
+			}
+			paths = matches
+			root = globRoot(arg)
+		}
+
+		found := false
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+
+			if info.IsDir() {
+				dirRoot := root
+				if dirRoot == "" {
+					dirRoot = path
+				}
+
+				filenames, err := FindFilesOfExtensionsRecursiveFlatten(path, extensions...)
+				if err != nil {
+					return nil, err
+				}
+				slices.Sort(filenames)
+				for _, filename := range filenames {
+					add(filename, dirRoot)
+					found = true
+				}
+				continue
+			}
+
+			if CheckFileExtension(path, extensions...) {
+				fileRoot := root
+				if fileRoot == "" {
+					fileRoot = filepath.Dir(path)
+				}
+				add(path, fileRoot)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("no files with extensions %v found for %q", extensions, arg)
+		}
+	}
+
+	return sources, nil
+}
+
+// IsGlobPattern reports whether path contains glob meta characters.
+func IsGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// globRoot returns directory part of glob pattern without meta characters
+func globRoot(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for IsGlobPattern(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
@@ -0,0 +1,126 @@
+package files_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sourcesTree creates sprites tree in temporary directory and returns its path
+func sourcesTree(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"sprites/hero.aseprite",
+		"sprites/readme.txt",
+		"sprites/enemies/slime.aseprite",
+		"sprites/enemies/bat.ase",
+		"other/boss.aseprite",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, nil, 0644))
+	}
+	return dir
+}
+
+func TestCollectSources(t *testing.T) {
+	dir := sourcesTree(t)
+	path := func(name string) string { return filepath.Join(dir, filepath.FromSlash(name)) }
+
+	// source is path, root and RelDir relative to dir
+	type source struct{ path, root, relDir string }
+	tests := []struct {
+		name string
+		args []string
+		want []source
+	}{
+		{"file", []string{"sprites/hero.aseprite"}, []source{
+			{"sprites/hero.aseprite", "sprites", "."},
+		}},
+		{"directory", []string{"sprites"}, []source{
+			{"sprites/enemies/bat.ase", "sprites", "enemies"},
+			{"sprites/enemies/slime.aseprite", "sprites", "enemies"},
+			{"sprites/hero.aseprite", "sprites", "."},
+		}},
+		{"glob", []string{"*/*/*.aseprite"}, []source{
+			{"sprites/enemies/slime.aseprite", "", "sprites/enemies"},
+		}},
+		{"glob of directories", []string{"sprites/ene*"}, []source{
+			{"sprites/enemies/bat.ase", "sprites", "enemies"},
+			{"sprites/enemies/slime.aseprite", "sprites", "enemies"},
+		}},
+		{"duplicates", []string{"sprites/hero.aseprite", "sprites", "sprites/../sprites/enemies/bat.ase", "other"}, []source{
+			{"sprites/hero.aseprite", "sprites", "."},
+			{"sprites/enemies/bat.ase", "sprites", "enemies"},
+			{"sprites/enemies/slime.aseprite", "sprites", "enemies"},
+			{"other/boss.aseprite", "other", "."},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			for _, arg := range tt.args {
+				args = append(args, path(arg))
+			}
+
+			sources, err := files.CollectSources(args, ".aseprite", "ase")
+			require.NoError(t, err)
+
+			var want []files.Source
+			for _, s := range tt.want {
+				want = append(want, files.Source{Path: path(s.path), Root: path(s.root)})
+			}
+			assert.Equal(t, want, sources)
+
+			for i, s := range sources {
+				assert.Equal(t, filepath.FromSlash(tt.want[i].relDir), s.RelDir(), s.Path)
+			}
+		})
+	}
+}
+
+func TestCollectSourcesErrors(t *testing.T) {
+	dir := sourcesTree(t)
+
+	tests := []struct {
+		name    string
+		arg     string
+		wantErr string
+	}{
+		{"missing file", "sprites/missing.aseprite", "no files with extensions"},
+		{"other extension", "sprites/readme.txt", "no files with extensions"},
+		{"glob without matches", "sprites/*.gif", "no files with extensions"},
+		{"directory without sprites", "empty", "no files with extensions"},
+		{"invalid glob", "sprites/[", "invalid glob pattern"},
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "empty"), 0755))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arg := filepath.Join(dir, filepath.FromSlash(tt.arg))
+			_, err := files.CollectSources([]string{filepath.Join(dir, "sprites"), arg}, ".aseprite")
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.ErrorContains(t, err, tt.arg)
+		})
+	}
+}
+
+func TestSourceRelDir(t *testing.T) {
+	tests := []struct {
+		source files.Source
+		want   string
+	}{
+		{files.Source{Path: "sprites/hero.aseprite", Root: "sprites"}, "."},
+		{files.Source{Path: "sprites/enemies/boss/slime.aseprite", Root: "sprites"}, filepath.Join("enemies", "boss")},
+		// source out of root is not mirrored above output directory
+		{files.Source{Path: "other/boss.aseprite", Root: "sprites"}, "."},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.source.RelDir(), tt.source.Path)
+	}
+}
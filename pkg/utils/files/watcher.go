@@ -0,0 +1,159 @@
+package files
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher reports changes of files with specified extensions under watched sources.
+// Bursts of events are debounced and reported as one batch of changed paths.
+type Watcher struct {
+	watcher    *fsnotify.Watcher
+	extensions []string
+	delay      time.Duration
+	// recursive are roots which new subdirectories are watched too
+	recursive []string
+}
+
+// NewWatcher watches directories of sources arguments (as accepted by CollectSources):
+// directories and glob roots recursively, directories of files non recursively
+func NewWatcher(args []string, delay time.Duration, extensions ...string) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{watcher: fsWatcher, extensions: extensions, delay: delay}
+
+	for _, arg := range args {
+		dir, recursive := watchRoot(arg)
+		if recursive {
+			w.recursive = append(w.recursive, dir)
+			err = w.addRecursive(dir)
+		} else {
+			err = fsWatcher.Add(dir)
+		}
+
+		if err != nil {
+			fsWatcher.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
+
+// Run calls changed with absolute paths of created, written, removed or renamed files until ctx is done
+// or watcher is closed. Watching errors (e.g. events overflow) are passed to failed and do not stop watching
+func (w *Watcher) Run(ctx context.Context, changed func(paths []string), failed func(err error)) {
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.delay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			failed(err)
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Has(fsnotify.Create) && w.underRecursiveRoot(event.Name) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					// files could be created before directory is watched, so they are reported by walk
+					_ = w.addRecursive(event.Name)
+					_ = filepath.WalkDir(event.Name, func(path string, d fs.DirEntry, err error) error {
+						if err == nil && !d.IsDir() && CheckFileExtension(path, w.extensions...) {
+							pending[absPath(path)] = true
+						}
+						return nil
+					})
+					timer.Reset(w.delay)
+					continue
+				}
+			}
+
+			if event.Op == fsnotify.Chmod || !CheckFileExtension(event.Name, w.extensions...) {
+				continue
+			}
+
+			pending[absPath(event.Name)] = true
+			timer.Reset(w.delay)
+
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			clear(pending)
+
+			changed(paths)
+		}
+	}
+}
+
+func (w *Watcher) addRecursive(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+func (w *Watcher) underRecursiveRoot(path string) bool {
+	for _, root := range w.recursive {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// watchRoot returns directory to watch for source argument and whether its subdirectories are watched
+func watchRoot(arg string) (string, bool) {
+	if IsGlobPattern(arg) {
+		return globRoot(arg), true
+	}
+
+	info, err := os.Stat(arg)
+	if err == nil && info.IsDir() {
+		return arg, true
+	}
+
+	return filepath.Dir(arg), false
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
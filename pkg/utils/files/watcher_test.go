@@ -0,0 +1,73 @@
+package files_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spinozanilast/aseprite-assets-cli/pkg/utils/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watch runs watcher of dir sources until test ends and returns channel of changed paths batches
+func watch(t *testing.T, dir string) <-chan []string {
+	watcher, err := files.NewWatcher([]string{dir}, 20*time.Millisecond, ".aseprite")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := make(chan []string, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watcher.Run(ctx, func(paths []string) { batches <- paths }, func(err error) { t.Error(err) })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		watcher.Close()
+	})
+	return batches
+}
+
+func nextBatch(t *testing.T, batches <-chan []string) []string {
+	select {
+	case paths := <-batches:
+		return paths
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported")
+		return nil
+	}
+}
+
+func TestWatcherReportsChangedSprites(t *testing.T) {
+	dir, err := filepath.Abs(t.TempDir())
+	require.NoError(t, err)
+	batches := watch(t, dir)
+
+	sprite := filepath.Join(dir, "hero.aseprite")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("skip"), 0644))
+	require.NoError(t, os.WriteFile(sprite, []byte("v1"), 0644))
+	require.NoError(t, os.WriteFile(sprite, []byte("v2"), 0644))
+	assert.Equal(t, []string{sprite}, nextBatch(t, batches), "burst of events is one batch without other extensions")
+
+	require.NoError(t, os.Remove(sprite))
+	assert.Equal(t, []string{sprite}, nextBatch(t, batches))
+}
+
+func TestWatcherReportsSpritesOfNewDirectories(t *testing.T) {
+	dir, err := filepath.Abs(t.TempDir())
+	require.NoError(t, err)
+	batches := watch(t, dir)
+
+	nested := filepath.Join(dir, "enemies", "slime.aseprite")
+	require.NoError(t, os.MkdirAll(filepath.Dir(nested), 0755))
+	require.NoError(t, os.WriteFile(nested, []byte("v1"), 0644))
+	assert.Equal(t, []string{nested}, nextBatch(t, batches))
+
+	require.NoError(t, os.WriteFile(nested, []byte("v2"), 0644))
+	assert.Equal(t, []string{nested}, nextBatch(t, batches), "new directories are watched too")
+}
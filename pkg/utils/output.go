@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"io"
+
 	"github.com/fatih/color"
 )
 
@@ -16,6 +18,14 @@ func PrintlnBold(message string) {
 	color.New(color.Bold).Println(message)
 }
 
+func FprintlnBold(w io.Writer, message string) {
+	color.New(color.Bold).Fprintln(w, message)
+}
+
+func PrintlnWarning(message string) {
+	color.New(color.FgYellow).Println(message)
+}
+
 func PrintError(message string) {
 	color.New(color.FgRed).Println(message)
 }
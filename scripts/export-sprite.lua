@@ -6,20 +6,31 @@ Exports sprites with scaling/sizing options while maintaining original file stru
 Features:
 - Multiple export scales (1x, 2x, etc.)
 - Multiple export sizes (64x64, 128x128, etc.)
-- Automatic filename generation
+- Output filename templates ({tag}, {layer}, {frame}, {frame:03}, {scale}, {size})
+- Export by frame tags (one output per tag) honouring tag direction
+- Split exports: file per visible layer (groups are not split, their layers are) and/or file per frame
+- Layers selection (group aware), original layers visibility is restored even on failure
 - Proper resource cleanup
 
 Usage:
 Requires Aseprite CLI parameters:
   --sprite-filename  Input sprite path
-  --output-filename  Base output path
+  --output-filename  Output path, may contain template placeholders
+                     (template with {frame} placeholder writes every frame to its own file)
   --format           Output file format
   --sizes            Comma-separated size list (WxH)
   --scales           Comma-separated scale factors
   --frames_included range of included frames to export
+  --tags             Comma-separated tag names (each tag is exported to its own output)
+  --visible-layers   Comma-separated zero based layer indices (layers tree order) shown during export,
+                     other layers are hidden
+  --split-layers     "true" to export every visible image or tilemap layer to its own file ({layer} placeholder,
+                     layers of groups are named by path joined with "-", e.g. "body-arm")
+  --split-frames     "true" to export every frame to its own file ({frame} placeholder)
 ]]
 
 local FRAMES_INCLUDED_SEPARATOR = ":"
+local FRAME_PLACEHOLDER = "{frame"
 
 local sprite_filename = app.params["sprite-filename"]
 local output_filename = app.params["output-filename"]
@@ -31,6 +42,18 @@ local frames_included = app.params["frames-included"]
 local selected_layer = app.params["layer-selected"]
 local sizes = app.params["sizes"]
 local scales = app.params["scales"]
+local tags = app.params["tags"]
+local visible_layers = app.params["visible-layers"]
+local split_layers = app.params["split-layers"] == "true"
+local split_frames = app.params["split-frames"] == "true"
+
+-- SaveFileCopyAs aniDir values for sprite tag directions (Aseprite names, unknown names are exported forward)
+local TAG_DIRECTIONS = {
+    [AniDir.FORWARD] = "forward",
+    [AniDir.REVERSE] = "reverse",
+    [AniDir.PING_PONG] = "pingpong",
+    [AniDir.PING_PONG_REVERSE] = "pingpong_reverse",
+}
 
 local function validate_parameters()
     if not sprite_filename and not sprite_filename then
@@ -108,35 +131,94 @@ local function generate_output_path(base_path, suffix, ext, separator)
     return string.format("%s%s%s%s", name, separator, suffix, extension)
 end
 
-local function save_scaled_versions(sprite, base_path, sizes_set, from_frame, to_frame)
+-- render_output replaces known placeholders ({tag}, {frame:03}, ...) with values,
+-- unknown placeholders are kept as is
+local function render_output(template, vars)
+    return (string.gsub(template, "{(%w+):?(%d*)}", function(key, width)
+        local value = vars[key]
+        if value == nil then
+            return nil
+        end
+        if width ~= "" then
+            return string.format("%0" .. tonumber(width) .. "d", value)
+        end
+        return tostring(value)
+    end))
+end
+
+-- frames_range describes exported frames for explicit range or tag
+local function frames_range(from_frame, to_frame, tag)
+    if tag then
+        return {
+            from_frame = tag.fromFrame.frameNumber - 1,
+            to_frame = tag.toFrame.frameNumber - 1,
+            params = { tag = tag.name, aniDir = TAG_DIRECTIONS[tag.aniDir] },
+        }
+    end
+    return {
+        from_frame = from_frame,
+        to_frame = to_frame,
+        params = { fromFrame = from_frame, toFrame = to_frame },
+    }
+end
+
+local function save_copy(filename, params, extra)
+    local copy_params = { filename = filename }
+    for key, value in pairs(params) do
+        copy_params[key] = value
+    end
+    for key, value in pairs(extra or {}) do
+        copy_params[key] = value
+    end
+    app.command.SaveFileCopyAs(copy_params)
+end
+
+-- save_range saves frames range to rendered output, template with {frame} placeholder
+-- produces separate file for every frame of range
+local function save_range(template, vars, range, extra)
+    if not split_frames and not string.find(template, FRAME_PLACEHOLDER, 1, true) then
+        local output_path = render_output(template, vars)
+        print(output_path)
+        save_copy(output_path, range.params, extra)
+        return
+    end
+
+    for frame = range.from_frame, range.to_frame do
+        vars.frame = frame
+        local output_path = render_output(template, vars)
+        print(output_path)
+        save_copy(output_path, { fromFrame = frame, toFrame = frame }, extra)
+    end
+    vars.frame = nil
+end
+
+local function find_tag(sprite, name)
+    for _, tag in ipairs(sprite.tags) do
+        if tag.name == name then
+            return tag
+        end
+    end
+    error("Tag not found: " .. name)
+end
+
+local function save_scaled_versions(sprite, template, scales_set, export)
     local original_sprite = app.sprite
     app.sprite = sprite
 
-    for _, scale in ipairs(sizes_set) do
+    for _, scale in ipairs(scales_set) do
         local numeric_scale = tonumber(scale)
         if not numeric_scale or numeric_scale <= 0 then
             error("Invalid scale factor: " .. scale)
         end
 
-        local output_path = generate_output_path(
-                base_path,
-                string.format("%dx", numeric_scale),
-                format
-        )
-
-        print(output_path)
-        app.command.SaveFileCopyAs {
-            filename = output_path,
-            scale = numeric_scale,
-            fromFrame = from_frame,
-            toFrame = to_frame
-        }
+        export.vars.scale = scale
+        save_range(template, export.vars, export.range, { scale = numeric_scale })
     end
 
     app.sprite = original_sprite
 end
 
-local function save_sized_versions(sprite, base_path, sizes_set, from_frame, to_frame)
+local function save_sized_versions(sprite, template, sizes_set, export)
     local original_sprite = app.sprite
 
     for _, size in ipairs(sizes_set) do
@@ -152,18 +234,8 @@ local function save_sized_versions(sprite, base_path, sizes_set, from_frame, to_
         app.sprite = resized_sprite
         resized_sprite:resize(width, height)
 
-        local output_path = generate_output_path(
-                base_path,
-                string.format("%dx%d", width, height),
-                format
-        )
-
-        print(output_path)
-        app.command.SaveFileCopyAs {
-            filename = output_path,
-            fromFrame = from_frame,
-            toFrame = to_frame
-        }
+        export.vars.size = string.format("%dx%d", width, height)
+        save_range(template, export.vars, export.range)
 
         resized_sprite:close()
     end
@@ -171,12 +243,74 @@ local function save_sized_versions(sprite, base_path, sizes_set, from_frame, to_
     app.sprite = original_sprite
 end
 
-local function toggle_other_layers_visibility(sprite, selected_layer_name, visible)
-    for _, layer in ipairs(sprite.layers) do
-        if layer.name ~= selected_layer_name then
-            layer.isVisible = visible
+-- flatten_layers lists layers in file order (bottom to top, groups before their children)
+-- with parent group entry, split name (path joined with "-") and visibility in layers tree
+local function flatten_layers(layers, parent, parent_shown, list)
+    for _, layer in ipairs(layers) do
+        local shown = parent_shown and layer.isVisible
+        local entry = {
+            layer = layer,
+            parent = parent,
+            name = parent and (parent.name .. "-" .. layer.name) or layer.name,
+            shown = shown,
+        }
+        table.insert(list, entry)
+        if layer.isGroup then
+            flatten_layers(layer.layers, entry, shown, list)
+        end
+    end
+    return list
+end
+
+-- visible_indices collects layers currently visible in sprite
+local function visible_indices(entries)
+    local set = {}
+    for index, entry in ipairs(entries) do
+        if entry.shown then
+            set[index - 1] = true
         end
     end
+    return set
+end
+
+local function parse_indices(input)
+    local set = {}
+    for index in string.gmatch(input or "", "(%d+)") do
+        set[tonumber(index)] = true
+    end
+    return set
+end
+
+-- show_layers makes visible only layers of visible set (optionally only split layer with its parent groups)
+local function show_layers(entries, visible_set, split)
+    local branch = {}
+    local cur = split
+    while cur do
+        branch[cur] = true
+        cur = cur.parent
+    end
+
+    for index, entry in ipairs(entries) do
+        entry.layer.isVisible = visible_set[index - 1] == true and (split == nil or branch[entry] == true)
+    end
+end
+
+-- with_layers_visibility runs action and always restores original visibility of layers
+local function with_layers_visibility(entries, action)
+    local original = {}
+    for index, entry in ipairs(entries) do
+        original[index] = entry.layer.isVisible
+    end
+
+    local ok, err = pcall(action)
+
+    for index, entry in ipairs(entries) do
+        entry.layer.isVisible = original[index]
+    end
+
+    if not ok then
+        error(err, 0)
+    end
 end
 
 local function main()
@@ -198,48 +332,79 @@ local function main()
         error("Failed to load sprite: " .. sprite_filename)
     end
 
-    -- **Handle layer selection**
-    local restore_visibility = false
-    if selected_layer and selected_layer ~= "" then
-        toggle_other_layers_visibility(sprite, selected_layer, false)
-        restore_visibility = true
-    end
-
-    local base_output = output_filename or
+    local template = output_filename or
             generate_output_path(sprite_filename, "", format, "")
 
     local total_frames = #sprite.frames
     local from_frame, to_frame = parse_frames(frames_included, total_frames)
 
-    if not sizes and not scales then
-        app.command.SaveFileCopyAs {
-            filename = base_output,
-            fromFrame = from_frame,
-            toFrame = to_frame
-        }
-    elseif scales then
-        local scale_list = parse_comma_list(scales, function(s)
-            return tonumber(s) and true or false
-        end)
-        save_scaled_versions(sprite, base_output, scale_list, from_frame, to_frame)
+    -- defaults for placeholders of not requested dimensions
+    local default_vars = {
+        layer = selected_layer or "",
+        scale = "1",
+        size = string.format("%dx%d", sprite.width, sprite.height),
+    }
+
+    local exports = {}
+    local tag_names = parse_comma_list(tags, function(t)
+        return t ~= ""
+    end)
+    if tag_names then
+        for _, tag_name in ipairs(tag_names) do
+            local tag = find_tag(sprite, tag_name)
+            table.insert(exports, { tag = tag_name, range = frames_range(nil, nil, tag) })
+        end
     else
-        local size_list = parse_comma_list(sizes, function(s)
-            return s:match("^%d+x%d+$") and true or false
-        end)
-        save_sized_versions(sprite, base_output, size_list, from_frame, to_frame)
+        table.insert(exports, { tag = "", range = frames_range(from_frame, to_frame) })
     end
 
-    -- **Restore layer visibility**
-    if restore_visibility then
-        toggle_other_layers_visibility(sprite, selected_layer, true)
+    local function save_exports(layer_name)
+        for _, export in ipairs(exports) do
+            export.vars = { tag = export.tag }
+            for key, value in pairs(default_vars) do
+                export.vars[key] = value
+            end
+            export.vars.layer = layer_name or export.vars.layer
+
+            if not sizes and not scales then
+                save_range(template, export.vars, export.range)
+            elseif scales then
+                local scale_list = parse_comma_list(scales, function(s)
+                    return tonumber(s) and true or false
+                end)
+                save_scaled_versions(sprite, template, scale_list, export)
+            else
+                local size_list = parse_comma_list(sizes, function(s)
+                    return s:match("^%d+x%d+$") and true or false
+                end)
+                save_sized_versions(sprite, template, size_list, export)
+            end
+        end
     end
 
+    local entries = flatten_layers(sprite.layers, nil, true, {})
+    local visible_set = visible_layers and parse_indices(visible_layers) or visible_indices(entries)
+
+    with_layers_visibility(entries, function()
+        if split_layers then
+            -- every visible image or tilemap layer is split, groups are shown with their layers
+            for index, entry in ipairs(entries) do
+                if visible_set[index - 1] and not entry.layer.isGroup then
+                    show_layers(entries, visible_set, entry)
+                    save_exports(entry.name)
+                end
+            end
+        else
+            show_layers(entries, visible_set)
+            save_exports()
+        end
+    end)
+
     sprite:close()
 end
 
 local function error_handler(error)
-    print("Error during export:\n%s", error)
+    print("Error during export:\n" .. tostring(error))
 end
 
 xpcall(main, error_handler)
-